@@ -4,8 +4,15 @@
 package cli
 
 import (
+	"context"
 	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"syscall"
 )
 
 type (
@@ -30,10 +37,21 @@ type (
 	//
 	// when helpArgAt >= 0, args[helpArgAt] is the arg initiated the help
 	// request, in this case return nil error will be replaced with
-	// ErrHelpRequestHandled{}.
+	// ErrHelpRequestHandled{}, and HelpVerbosityOf tells the verbosity
+	// requested.
 	HelpHandleFunc = func(
 		opts *CmdOptions, route Route, args []string, helpArgAt int,
 	) error
+
+	// VersionHandleFunc for handling version requests.
+	//
+	// args[versionArgAt] is the `--version` arg, route only contains the
+	// root Cmd.
+	//
+	// Return nil error will be replaced with ErrVersionHandled{}.
+	VersionHandleFunc = func(
+		opts *CmdOptions, route Route, args []string, versionArgAt int,
+	) error
 )
 
 type (
@@ -74,6 +92,11 @@ type CmdOptions struct {
 	// RouteBuf is the buffer for building the Cmd route.
 	RouteBuf Route
 
+	// Context is the context of the cmd execution.
+	//
+	// Defaults to nil.
+	Context context.Context
+
 	// Stdin is the stdin of the cmd.
 	//
 	// Defaults to nil.
@@ -99,6 +122,26 @@ type CmdOptions struct {
 	// CmdOptions.HandleHelpRequest are nil, no help will be provided.
 	HandleHelpRequest HelpHandleFunc
 
+	// HandleVersionRequest handles the `--version` arg parsed as a flag of
+	// the root Cmd (before the first positional arg and the dash), it is
+	// called by Cmd.Exec before resolving the target.
+	//
+	// It is not called when the root Cmd has its own `version` flag.
+	//
+	// Hint: use (*VersionCmd).HandleVersionRequest.
+	//
+	// Defaults to nil (`--version` is parsed as a normal flag).
+	HandleVersionRequest VersionHandleFunc
+
+	// FormatError writes usage errors to out (the Stderr), it is called
+	// when Cmd.Exec is about to return such error not handled by
+	// HandleArgError.
+	//
+	// Set it to DefaultFormatError for user-friendly error messages.
+	//
+	// Defaults to nil (do not write errors).
+	FormatError func(err error, out io.Writer)
+
 	// Extra custom data.
 	Extra any
 
@@ -107,6 +150,116 @@ type CmdOptions struct {
 
 	// DoNotSetFlags skips setting flag values.
 	DoNotSetFlags bool
+
+	// HandleLineError is called by Cmd.ExecLines when a line failed, return
+	// nil to continue with the next line.
+	//
+	// Defaults to nil (stop at the first error).
+	HandleLineError func(lineno int, line string, err error) error
+
+	// ResetFlagsPerLine tells Cmd.ExecLines to reset flags (see ResetFlags)
+	// of all commands before executing each line except the first one.
+	ResetFlagsPerLine bool
+
+	// EnvPrefix enables loading flag values from environment variables
+	// before assigning default values in Cmd.Exec when not empty, see
+	// LoadFlagsFromEnv for details.
+	EnvPrefix string
+
+	// AllowExperimental suppresses the warning written by Cmd.Exec before
+	// running commands with CmdStateExperimental, the warning is also
+	// suppressed when EnvPrefix is not empty and the environment variable
+	// `<EnvPrefix>_ALLOW_EXPERIMENTAL` is set to a true value.
+	AllowExperimental bool
+
+	// Executable is the path to the invoked executable, it is used by
+	// Cmd.Exec to pick the root command from Cmd.MultiCall.
+	//
+	// Defaults to "" (use os.Args[0]).
+	Executable string
+
+	// values set by SetValue.
+	values map[any]any
+}
+
+// SetValue stores val with key for later retrieval by Value, it is meant
+// for sharing state between commands in the same route (e.g. a parent's
+// PreRun stashes the loaded config for a child's Run).
+//
+// Like context.WithValue, the key SHOULD be of a user-defined type to
+// avoid collisions.
+func (c *CmdOptions) SetValue(key, val any) {
+	if c.values == nil {
+		c.values = make(map[any]any)
+	}
+
+	c.values[key] = val
+}
+
+// Value returns the value stored by SetValue with key, it returns nil if
+// there is no such key.
+func (c *CmdOptions) Value(key any) any {
+	if c == nil {
+		return nil
+	}
+
+	return c.values[key]
+}
+
+// warnExperimental writes a warning to the stderr once per experimental
+// Cmd in route unless experimental commands are allowed.
+func (c *CmdOptions) warnExperimental(route Route) {
+	var exp *Cmd
+	for _, x := range route {
+		if x.State.Experimental() {
+			exp = x
+			break
+		}
+	}
+
+	if exp == nil || exp.State&CmdStateExperimentalWarned != 0 || c.AllowExperimental {
+		return
+	}
+
+	if len(c.EnvPrefix) != 0 {
+		value, _ := c.ParseOptions.lookupEnv(c.EnvPrefix + "_ALLOW_EXPERIMENTAL")
+		if allow, err := strconv.ParseBool(value); err == nil && allow {
+			return
+		}
+	}
+
+	exp.State |= CmdStateExperimentalWarned
+	_, _ = wstr(c.PickStderr(os.Stderr), "warning: command "+exp.Name()+
+		" is experimental and may change or be removed\n")
+}
+
+// executable returns c.Executable, or os.Args[0] if it is empty.
+func (c *CmdOptions) executable() string {
+	if c != nil && len(c.Executable) != 0 {
+		return c.Executable
+	}
+
+	if len(os.Args) == 0 {
+		return ""
+	}
+
+	return os.Args[0]
+}
+
+// PickContext returns def if c.Context is nil, it returns
+// context.Background() if all of them are nil.
+func (c *CmdOptions) PickContext(def ...context.Context) context.Context {
+	if c != nil && c.Context != nil {
+		return c.Context
+	}
+
+	for _, ctx := range def {
+		if ctx != nil {
+			return ctx
+		}
+	}
+
+	return context.Background()
 }
 
 // PickStdin returns def if c.Stdin is nil.
@@ -154,6 +307,15 @@ func (c *CmdOptions) PickStderr(def ...io.Writer) io.Writer {
 	return nil
 }
 
+// formatError calls c.FormatError with stderr if set.
+func (c *CmdOptions) formatError(err error) {
+	if c == nil || c.FormatError == nil || err == nil {
+		return
+	}
+
+	c.FormatError(err, c.PickStderr(os.Stderr))
+}
+
 type CmdState uint32
 
 const (
@@ -166,6 +328,12 @@ const (
 	// CmdStatePostRunOnce to require the PostRun only gets called once.
 	CmdStatePostRunOnce
 	CmdStatePostRunCalled
+
+	// CmdStateExperimental marks the cmd and its children experimental,
+	// Cmd.Exec warns before running them (see CmdOptions.AllowExperimental)
+	// and completion annotates them.
+	CmdStateExperimental
+	CmdStateExperimentalWarned
 )
 
 func (s CmdState) Hidden() bool        { return s&CmdStateHidden != 0 }
@@ -173,6 +341,7 @@ func (s CmdState) PreRunOnce() bool    { return s&CmdStatePreRunOnce != 0 }
 func (s CmdState) PreRunCalled() bool  { return s&CmdStatePreRunCalled != 0 }
 func (s CmdState) PostRunOnce() bool   { return s&CmdStatePostRunOnce != 0 }
 func (s CmdState) PostRunCalled() bool { return s&CmdStatePostRunCalled != 0 }
+func (s CmdState) Experimental() bool  { return s&CmdStateExperimental != 0 }
 
 // AnyMaybeHelperTerminal is an alias of `any` and indicates
 // some component will try to cast the value as a HelperTerminal.
@@ -205,6 +374,14 @@ type Cmd struct {
 	// BriefUsage introduces the command briefly.
 	BriefUsage string
 
+	// Examples are typical invocations of the command, each shown on its own
+	// line in the `Examples:` section of the help text.
+	Examples []string
+
+	// SeeAlso references related commands or documents, each shown on its
+	// own line in the `See Also:` section of the help text.
+	SeeAlso []string
+
 	// Flags are flags accessible from both this Cmd and all its children.
 	Flags FlagFinderMaybeIter
 
@@ -243,16 +420,82 @@ type Cmd struct {
 	// command.
 	Completion CompAction
 
+	// PosArgCompletion are shell completion helpers for positional args,
+	// PosArgCompletion[i] suggests the i-th positional arg when there is no
+	// matching sub-command.
+	PosArgCompletion []CompAction
+
+	// DashArgsCompletion is the shell completion helper for args after the
+	// dash (`--`), when set, flag names and sub-commands are not suggested
+	// after the dash.
+	DashArgsCompletion CompAction
+
+	// DashArgsFiles makes completion suggest files for args after the dash
+	// (`--`) when DashArgsCompletion is nil.
+	DashArgsFiles bool
+
+	// NoPosArgs makes Cmd.Exec return ErrUnexpectedPosArgs when there is
+	// any positional arg for this Cmd as the target.
+	//
+	// The error is passed to CmdOptions.HandleArgError if set, return nil
+	// from there to ignore it.
+	NoPosArgs bool
+
 	// Extra stores application specific custom data.
 	Extra AnyMaybeHelperTerminal
 
 	// Children are sub-commands beloning to this Cmd.
 	Children []*Cmd
 
+	// MatchChild is an optional hook to pick the sub-command by the arg,
+	// return nil to fallback to the default matching (by child Pattern).
+	//
+	// In Cmd.ResolveTarget, it is called with the arg right after this Cmd
+	// before parsing flags, so the arg can be a token otherwise parsed as
+	// flag (e.g. `-plugin`), and then called with the first positional arg
+	// after flags.
+	//
+	// The returned Cmd is not required to be one of the Children.
+	MatchChild func(arg string) *Cmd
+
+	// UnknownCommandFunc, when set on a Cmd without Run, is called by
+	// Cmd.Exec instead of failing with ErrCmdNotRunnable when the first
+	// positional arg matches no child (e.g. to dispatch `tool foo` to an
+	// external executable `tool-foo`), PostRun hooks are called after it the
+	// same way as after Run.
+	//
+	// The argument `name` is the unmatched arg, and `args` are all args
+	// after it, unparsed (flags and dash included). Cmd.ResolveTarget stops
+	// at the unmatched arg and returns it with `args` as posArgs.
+	UnknownCommandFunc func(opts *CmdOptions, route Route, name string, args []string) error
+
+	// MultiCall maps names of the invoked executable to commands used as the
+	// root instead of this Cmd, for multi-call binaries dispatching on the
+	// executable name (e.g. a symlink `ls` to the `busybox` executable).
+	//
+	// Keys are matched against the base name of the executable path with
+	// `.exe` suffix trimmed, see CmdOptions.Executable and CompTask.Init.
+	MultiCall map[string]*Cmd
+
 	// State is Cmd's current state.
 	State CmdState
 }
 
+// MultiCallTarget returns the Cmd in c.MultiCall matching the base name of
+// executable, it returns c if there is no match.
+func (c *Cmd) MultiCallTarget(executable string) *Cmd {
+	if len(c.MultiCall) == 0 || len(executable) == 0 {
+		return c
+	}
+
+	name := strings.TrimSuffix(filepath.Base(executable), ".exe")
+	if target, ok := c.MultiCall[name]; ok && target != nil {
+		return target
+	}
+
+	return c
+}
+
 // Name returns the first name in Pattern of this Cmd.
 func (c *Cmd) Name() (name string) {
 	name, _, _ = strings.Cut(c.Pattern, " ")
@@ -284,6 +527,58 @@ func pick(fns ...HelpHandleFunc) HelpHandleFunc {
 	return nil
 }
 
+// findChild returns the child command matching name, it returns nil if not
+// found.
+func (c *Cmd) findChild(name string) *Cmd {
+	if c.MatchChild != nil {
+		if child := c.MatchChild(name); child != nil {
+			return child
+		}
+	}
+
+	for _, child := range c.Children {
+		if child != nil && child.Is(name) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// VisitAll calls fn with the route to each Cmd in the Cmd tree in depth-first
+// order, starting from c (as the root).
+//
+// It stops when fn returns a non-nil error and returns that error, or
+// returns an *ErrCommandCycle when a Cmd is found among its own descendants.
+func (c *Cmd) VisitAll(fn func(route Route) error) error {
+	return c.visitAll(nil, fn)
+}
+
+func (c *Cmd) visitAll(route Route, fn func(route Route) error) error {
+	if route.contains(c) {
+		return &ErrCommandCycle{Name: c.Name()}
+	}
+
+	route = route.Push(c)
+	err := fn(route)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range c.Children {
+		if child == nil {
+			continue
+		}
+
+		err = child.visitAll(route, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ResolveTarget walks the Cmd tree from c to the target Cmd by parsing args.
 //
 // On a successful return, the `route` leads to the target Cmd with this Cmd
@@ -320,6 +615,17 @@ func (c *Cmd) ResolveTarget(opts *CmdOptions, args ...string) (
 			return false
 		}
 
+		// `--help build` requests help for the child command `build`.
+		for i := helpArgAt + 1; i < len(args); i++ {
+			child := c.findChild(args[i])
+			if child == nil || route.contains(child) {
+				break
+			}
+
+			c = child
+			route = route.Push(c)
+		}
+
 		if handleHelp := pick(c.Help, fallbackHelp); handleHelp != nil {
 			if err = handleHelp(opts, route, args, helpArgAt); err == nil {
 				err = ErrHelpHandled{}
@@ -340,6 +646,8 @@ func (c *Cmd) ResolveTarget(opts *CmdOptions, args ...string) (
 		}
 
 		if handleArgErr == nil {
+			opts.formatError(err)
+
 			if handleHelp := pick(c.Help, fallbackHelp); handleHelp != nil {
 				if helpArgAt >= 0 {
 					_ = handleHelp(opts, route, args, helpArgAt)
@@ -360,7 +668,20 @@ func (c *Cmd) ResolveTarget(opts *CmdOptions, args ...string) (
 	}
 
 	protue := noescape(&route)
-	for route = route.Push(c); len(c.Children) != 0; route = route.Push(c) {
+	for route = route.Push(c); len(c.Children) != 0 || c.MatchChild != nil || c.UnknownCommandFunc != nil; route = route.Push(c) {
+		if c.MatchChild != nil && offset < len(args) {
+			if child := c.MatchChild(args[offset]); child != nil {
+				if route.contains(child) {
+					err = &ErrCommandCycle{Name: child.Name()}
+					return
+				}
+
+				c = child
+				offset++
+				continue
+			}
+		}
+
 		var foundPosArgs bool
 		nParsed, _, foundPosArgs, _, helpArgAt, err = ParseFlagsLowLevel(
 			args, protue, popts,
@@ -384,18 +705,16 @@ func (c *Cmd) ResolveTarget(opts *CmdOptions, args ...string) (
 			break
 		}
 
-		expectedName := args[offset]
-		noSuchCmd := true
-		for _, child := range c.Children {
-			if child.Is(expectedName) {
-				c = child
-				noSuchCmd = false
-				break
+		matched := c.findChild(args[offset])
+		if matched == nil {
+			if helpRequested() {
+				return
 			}
-		}
 
-		if noSuchCmd {
-			if helpRequested() {
+			if c.Run == nil && c.UnknownCommandFunc != nil {
+				// leave the unknown command and all args after it to
+				// UnknownCommandFunc.
+				posArgs = append(posArgs, args[offset:]...)
 				return
 			}
 
@@ -403,6 +722,13 @@ func (c *Cmd) ResolveTarget(opts *CmdOptions, args ...string) (
 			break
 		}
 
+		if route.contains(matched) {
+			err = &ErrCommandCycle{Name: matched.Name()}
+			return
+		}
+
+		c = matched
+
 		if helpRequested() {
 			return
 		}
@@ -433,46 +759,145 @@ func (c *Cmd) ResolveTarget(opts *CmdOptions, args ...string) (
 	return
 }
 
+// ParseArgs resolves the target Cmd and sets flag values like Exec does
+// (including default values and FlagRule checking), but never calls PreRun,
+// Run or PostRun.
+//
+// CmdOptions.DoNotSetFlags is ignored.
+func (c *Cmd) ParseArgs(opts *CmdOptions, args ...string) (
+	route Route, posArgs, dashArgs []string, err error,
+) {
+	var o CmdOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.DoNotSetFlags = false
+
+	route, posArgs, dashArgs, err = c.ResolveTarget(&o, args...)
+	if err != nil {
+		return
+	}
+
+	var (
+		proute    = noescape(&route)
+		defaulted []Flag
+	)
+	for _, x := range route {
+		err = x.prepareFlags(&o, proute, &defaulted)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// prepareFlags loads flag values from environment variables, assigns
+// default values of flags of c and checks c.FlagRule,
+// route is the full Cmd route containing c.
+func (c *Cmd) prepareFlags(opts *CmdOptions, route *Route, defaulted *[]Flag) (err error) {
+	popts := opts.ParseOptions
+
+	if len(opts.EnvPrefix) != 0 {
+		for _, flags := range [...]FlagFinderMaybeIter{c.LocalFlags, c.Flags} {
+			if indexer, ok := flags.(FlagIndexer); ok && indexer != nil {
+				err = LoadFlagsFromEnv(indexer, popts, opts.EnvPrefix)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	err = tryAssignFlagsDefaultValue(c.LocalFlags, popts, defaulted)
+	if err != nil {
+		return
+	}
+
+	err = tryAssignFlagsDefaultValue(c.Flags, popts, defaulted)
+	if err != nil {
+		return
+	}
+
+	if rule := c.FlagRule; rule != nil {
+		violation, ok := rule.NthEx(route, 0)
+		if ok {
+			err = &FlagViolation{
+				Key:    violation.Key,
+				Reason: violation.Reason,
+			}
+		}
+	}
+
+	return
+}
+
 // Exec tries to find and run the Cmd with longest matching Cmd.Pattern in args.
 //
 // When called, this Cmd assumes itself as the root command.
 func (c *Cmd) Exec(opts *CmdOptions, args ...string) (err error) {
+	if opts == nil {
+		// allocate for CmdOptions.SetValue
+		opts = &CmdOptions{}
+	}
+
+	if len(c.MultiCall) != 0 {
+		c = c.MultiCallTarget(opts.executable())
+	}
+
+	if opts.HandleVersionRequest != nil {
+		if at := indexVersionArg(c, opts, args); at >= 0 {
+			err = opts.HandleVersionRequest(opts, Route{c}, args, at)
+			if err == nil {
+				err = ErrVersionHandled{}
+			}
+
+			return
+		}
+	}
+
 	route, posArgs, dashArgs, err := c.ResolveTarget(opts, args...)
 	if err != nil {
 		return
 	}
 
-	var popts *ParseOptions
-	if opts != nil && opts.ParseOptions != nil {
-		popts = opts.ParseOptions
-	}
+	if target := route.Target(); target.NoPosArgs && len(posArgs) != 0 {
+		err = &ErrUnexpectedPosArgs{
+			Args: posArgs,
+		}
 
-	proute := noescape(&route)
+		if opts.HandleArgError != nil {
+			err = opts.HandleArgError(opts, route, args, -1, err)
+		} else {
+			opts.formatError(err)
 
-	var i int
-	for i, c = range route {
-		err = tryAssignFlagsDefaultValue(c.LocalFlags, popts)
-		if err != nil {
-			return
+			if help := pick(target.Help, opts.HandleHelpRequest); help != nil {
+				_ = help(opts, route, args, -1)
+			}
 		}
 
-		err = tryAssignFlagsDefaultValue(c.Flags, popts)
 		if err != nil {
 			return
 		}
+	}
 
-		if rule := c.FlagRule; rule != nil {
-			for k := 0; ; k++ {
-				violation, ok := rule.NthEx(proute, k)
-				if !ok {
-					break
-				}
+	proute := noescape(&route)
 
-				return &FlagViolation{
-					Key:    violation.Key,
-					Reason: violation.Reason,
-				}
+	var (
+		i int
+
+		// flags with default value assigned, to assign default values of
+		// inherited flags only once.
+		defaulted []Flag
+	)
+	for i, c = range route {
+		err = c.prepareFlags(opts, proute, &defaulted)
+		if err != nil {
+			if _, ok := err.(*FlagViolation); ok {
+				opts.formatError(err)
 			}
+
+			return
 		}
 
 		if c.PreRun == nil || (c.State.PreRunOnce() && c.State.PreRunCalled()) {
@@ -487,28 +912,34 @@ func (c *Cmd) Exec(opts *CmdOptions, args ...string) (err error) {
 		}
 	}
 
-	if c.Run == nil {
+	fallback := c.Run == nil && c.UnknownCommandFunc != nil && len(posArgs) != 0
+	if c.Run == nil && !fallback {
 		err = &ErrCmdNotRunnable{
 			Name: c.Name(),
 		}
 
-		if opts != nil {
-			if opts.HandleArgError != nil {
-				err = opts.HandleArgError(opts, route, args, -1, err)
-			} else if help := pick(c.Help, opts.HandleHelpRequest); help != nil {
-				_ = help(opts, route, args, -1)
-			}
+		if opts.HandleArgError != nil {
+			err = opts.HandleArgError(opts, route, args, -1, err)
 		} else {
-			if c.Help != nil {
-				_ = c.Help(opts, route, args, -1)
+			opts.formatError(err)
+
+			if help := pick(c.Help, opts.HandleHelpRequest); help != nil {
+				_ = help(opts, route, args, -1)
 			}
 		}
 
 		return
 	}
 
-	err = c.Run(opts, route, posArgs, dashArgs)
-	if opts != nil && opts.SkipPostRun {
+	opts.warnExperimental(route)
+
+	if fallback {
+		err = c.UnknownCommandFunc(opts, route, posArgs[0], posArgs[1:])
+	} else {
+		err = c.Run(opts, route, posArgs, dashArgs)
+	}
+
+	if opts.SkipPostRun {
 		return
 	}
 
@@ -529,7 +960,33 @@ func (c *Cmd) Exec(opts *CmdOptions, args ...string) (err error) {
 	return
 }
 
-func tryAssignFlagsDefaultValue(flags FlagFinderMaybeIter, opts *ParseOptions) error {
+// ExecWithSignals is Exec but cancels the execution context on SIGINT or
+// SIGTERM, so Run functions observing CmdOptions.Context can shut down.
+//
+// After the first signal, default signal handling is restored, thus a
+// second signal forces the process to exit.
+//
+// NOTE: Hook functions are called with a copy of opts, whose Context is
+// derived from opts.Context.
+func (c *Cmd) ExecWithSignals(opts *CmdOptions, args ...string) error {
+	var o CmdOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	ctx, stop := signal.NotifyContext(o.PickContext(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop() // restore default handling for the second signal
+	}()
+
+	o.Context = ctx
+	return c.Exec(&o, args...)
+}
+
+func tryAssignFlagsDefaultValue(flags FlagFinderMaybeIter, opts *ParseOptions, defaulted *[]Flag) error {
 	if flags == nil {
 		return nil
 	}
@@ -539,26 +996,44 @@ func tryAssignFlagsDefaultValue(flags FlagFinderMaybeIter, opts *ParseOptions) e
 		return nil
 	}
 
-	return AssignFlagsDefaultValue(indexer, opts)
+	return assignFlagsDefaultValue(indexer, opts, defaulted)
 }
 
 // AssignFlagsDefaultValue iterates through all flags and call Flag.Decode on
-// flags with default value (indicated by FlagInfo.DefaultValue) but without
-// FlagStateValueChanged set (indicated by both FlagInfo.State and
-// Flag.State()).
+// flags with default value (indicated by FlagInfo.DefaultValue, or the
+// LazyDefault when it is empty) but without FlagStateValueChanged set
+// (indicated by both FlagInfo.State and Flag.State()).
+//
+// A default value in the form of `[a, b]` is decoded as multiple values,
+// double-quoted entries in it (e.g. `["a, b", c]`) are unquoted before
+// decoding.
 func AssignFlagsDefaultValue(flags FlagIndexer, opts *ParseOptions) (err error) {
+	return assignFlagsDefaultValue(flags, opts, nil)
+}
+
+// assignFlagsDefaultValue is AssignFlagsDefaultValue but skips flags in
+// defaulted and adds flags with default value assigned to it when not nil.
+//
+// It prevents decoding the default value of a flag reachable from multiple
+// FlagIndexers twice (e.g. appending to a slice again) in case the flag
+// doesn't set FlagStateValueChanged.
+func assignFlagsDefaultValue(flags FlagIndexer, opts *ParseOptions, defaulted *[]Flag) (err error) {
 	for i := 0; ; i++ {
 		info, ok := flags.NthFlag(i)
 		if !ok {
 			break
 		}
 
-		if len(info.DefaultValue) == 0 || info.State.ValueChanged() {
+		if info.State.ValueChanged() {
 			continue
 		}
 
 		name, flag, ok := FindFlag(flags, info.Name, info.Shorthand)
 		if !ok {
+			if len(info.DefaultValue) == 0 {
+				continue
+			}
+
 			name = info.Name
 			if len(name) == 0 {
 				name = info.Shorthand
@@ -574,22 +1049,149 @@ func AssignFlagsDefaultValue(flags FlagIndexer, opts *ParseOptions) (err error)
 			continue
 		}
 
-		if def := info.DefaultValue; def[0] == '[' && def[len(def)-1] == ']' {
+		comparable := defaulted != nil && reflect.TypeOf(flag).Comparable()
+		if comparable && containsFlag(*defaulted, flag) {
+			continue
+		}
+
+		def := info.DefaultValue
+		if len(def) == 0 {
+			lazy, ok := flag.(LazyDefault)
+			if !ok {
+				lazy, ok = flag.Extra().(LazyDefault)
+			}
+
+			if !ok {
+				continue
+			}
+
+			def, ok = lazy.LazyDefault(opts)
+			if !ok || len(def) == 0 {
+				continue
+			}
+		}
+
+		if def[0] == '[' && def[len(def)-1] == ']' {
 			var ent string
 			for def = def[1 : len(def)-1]; len(def) > 0; {
-				ent, def, _ = strings.Cut(def, ", ")
-				err = flag.Decode(opts, name, ent, true)
+				ent, def = cutDefaultEntry(def)
+				err = DecodeWithSource(flag, ValueSourceDefault, opts, name, ent)
 				if err != nil {
 					return
 				}
 			}
 		} else {
-			err = flag.Decode(opts, name, def, true)
+			err = DecodeWithSource(flag, ValueSourceDefault, opts, name, def)
 			if err != nil {
 				return
 			}
 		}
+
+		if comparable {
+			*defaulted = append(*defaulted, flag)
+		}
+	}
+
+	return nil
+}
+
+// LoadFlagsFromEnv decodes values of environment variables to flags without
+// FlagStateValueChanged.
+//
+// The variable of a flag is named by prefix, an underscore and the
+// upper-cased long name with hyphens replaced by underscores (e.g.
+// `APP_DRY_RUN` for flag `--dry-run` with prefix `APP`), flags without long
+// name are skipped.
+//
+// A value is decoded as if it was the arg of the flag, thus bool flags
+// accept `1`, `yes`, `false` and so on (see VPBool). Variables not set or
+// set to empty string are ignored, leaving flags to their default values.
+func LoadFlagsFromEnv(flags FlagIndexer, opts *ParseOptions, prefix string) (err error) {
+	for i := 0; ; i++ {
+		info, ok := flags.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		if info.State.ValueChanged() || len(info.Name) == 0 || IsShorthand(info.Name) {
+			continue
+		}
+
+		flag, ok := flags.FindFlag(info.Name)
+		if !ok || flag.State().ValueChanged() {
+			continue
+		}
+
+		value, _ := opts.lookupEnv(envName(prefix, info.Name))
+		if len(value) == 0 {
+			continue
+		}
+
+		err = DecodeWithSource(flag, ValueSourceEnv, opts, info.Name, value)
+		if err != nil {
+			return
+		}
 	}
 
 	return nil
 }
+
+// envName returns the environment variable name of the flag name.
+func envName(prefix, name string) string {
+	return prefix + "_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func containsFlag(flags []Flag, flag Flag) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResetFlags calls FlagResetter.ResetFlag on all flags implementing it.
+func ResetFlags(flags FlagIndexer) {
+	for i := 0; ; i++ {
+		info, ok := flags.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		_, flag, ok := FindFlag(flags, info.Name, info.Shorthand)
+		if !ok {
+			continue
+		}
+
+		if r, ok := flag.(FlagResetter); ok {
+			r.ResetFlag()
+		}
+	}
+}
+
+func tryResetFlags(flags FlagFinderMaybeIter) {
+	indexer, ok := flags.(FlagIndexer)
+	if !ok || indexer == nil {
+		return
+	}
+
+	ResetFlags(indexer)
+}
+
+// cutDefaultEntry cuts the first entry from the `a, b` list of multiple
+// default values, double-quoted entries are unquoted.
+func cutDefaultEntry(def string) (ent, rest string) {
+	if def[0] == '"' {
+		if q, err := strconv.QuotedPrefix(def); err == nil {
+			rest = def[len(q):]
+			if len(rest) == 0 || strings.HasPrefix(rest, ", ") {
+				ent, _ = strconv.Unquote(q)
+				return ent, strings.TrimPrefix(rest, ", ")
+			}
+		}
+	}
+
+	ent, rest, _ = strings.Cut(def, ", ")
+	return
+}
@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 The Prime Citizens
+
+package cli
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ExecLines reads r line by line, splits each line into args by SplitArgs
+// and calls c.Exec with them, empty lines are skipped.
+//
+// When opts.ResetFlagsPerLine is true, flags of all commands are reset
+// before executing each line except the first one, so that every line gets
+// independent flag state.
+//
+// On error, opts.HandleLineError is called to decide whether to continue,
+// it stops at the first error if opts.HandleLineError is nil.
+func (c *Cmd) ExecLines(opts *CmdOptions, r io.Reader) (err error) {
+	if opts == nil {
+		opts = &CmdOptions{}
+	}
+
+	var (
+		args     []string
+		executed bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := scanner.Text()
+		args, err = SplitArgs(line)
+		if err == nil && len(args) == 0 {
+			continue
+		}
+
+		if err == nil {
+			if executed && opts.ResetFlagsPerLine {
+				_ = c.VisitAll(func(route Route) error {
+					cmd := route[len(route)-1]
+					tryResetFlags(cmd.Flags)
+					tryResetFlags(cmd.LocalFlags)
+					return nil
+				})
+			}
+
+			executed = true
+			err = c.Exec(opts, args...)
+		}
+
+		if err != nil {
+			if opts.HandleLineError == nil {
+				return
+			}
+
+			err = opts.HandleLineError(lineno, line, err)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// SplitArgs splits line into args like a POSIX shell without expansion:
+//
+//   - args are separated by unquoted spaces and tabs.
+//   - text in single quotes is literal.
+//   - in double quotes, backslash only escapes `"`, `\`, `$` and '`'.
+//   - outside quotes, backslash escapes the next character.
+//   - an unquoted `#` at the start of an arg begins a comment.
+func SplitArgs(line string) (args []string, err error) {
+	var (
+		sb    strings.Builder
+		inArg bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case ' ', '\t', '\r', '\n':
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		case '#':
+			if !inArg {
+				return
+			}
+
+			sb.WriteByte(c)
+		case '\\':
+			inArg = true
+			if i+1 < len(line) {
+				i++
+				sb.WriteByte(line[i])
+			}
+		case '\'':
+			inArg = true
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, &ErrUnterminatedQuote{Quote: c, At: i}
+			}
+
+			sb.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+		case '"':
+			inArg = true
+			start := i
+			for i++; ; i++ {
+				if i >= len(line) {
+					return nil, &ErrUnterminatedQuote{Quote: c, At: start}
+				}
+
+				if line[i] == '"' {
+					break
+				}
+
+				if line[i] == '\\' && i+1 < len(line) {
+					switch line[i+1] {
+					case '"', '\\', '$', '`':
+						i++
+					}
+				}
+
+				sb.WriteByte(line[i])
+			}
+		default:
+			inArg = true
+			sb.WriteByte(c)
+		}
+	}
+
+	if inArg {
+		args = append(args, sb.String())
+	}
+
+	return
+}
+
+// FormatArgs reconstructs a command line from the resolved route and flags
+// set from command-line args (e.g. for audit logs), it is the reverse of
+// SplitArgs:
+//
+//	<route names> <flags> <posArgs> [-- <dashArgs>]
+//
+// Flags are written in the order of flags.NthFlag as `--<name>=<value>`
+// (or `-<shorthand>=<value>` if there is no name), bool flags set to true
+// are written without value, slice flags are written once per element,
+// values of secret flags are replaced with `<redacted>`.
+//
+// Args containing spaces, quotes or backslashes are single-quoted.
+//
+// If the argument `flags` is nil, use flags of the route.
+func FormatArgs(route Route, posArgs, dashArgs []string, flags FlagIndexer) string {
+	if flags == nil {
+		flags = noescape(&route)
+	}
+
+	var sb strings.Builder
+	for _, c := range route {
+		if name := c.Name(); len(name) != 0 {
+			appendFormattedArg(&sb, name)
+		}
+	}
+
+	for i := 0; ; i++ {
+		info, ok := flags.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		_, f, ok := FindFlag(flags, info.Name, info.Shorthand)
+		if !ok || !f.State().ValueChanged() {
+			continue
+		}
+
+		if s, ok := f.(ValueSourcer); ok && s.ValueSource() != ValueSourceArg {
+			continue // e.g. default values
+		}
+
+		name := "--" + info.Name
+		if len(info.Name) == 0 {
+			name = "-" + info.Shorthand
+		}
+
+		if f.State().Secret() {
+			appendFormattedArg(&sb, name+"="+redacted)
+			continue
+		}
+
+		var value strings.Builder
+		if _, err := f.PrintValue(&value); err != nil {
+			continue
+		}
+
+		typ, _ := f.Type()
+		switch v := value.String(); {
+		case isBoolFlag(f) && v == "true":
+			appendFormattedArg(&sb, name)
+		case strings.HasPrefix(typ, "[]") && len(v) >= 2 && v[0] == '[' && v[len(v)-1] == ']':
+			var ent string
+			for v = v[1 : len(v)-1]; len(v) > 0; {
+				ent, v = cutDefaultEntry(v)
+				appendFormattedArg(&sb, name+"="+ent)
+			}
+		default:
+			appendFormattedArg(&sb, name+"="+v)
+		}
+	}
+
+	for _, arg := range posArgs {
+		appendFormattedArg(&sb, arg)
+	}
+
+	if dashArgs != nil {
+		appendFormattedArg(&sb, "--")
+		for _, arg := range dashArgs {
+			appendFormattedArg(&sb, arg)
+		}
+	}
+
+	return sb.String()
+}
+
+// appendFormattedArg appends arg to sb with a space separator, arg is
+// single-quoted if SplitArgs would not return it as is.
+func appendFormattedArg(sb *strings.Builder, arg string) {
+	if sb.Len() != 0 {
+		sb.WriteByte(' ')
+	}
+
+	if len(arg) != 0 && arg[0] != '#' && !strings.ContainsAny(arg, " \t\r\n'\"\\") {
+		sb.WriteString(arg)
+		return
+	}
+
+	sb.WriteByte('\'')
+	for {
+		i := strings.IndexByte(arg, '\'')
+		if i < 0 {
+			break
+		}
+
+		// close the quote, escape the single quote and reopen.
+		sb.WriteString(arg[:i])
+		sb.WriteString(`'\''`)
+		arg = arg[i+1:]
+	}
+	sb.WriteString(arg)
+	sb.WriteByte('\'')
+}
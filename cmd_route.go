@@ -47,6 +47,17 @@ func (p Route) Up() Route {
 	return p[:len(p)-1]
 }
 
+// contains returns true if cmd is in the route.
+func (p Route) contains(cmd *Cmd) bool {
+	for _, c := range p {
+		if c == cmd {
+			return true
+		}
+	}
+
+	return false
+}
+
 // CheckFlagValueChanged implements [Inspector].
 func (p *Route) CheckFlagValueChanged(name string) bool {
 	flag, ok := p.FindFlag(name)
@@ -132,6 +143,75 @@ func (p *Route) NthFlag(i int) (info FlagInfo, ok bool) {
 	return FlagInfo{}, false
 }
 
+// AllFlags returns info of all flags reachable at the target Cmd, including
+// LocalFlags of the target and Flags inherited from its ancestors.
+//
+// Flags closer to the target shadow farther ones with the same name, for a
+// shadowed shorthand or alias, the farther flag is kept without it.
+func (p *Route) AllFlags() (ret []FlagInfo) {
+	for i := 0; ; i++ {
+		info, ok := p.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		info = info.normalized()
+		if len(info.Name) != 0 && hasFlagName(ret, info.Name) {
+			continue
+		}
+
+		for j := 0; j < len(info.Aliases); j++ {
+			if !hasFlagName(ret, info.Aliases[j]) {
+				continue
+			}
+
+			// copy before removing to not modify aliases owned by the indexer.
+			info.Aliases = append(info.Aliases[:j:j], info.Aliases[j+1:]...)
+			j--
+		}
+
+		if len(info.Shorthand) != 0 && hasFlagShorthand(ret, info.Shorthand) {
+			if len(info.Name) == 0 {
+				continue
+			}
+
+			info.Shorthand = ""
+		}
+
+		ret = append(ret, info)
+	}
+
+	return
+}
+
+// hasFlagName returns true if name is the name or an alias of any of infos.
+func hasFlagName(infos []FlagInfo, name string) bool {
+	for _, info := range infos {
+		if info.Name == name {
+			return true
+		}
+
+		for _, alias := range info.Aliases {
+			if alias == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasFlagShorthand returns true if shorthand is the shorthand of any of infos.
+func hasFlagShorthand(infos []FlagInfo, shorthand string) bool {
+	for _, info := range infos {
+		if info.Shorthand == shorthand {
+			return true
+		}
+	}
+
+	return false
+}
+
 // FormatRoute writes all Cmd.Name() in the route with sep in between.
 //
 // For the last Cmd in route (the target), it writes the complete Cmd.Pattern.
@@ -5,6 +5,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"strings"
 	"testing"
 
@@ -95,6 +96,34 @@ func TestCmdHelp(t *testing.T) {
 		}
 	})
 
+	t.Run("HelpTarget", func(t *testing.T) {
+		for _, test := range []struct {
+			args   []string
+			target string
+		}{
+			{[]string{"--help"}, "foo"},
+			{[]string{"--help", "bar"}, "bar"},
+			{[]string{"-h", "bar"}, "bar"},
+			{[]string{"help", "bar"}, "bar"},
+			{[]string{"--help", "baz"}, "foo"},
+			{[]string{"--any", "--help", "bar"}, "bar"},
+		} {
+			t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+				var target string
+				opts := &CmdOptions{
+					HandleHelpRequest: func(opts *CmdOptions, route Route, args []string, helpArgAt int) error {
+						target = route.Target().Name()
+						return nil
+					},
+				}
+
+				err := root.Exec(opts, test.args...)
+				assert.ErrorIs(t, ErrHelpHandled{}, err)
+				assert.Eq(t, test.target, target)
+			})
+		}
+	})
+
 	t.Run("CustomHelpArgs", func(t *testing.T) {
 		for _, args := range [][]string{
 			{"-?"},
@@ -405,6 +434,83 @@ func TestCmdFlagDefaultValue(t *testing.T) {
 	assert.True(t, postRunCalled)
 }
 
+func TestCmdFlagQuotedDefaultValue(t *testing.T) {
+	type Config struct {
+		Single string   `cli:"single,def=\"a,b\""`
+		Multi  []string `cli:"multi,def=x,def=\"a, b\""`
+		Two    []string `cli:"two,def=x,def=y"`
+	}
+
+	var actual Config
+	flags := NewReflectIndexer(DefaultReflectVPFactory{}, &actual)
+	root := Cmd{
+		Flags: flags,
+		Run:   func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+	}
+
+	err := root.Exec(nil)
+	assert.NoError(t, err)
+	assert.Eq(t, "a,b", actual.Single)
+	assert.EqS(t, []string{"x", "a, b"}, actual.Multi)
+	assert.EqS(t, []string{"x", "y"}, actual.Two)
+
+	for i, expected := range []string{"a,b", `[x, "a, b"]`, "[x, y]"} {
+		info, ok := flags.NthFlag(i)
+		assert.True(t, ok)
+		assert.Eq(t, expected, info.DefaultValue)
+	}
+}
+
+func TestCmdFlagLazyDefaultValue(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected string
+		called   bool
+	}{
+		{"Applied when not set", nil, "lazy", true},
+		{"Ignored when set", []string{"--dir", "arg"}, "arg", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var called bool
+			dir := &StringV{
+				Ext: DefaultFunc(func(opts *ParseOptions) (string, bool) {
+					called = true
+					return "lazy", true
+				}),
+			}
+
+			root := Cmd{
+				Flags: NewMapIndexer().Add(dir, "dir"),
+				Run:   func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+			}
+
+			assert.NoError(t, root.Exec(nil, test.args...))
+			assert.Eq(t, test.expected, dir.Value)
+			assert.Eq(t, test.called, called)
+		})
+	}
+}
+
+func TestCmd_ExecWithSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var cancelled bool
+	root := Cmd{
+		Run: func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error {
+			cancel() // simulate the signal
+			<-opts.PickContext().Done()
+			cancelled = true
+			return opts.PickContext().Err()
+		},
+	}
+
+	err := root.ExecWithSignals(&CmdOptions{Context: ctx})
+	assert.ErrorIs(t, context.Canceled, err)
+	assert.True(t, cancelled)
+}
+
 func BenchmarkCmd(b *testing.B) {
 	var (
 		flag Int64SumV
@@ -462,3 +568,776 @@ func BenchmarkCmd(b *testing.B) {
 	assert.Eq(b, b.N, n)
 	assert.Eq(b, int64(b.N)*int64(len(args)/2), flag.Value)
 }
+
+func TestRoute_AllFlags(t *testing.T) {
+	root := &Cmd{
+		Pattern: "root",
+		Flags: NewMapIndexer().
+			Add(&BoolV{}, "verbose", "v").
+			Add(&StringV{}, "output", "o"),
+	}
+	child := &Cmd{
+		Pattern: "child",
+		Flags: NewMapIndexer().
+			Add(&IntV{}, "level", "o"),
+		LocalFlags: NewMapIndexer().
+			Add(&StringV{}, "verbose"),
+	}
+
+	route := Route{root, child}
+	assert.DeepEq(t, []FlagInfo{
+		{Name: "verbose"},
+		{Name: "level", Shorthand: "o"},
+		{Name: "output"},
+	}, route.AllFlags())
+}
+
+func TestCmd_FormatError(t *testing.T) {
+	var stderr strings.Builder
+	root := &Cmd{
+		Pattern: "root",
+		Run:     func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+	}
+
+	err := root.Exec(&CmdOptions{
+		Stderr:      &stderr,
+		FormatError: DefaultFormatError,
+	}, "--foo")
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "foo", At: 0}, err)
+	assert.Eq(t, "error: unknown flag --foo\n", stderr.String())
+
+	// FlagRule violation is formatted by Exec only.
+	root.Flags = NewMapIndexer().Add(&BoolV{}, "json").Add(&BoolV{}, "yaml")
+	root.FlagRule = OneOf("json", "yaml")
+
+	stderr.Reset()
+	opts := &CmdOptions{Stderr: &stderr, FormatError: DefaultFormatError}
+	_, _, _, err = root.ParseArgs(opts)
+	assert.ErrorIs(t, &FlagViolation{Key: "json", Reason: ViolationCodeEmptyOneOf}, err)
+	assert.Eq(t, "", stderr.String())
+
+	err = root.Exec(opts)
+	assert.ErrorIs(t, &FlagViolation{Key: "json", Reason: ViolationCodeEmptyOneOf}, err)
+	assert.True(t, len(stderr.String()) != 0)
+}
+
+func TestCmd_VisitAll(t *testing.T) {
+	leaf := &Cmd{Pattern: "leaf"}
+	sub := &Cmd{Pattern: "sub", Children: []*Cmd{leaf}}
+	root := &Cmd{Pattern: "root", Children: []*Cmd{sub, {Pattern: "other"}}}
+
+	var visited []string
+	assert.NoError(t, root.VisitAll(func(route Route) error {
+		var buf strings.Builder
+		_, _ = FormatRoute(&buf, route, " ")
+		visited = append(visited, buf.String())
+		return nil
+	}))
+	assert.EqS(t, []string{"root", "root sub", "root sub leaf", "root other"}, visited)
+
+	// make a cycle: root -> sub -> leaf -> sub
+	leaf.Children = []*Cmd{sub}
+	assert.ErrorIs(t, &ErrCommandCycle{Name: "sub"}, root.VisitAll(func(Route) error { return nil }))
+
+	_, _, _, err := root.ResolveTarget(nil, "sub", "leaf", "sub")
+	assert.ErrorIs(t, &ErrCommandCycle{Name: "sub"}, err)
+}
+
+func TestCmd_MatchChild(t *testing.T) {
+	var (
+		ran     string
+		posArgs []string
+	)
+
+	plugin := &Cmd{
+		Pattern: "plugin",
+		Run: func(opts *CmdOptions, route Route, args, dashArgs []string) error {
+			ran, posArgs = "plugin", args
+			return nil
+		},
+	}
+	root := &Cmd{
+		Pattern: "root",
+		Flags:   NewMapIndexer().Add(&BoolV{}, "verbose", "v"),
+		MatchChild: func(arg string) *Cmd {
+			if strings.HasPrefix(arg, "@") || arg == "-plugin" {
+				return plugin
+			}
+
+			return nil
+		},
+		Children: []*Cmd{
+			{
+				Pattern: "foo",
+				Run: func(opts *CmdOptions, route Route, args, dashArgs []string) error {
+					ran = "foo"
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+		posArgs  []string
+	}{
+		{[]string{"@plugin", "x"}, "plugin", []string{"x"}},
+		{[]string{"-v", "@plugin", "x"}, "plugin", []string{"x"}},
+		{[]string{"-plugin", "y"}, "plugin", []string{"y"}},
+		{[]string{"foo"}, "foo", nil},
+	} {
+		ran, posArgs = "", nil
+		assert.NoError(t, root.Exec(nil, test.args...))
+		assert.Eq(t, test.expected, ran)
+		assert.EqS(t, test.posArgs, posArgs)
+	}
+}
+
+func TestCmd_UnknownCommandFunc(t *testing.T) {
+	var (
+		ran      string
+		unknown  string
+		rest     []string
+		dispatch []string
+	)
+
+	root := &Cmd{
+		Pattern: "tool",
+		Flags:   NewMapIndexer().Add(&BoolV{}, "verbose", "v"),
+		UnknownCommandFunc: func(opts *CmdOptions, route Route, name string, args []string) error {
+			unknown, rest = name, args
+			dispatch = append(dispatch, route.Target().Name()+"-"+name)
+			return nil
+		},
+		Children: []*Cmd{
+			{
+				Pattern: "build",
+				Run: func(opts *CmdOptions, route Route, args, dashArgs []string) error {
+					ran = "build"
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		args    []string
+		ran     string
+		unknown string
+		rest    []string
+	}{
+		{[]string{"build"}, "build", "", nil},
+		{[]string{"deploy", "--env", "prod", "x", "--", "y"}, "", "deploy", []string{"--env", "prod", "x", "--", "y"}},
+		{[]string{"-v", "lint"}, "", "lint", []string{}},
+	} {
+		ran, unknown, rest = "", "", nil
+		assert.NoError(t, root.Exec(nil, test.args...))
+		assert.Eq(t, test.ran, ran)
+		assert.Eq(t, test.unknown, unknown)
+		assert.EqS(t, test.rest, rest)
+	}
+	assert.EqS(t, []string{"tool-deploy", "tool-lint"}, dispatch)
+
+	// no positional arg, still not runnable
+	err := root.Exec(nil, "-v")
+	assert.ErrorIs(t, &ErrCmdNotRunnable{Name: "tool"}, err)
+
+	route, posArgs, _, err := root.ResolveTarget(nil, "deploy", "--env", "prod")
+	assert.NoError(t, err)
+	assert.Eq(t, 1, len(route))
+	assert.EqS(t, []string{"deploy", "--env", "prod"}, posArgs)
+}
+
+func TestCmd_UnknownCommandFunc_PostRun(t *testing.T) {
+	var events []string
+	root := &Cmd{
+		Pattern: "tool",
+		PreRun: func(opts *CmdOptions, route Route, at int, args, dashArgs []string) error {
+			events = append(events, "prerun")
+			return nil
+		},
+		PostRun: func(opts *CmdOptions, route Route, at int, err error) error {
+			events = append(events, "postrun")
+			return err
+		},
+		Children: []*Cmd{
+			{
+				Pattern: "plugin",
+				UnknownCommandFunc: func(opts *CmdOptions, route Route, name string, args []string) error {
+					events = append(events, "dispatch-"+name)
+					return nil
+				},
+			},
+		},
+	}
+
+	assert.NoError(t, root.Exec(nil, "plugin", "deploy"))
+	assert.EqS(t, []string{"prerun", "dispatch-deploy", "postrun"}, events)
+}
+
+func TestCmdOptions_Value(t *testing.T) {
+	type configKey struct{}
+
+	var (
+		loaded any
+		ran    bool
+	)
+	root := &Cmd{
+		Pattern: "root",
+		PreRun: func(opts *CmdOptions, route Route, prerunAt int, posArgs, dashArgs []string) error {
+			opts.SetValue(configKey{}, "config")
+			return nil
+		},
+		Children: []*Cmd{
+			{
+				Pattern: "child",
+				Run: func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error {
+					ran, loaded = true, opts.Value(configKey{})
+					return nil
+				},
+			},
+		},
+	}
+
+	err := root.Exec(nil, "child")
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Eq[any](t, "config", loaded)
+
+	var opts *CmdOptions
+	assert.Eq[any](t, nil, opts.Value(configKey{}))
+}
+
+func TestCmd_ExecLines(t *testing.T) {
+	type Opts struct {
+		Verbose bool   `cli:"verbose|v"`
+		Name    string `cli:"name,def=anonymous"`
+	}
+
+	var (
+		opts Opts
+		ran  []Opts
+	)
+	root := &Cmd{
+		Pattern: "root",
+		Flags:   NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+		Children: []*Cmd{
+			{
+				Pattern: "greet",
+				Run: func(_ *CmdOptions, route Route, posArgs, dashArgs []string) error {
+					ran = append(ran, opts)
+					return nil
+				},
+			},
+		},
+	}
+
+	input := "greet -v --name 'John Doe'\n\n# comment\ngreet\n"
+	err := root.ExecLines(&CmdOptions{ResetFlagsPerLine: true}, strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.EqS(t, []Opts{
+		{Verbose: true, Name: "John Doe"},
+		{Verbose: false, Name: "anonymous"},
+	}, ran)
+
+	var failed []int
+	err = root.ExecLines(&CmdOptions{
+		HandleLineError: func(lineno int, line string, err error) error {
+			failed = append(failed, lineno)
+			return nil
+		},
+	}, strings.NewReader("greet --unknown\ngreet 'x\ngreet\n"))
+	assert.NoError(t, err)
+	assert.EqS(t, []int{1, 2}, failed)
+
+	err = root.ExecLines(nil, strings.NewReader("greet --unknown\ngreet\n"))
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "unknown", At: 1}, err)
+}
+
+func TestCmd_ExecLines_PresetValues(t *testing.T) {
+	type Opts struct {
+		Level int      `cli:"level"`
+		Tags  []string `cli:"tag"`
+	}
+
+	var (
+		opts   = Opts{Level: 3, Tags: []string{"a"}}
+		label  = StringV{Value: "none"}
+		ran    []Opts
+		labels []string
+	)
+	root := &Cmd{
+		Pattern:    "root",
+		Flags:      NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+		LocalFlags: NewMapIndexer().Add(&label, "label"),
+		Run: func(_ *CmdOptions, route Route, posArgs, dashArgs []string) error {
+			ran = append(ran, Opts{Level: opts.Level, Tags: append([]string(nil), opts.Tags...)})
+			labels = append(labels, label.Value)
+			return nil
+		},
+	}
+
+	input := "--level 5 --tag b --label x\n--tag c\n"
+	err := root.ExecLines(&CmdOptions{ResetFlagsPerLine: true}, strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.DeepEq(t, []Opts{
+		{Level: 5, Tags: []string{"a", "b"}},
+		{Level: 3, Tags: []string{"a", "c"}},
+	}, ran)
+	assert.EqS(t, []string{"x", "none"}, labels)
+}
+
+func TestFormatArgs(t *testing.T) {
+	type Opts struct {
+		Verbose bool     `cli:"verbose|v"`
+		Name    string   `cli:"name,def=anonymous"`
+		Token   string   `cli:"token,secret"`
+		Tags    []string `cli:"tag"`
+		Quiet   bool     `cli:"quiet|q"`
+	}
+
+	var (
+		opts  Opts
+		line  string
+		flags = NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	)
+	root := &Cmd{
+		Pattern: "root",
+		Flags:   flags,
+		Children: []*Cmd{
+			{
+				Pattern: "greet|g [name...]",
+				Run: func(_ *CmdOptions, route Route, posArgs, dashArgs []string) error {
+					line = FormatArgs(route, posArgs, dashArgs, nil)
+					return nil
+				},
+			},
+		},
+	}
+
+	err := root.Exec(nil, "g", "-v", "--token", "s3cr3t", "--tag=a", "--tag", "b c",
+		"John Doe", "it's", "--", "-x")
+	assert.NoError(t, err)
+	assert.Eq(t, `root greet --verbose --token=<redacted> --tag=a '--tag=b c' 'John Doe' 'it'\''s' -- -x`, line)
+
+	args, err := SplitArgs(line)
+	assert.NoError(t, err)
+	assert.EqS(t, []string{
+		"root", "greet", "--verbose", "--token=<redacted>", "--tag=a", "--tag=b c",
+		"John Doe", "it's", "--", "-x",
+	}, args)
+
+	ResetFlags(flags)
+	err = root.Exec(nil, "greet", "--quiet=false")
+	assert.NoError(t, err)
+	assert.Eq(t, "root greet --quiet=false", line)
+}
+
+func TestAuditFlags(t *testing.T) {
+	type Opts struct {
+		Verbose bool   `cli:"verbose|v"`
+		Name    string `cli:"name,def=anonymous"`
+		Count   int    `cli:"count"`
+	}
+
+	var opts Opts
+	flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	root := &Cmd{
+		Flags: flags,
+		Run:   func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+	}
+
+	err := root.Exec(nil, "-v")
+	assert.NoError(t, err)
+	assert.EqS(t, []FlagAudit{
+		{Name: "verbose", Shorthand: "v", Value: "true", Source: ValueSourceArg},
+		{Name: "name", Value: "anonymous", Source: ValueSourceDefault},
+		{Name: "count", Source: ValueSourceUnset},
+	}, AuditFlags(flags))
+
+	env := &StringV{}
+	assert.NoError(t, DecodeWithSource(env, ValueSourceEnv, nil, "env", "x"))
+	assert.Eq(t, ValueSourceEnv, env.ValueSource())
+	assert.Eq(t, "env", env.ValueSource().String())
+}
+
+func TestVersionCmd(t *testing.T) {
+	var vc VersionCmd
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{
+			vc.Setup(VersionInfo{
+				Version:   "v1.2.3",
+				Commit:    "abcdef",
+				BuildDate: "2023-01-02",
+				GoVersion: "go1.20",
+			}),
+		},
+	}
+
+	const (
+		plain = "" +
+			"version: v1.2.3\n" +
+			"commit: abcdef\n" +
+			"build date: 2023-01-02\n" +
+			"go version: go1.20\n"
+		json = `{"version":"v1.2.3","commit":"abcdef","buildDate":"2023-01-02","goVersion":"go1.20"}` + "\n"
+	)
+
+	t.Run("Flag", func(t *testing.T) {
+		var sb strings.Builder
+		opts := &CmdOptions{
+			Stdout:               &sb,
+			HandleVersionRequest: vc.HandleVersionRequest,
+		}
+
+		err := root.Exec(opts, "--version")
+		assert.ErrorIs(t, ErrVersionHandled{}, err)
+		assert.Eq(t, plain, sb.String())
+
+		sb.Reset()
+		err = root.Exec(opts, "--", "--version")
+		assert.ErrorIs(t, &ErrCmdNotRunnable{Name: "tool"}, err)
+		assert.Eq(t, "", sb.String())
+
+		vc.Format = VersionFormatJSON
+		defer func() { vc.Format = VersionFormatPlain }()
+
+		sb.Reset()
+		err = root.Exec(opts, "--version")
+		assert.ErrorIs(t, ErrVersionHandled{}, err)
+		assert.Eq(t, json, sb.String())
+	})
+
+	t.Run("SubcmdFlag", func(t *testing.T) {
+		var (
+			sb      strings.Builder
+			pkgVer  StringV
+			posArgs []string
+		)
+		root := &Cmd{
+			Pattern: "tool",
+			Children: []*Cmd{
+				{
+					Pattern:    "install",
+					LocalFlags: NewMapIndexer().Add(&pkgVer, "version"),
+					Run: func(opts *CmdOptions, route Route, args, dashArgs []string) error {
+						posArgs = args
+						return nil
+					},
+				},
+			},
+		}
+		opts := &CmdOptions{
+			Stdout:               &sb,
+			HandleVersionRequest: vc.HandleVersionRequest,
+		}
+
+		err := root.Exec(opts, "install", "pkg", "--version", "1.2.3")
+		assert.NoError(t, err)
+		assert.Eq(t, "1.2.3", pkgVer.Value)
+		assert.EqS(t, []string{"pkg"}, posArgs)
+		assert.Eq(t, "", sb.String())
+
+		err = root.Exec(opts, "--version", "install")
+		assert.ErrorIs(t, ErrVersionHandled{}, err)
+		assert.Eq(t, plain, sb.String())
+	})
+
+	t.Run("Cmd", func(t *testing.T) {
+		var sb strings.Builder
+		err := root.Exec(&CmdOptions{Stdout: &sb}, "version")
+		assert.NoError(t, err)
+		assert.Eq(t, plain, sb.String())
+
+		sb.Reset()
+		err = root.Exec(&CmdOptions{Stdout: &sb}, "version", "--json")
+		assert.NoError(t, err)
+		assert.Eq(t, json, sb.String())
+	})
+
+	t.Run("JSONEscape", func(t *testing.T) {
+		var sb strings.Builder
+		info := VersionInfo{Version: "a\"b\\c\n", GoVersion: "go"}
+		_, err := info.WriteVersion(&sb, VersionFormatJSON)
+		assert.NoError(t, err)
+		assert.Eq(t, `{"version":"a\"b\\c\n","goVersion":"go"}`+"\n", sb.String())
+	})
+}
+
+func TestCmd_NoPosArgs(t *testing.T) {
+	var (
+		called int
+		force  BoolV
+	)
+
+	root := &Cmd{
+		Pattern:   "tool",
+		NoPosArgs: true,
+		Flags:     NewMapIndexer().Add(&force, "force"),
+		Run: func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error {
+			called++
+			return nil
+		},
+	}
+
+	t.Run("None", func(t *testing.T) {
+		err := root.Exec(nil, "--force", "--", "dash")
+		assert.NoError(t, err)
+		assert.Eq(t, 1, called)
+	})
+
+	t.Run("Extra", func(t *testing.T) {
+		var sb strings.Builder
+		err := root.Exec(&CmdOptions{Stderr: &sb}, "--force", "extra")
+		assert.ErrorIs(t, &ErrUnexpectedPosArgs{Args: []string{"extra"}}, err)
+		assert.Eq(t, 1, called)
+	})
+
+	t.Run("HandleArgError", func(t *testing.T) {
+		var handled error
+		err := root.Exec(&CmdOptions{
+			HandleArgError: func(opts *CmdOptions, route Route, args []string, badArgAt int, argErr error) error {
+				handled = argErr
+				return nil
+			},
+		}, "extra")
+		assert.NoError(t, err)
+		assert.ErrorIs(t, &ErrUnexpectedPosArgs{Args: []string{"extra"}}, handled)
+		assert.Eq(t, 2, called)
+	})
+}
+
+// stickyStringSlice never reports FlagStateValueChanged.
+type stickyStringSlice struct{ StringSliceV }
+
+func (*stickyStringSlice) State() FlagState { return 0 }
+
+func TestCmd_InheritedFlagDefaultsOnce(t *testing.T) {
+	var (
+		tags stickyStringSlice
+		run  = func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil }
+	)
+
+	shared := NewMapIndexer().AddWithDefaultValue("[a, b]", &tags, "tag")
+	root := &Cmd{
+		Pattern: "root",
+		Flags:   shared,
+		Children: []*Cmd{{
+			Pattern: "mid",
+			Flags:   NewMapIndexer().AddWithDefaultValue("[a, b]", &tags, "tag"),
+			Children: []*Cmd{{
+				Pattern: "leaf",
+				Flags:   shared,
+				Run:     run,
+			}},
+		}},
+	}
+
+	err := root.Exec(nil, "mid", "leaf")
+	assert.NoError(t, err)
+	assert.EqS(t, []string{"a", "b"}, tags.Value)
+}
+
+func TestCmd_ParseArgs(t *testing.T) {
+	var (
+		called int
+		opts   struct {
+			Verbose bool   `cli:"verbose|v"`
+			Output  string `cli:"output|o,def=out.txt"`
+		}
+		hook = func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error {
+			called++
+			return nil
+		}
+	)
+
+	leaf := &Cmd{
+		Pattern: "leaf",
+		PreRun: func(opts *CmdOptions, route Route, prerunAt int, posArgs, dashArgs []string) error {
+			called++
+			return nil
+		},
+		Run: hook,
+		PostRun: func(opts *CmdOptions, route Route, postrunAt int, runErr error) error {
+			called++
+			return nil
+		},
+	}
+	root := &Cmd{
+		Pattern:  "root",
+		Flags:    NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+		Children: []*Cmd{{Pattern: "mid", Children: []*Cmd{leaf}}},
+	}
+
+	route, posArgs, dashArgs, err := root.ParseArgs(
+		&CmdOptions{DoNotSetFlags: true}, "mid", "-v", "leaf", "arg", "--", "dash",
+	)
+	assert.NoError(t, err)
+	assert.Eq(t, 3, len(route))
+	assert.Eq(t, leaf, route.Target())
+	assert.EqS(t, []string{"arg"}, posArgs)
+	assert.EqS(t, []string{"dash"}, dashArgs)
+	assert.True(t, opts.Verbose)
+	assert.Eq(t, "out.txt", opts.Output)
+	assert.Eq(t, 0, called)
+}
+
+func TestCmd_EnvPrefix(t *testing.T) {
+	for _, test := range []struct {
+		env      string
+		unset    bool
+		def      string
+		expected bool
+		source   ValueSource
+	}{
+		{env: "1", expected: true, source: ValueSourceEnv},
+		{env: "yes", expected: true, source: ValueSourceEnv},
+		{env: "false", def: "true", expected: false, source: ValueSourceEnv},
+		{env: "", def: "true", expected: true, source: ValueSourceDefault},
+		{unset: true, def: "true", expected: true, source: ValueSourceDefault},
+		{unset: true, expected: false, source: ValueSourceUnset},
+	} {
+		t.Run(test.env, func(t *testing.T) {
+			if !test.unset {
+				t.Setenv("APP_DRY_RUN", test.env)
+			}
+
+			var dryRun BoolV
+			root := &Cmd{
+				Pattern: "app",
+				Flags:   NewMapIndexer().AddWithDefaultValue(test.def, &dryRun, "dry-run"),
+				Run:     func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+			}
+
+			err := root.Exec(&CmdOptions{EnvPrefix: "APP"})
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected, dryRun.Value)
+			assert.Eq(t, test.source, dryRun.ValueSource())
+		})
+	}
+
+	t.Run("ArgFirst", func(t *testing.T) {
+		t.Setenv("APP_DRY_RUN", "1")
+
+		var dryRun BoolV
+		root := &Cmd{
+			Pattern: "app",
+			Flags:   NewMapIndexer().Add(&dryRun, "dry-run"),
+			Run:     func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+		}
+
+		err := root.Exec(&CmdOptions{EnvPrefix: "APP"}, "--dry-run=false")
+		assert.NoError(t, err)
+		assert.False(t, dryRun.Value)
+		assert.Eq(t, ValueSourceArg, dryRun.ValueSource())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("APP_DRY_RUN", "maybe")
+
+		root := &Cmd{
+			Pattern: "app",
+			Flags:   NewMapIndexer().Add(&BoolV{}, "dry-run"),
+			Run:     func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+		}
+
+		err := root.Exec(&CmdOptions{EnvPrefix: "APP"})
+		assert.ErrorIs(t, &ErrInvalidValue{Type: "bool", Value: "maybe"}, err)
+	})
+}
+
+func TestCmd_MultiCall(t *testing.T) {
+	var called []string
+
+	run := func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error {
+		called = append(called, route.Target().Name()+" "+strings.Join(posArgs, " "))
+		return nil
+	}
+
+	ls := &Cmd{Pattern: "ls", Run: run}
+	root := &Cmd{
+		Pattern:   "box",
+		Run:       run,
+		Children:  []*Cmd{ls, {Pattern: "cat", Run: run}},
+		MultiCall: map[string]*Cmd{"ls": ls},
+	}
+
+	for _, test := range []struct {
+		executable string
+		args       []string
+		expected   string
+	}{
+		{"/usr/bin/ls", []string{"dir"}, "ls dir"},
+		{`ls.exe`, []string{"dir"}, "ls dir"},
+		{"./box", []string{"cat", "file"}, "cat file"},
+		{"./box", []string{"ls", "dir"}, "ls dir"},
+		{"/usr/bin/cat", []string{"file"}, "box file"}, // not in MultiCall
+	} {
+		called = called[:0]
+		err := root.Exec(&CmdOptions{Executable: test.executable}, test.args...)
+		assert.NoError(t, err)
+		assert.EqS(t, []string{test.expected}, called)
+	}
+}
+
+func TestCmd_Experimental(t *testing.T) {
+	newRoot := func() *Cmd {
+		run := func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil }
+		return &Cmd{
+			Pattern: "tool",
+			Run:     run,
+			Children: []*Cmd{{
+				Pattern: "beta",
+				State:   CmdStateExperimental,
+				Children: []*Cmd{{
+					Pattern: "run",
+					Run:     run,
+				}},
+			}},
+		}
+	}
+
+	t.Run("Warn", func(t *testing.T) {
+		root := newRoot()
+
+		var sb strings.Builder
+		assert.NoError(t, root.Exec(&CmdOptions{Stderr: &sb}))
+		assert.Eq(t, "", sb.String())
+
+		assert.NoError(t, root.Exec(&CmdOptions{Stderr: &sb}, "beta", "run"))
+		assert.Eq(t, "warning: command beta is experimental and may change or be removed\n", sb.String())
+
+		sb.Reset()
+		assert.NoError(t, root.Exec(&CmdOptions{Stderr: &sb}, "beta", "run"))
+		assert.Eq(t, "", sb.String()) // warned only once
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		var sb strings.Builder
+		assert.NoError(t, newRoot().Exec(&CmdOptions{Stderr: &sb, AllowExperimental: true}, "beta", "run"))
+		assert.Eq(t, "", sb.String())
+
+		assert.NoError(t, newRoot().Exec(&CmdOptions{
+			Stderr:    &sb,
+			EnvPrefix: "TOOL",
+			ParseOptions: &ParseOptions{
+				LookupEnv: func(key string) (string, bool) {
+					if key == "TOOL_ALLOW_EXPERIMENTAL" {
+						return "1", true
+					}
+					return "", false
+				},
+			},
+		}, "beta", "run"))
+		assert.Eq(t, "", sb.String())
+	})
+
+	t.Run("Completion", func(t *testing.T) {
+		var tsk CompTask
+		tsk.Init(newRoot(), nil, 1, "./tool", "")
+		tsk.AddSubcmds(false, nil, true)
+		assert.EqS(t, []CompItem{{Value: "beta", Description: "(experimental)"}}, tsk.result)
+	})
+}
@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 The Prime Citizens
+
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// VersionInfo is the version information of an application.
+//
+// To produce consistent content, all values SHOULD NOT contain leading or
+// trailing whitespaces.
+type VersionInfo struct {
+	// Version is the version of the application (e.g. `v1.2.3`).
+	Version string
+
+	// Commit is the source revision the application was built from.
+	Commit string
+
+	// BuildDate is the time the application was built.
+	BuildDate string
+
+	// GoVersion is the go toolchain version used to build the application.
+	//
+	// Defaults to runtime.Version().
+	GoVersion string
+}
+
+// VersionFormat is the output format of VersionInfo.
+type VersionFormat uint8
+
+const (
+	// VersionFormatPlain writes one `<name>: <value>` per line.
+	VersionFormatPlain VersionFormat = iota
+
+	// VersionFormatJSON writes a JSON object in one line.
+	VersionFormatJSON
+)
+
+// WriteVersion writes all non-empty fields of v to out in the format.
+func (v *VersionInfo) WriteVersion(out io.Writer, format VersionFormat) (n int, err error) {
+	goVersion := v.GoVersion
+	if len(goVersion) == 0 {
+		goVersion = runtime.Version()
+	}
+
+	if format == VersionFormatJSON {
+		buf, err := json.Marshal(struct {
+			Version   string `json:"version,omitempty"`
+			Commit    string `json:"commit,omitempty"`
+			BuildDate string `json:"buildDate,omitempty"`
+			GoVersion string `json:"goVersion,omitempty"`
+		}{v.Version, v.Commit, v.BuildDate, goVersion})
+		if err != nil {
+			return 0, err
+		}
+
+		return out.Write(append(buf, '\n'))
+	}
+
+	var x int
+	for _, f := range [...][2]string{
+		{"version", v.Version},
+		{"commit", v.Commit},
+		{"build date", v.BuildDate},
+		{"go version", goVersion},
+	} {
+		if len(f[1]) == 0 {
+			continue
+		}
+
+		x, err = wstr(out, f[0]+": "+f[1]+"\n")
+		n += x
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// VersionCmd is the `version` command writing the VersionInfo to stdout.
+//
+// Its HandleVersionRequest method can be used as
+// CmdOptions.HandleVersionRequest to support the `--version` flag.
+type VersionCmd struct {
+	// Format is the output format of both the `version` command and the
+	// `--version` flag (see HandleVersionRequest), it is kept by Setup.
+	Format VersionFormat
+
+	// JSON makes the `version` command write the version info as JSON
+	// regardless of Format.
+	JSON BoolV
+
+	// Info is the version info to write.
+	Info VersionInfo
+
+	self Cmd
+}
+
+// Setup returns the initialized *Cmd.
+func (vc *VersionCmd) Setup(info VersionInfo) *Cmd {
+	*vc = VersionCmd{
+		Format: vc.Format,
+		JSON: BoolV{
+			BriefUsage: "write version info as JSON",
+		},
+		Info: info,
+		self: Cmd{
+			Pattern:    "version",
+			BriefUsage: "Show version info",
+			LocalFlags: vc,
+			Run:        runVersion,
+		},
+	}
+
+	return &vc.self
+}
+
+// NthFlag implements [FlagIter].
+func (vc *VersionCmd) NthFlag(i int) (info FlagInfo, ok bool) {
+	switch i {
+	case 0:
+		return FlagInfo{Name: "json"}, true
+	default:
+		return
+	}
+}
+
+// FindFlag implements [FlagFinder].
+func (vc *VersionCmd) FindFlag(name string) (Flag, bool) {
+	switch name {
+	case "json":
+		return &vc.JSON, true
+	default:
+		return nil, false
+	}
+}
+
+// HandleVersionRequest writes the version info to stdout in vc.Format, it
+// implements VersionHandleFunc.
+func (vc *VersionCmd) HandleVersionRequest(opts *CmdOptions, route Route, args []string, versionArgAt int) error {
+	_, err := vc.Info.WriteVersion(opts.PickStdout(os.Stdout), vc.Format)
+	return err
+}
+
+func runVersion(opts *CmdOptions, route Route, posArgs, dashArgs []string) error {
+	self := route.Target().LocalFlags.(*VersionCmd)
+	format := self.Format
+	if self.JSON.Value {
+		format = VersionFormatJSON
+	}
+
+	_, err := self.Info.WriteVersion(opts.PickStdout(os.Stdout), format)
+	return err
+}
+
+// indexVersionArg returns the index of the first `--version` arg parsed as a
+// flag of the root Cmd c (before its first positional arg), returns -1 if not
+// found or c has its own `version` flag.
+//
+// Args after the first positional arg belong to subcommands, thus a
+// subcommand's own `--version` flag (or value) is never taken as version
+// request.
+func indexVersionArg(c *Cmd, opts *CmdOptions, args []string) (at int) {
+	root := Route{c}
+	if _, ok := root.FindFlag("version"); ok {
+		return -1
+	}
+
+	var popts ParseOptions
+	if opts.ParseOptions != nil {
+		popts = *opts.ParseOptions
+	}
+
+	// report parse errors in ResolveTarget instead.
+	popts.HandleParseError = nil
+	popts.CollectUnknown = nil
+	popts.PosArgsBuf = nil
+
+	at = -1
+	popts.Trace = func(event ParseEvent) {
+		if at >= 0 || event.Kind != ParseEventLongFlag {
+			return
+		}
+
+		name, _, _ := strings.Cut(event.Arg[2:], popts.assignSep())
+		if name == "version" {
+			at = event.At
+		}
+	}
+
+	_, _, _, _, _, _ = ParseFlagsLowLevel(
+		args, &versionFlagFinder{root: root}, &popts,
+		0,
+		false, // appendPosArgs
+		true,  // stopAtFirstPosArg
+		false, // setFlagValue
+		nil,   // posArgsBuf
+	)
+
+	return
+}
+
+// versionFlagFinder finds flags of the root Cmd and the pseudo `version`
+// flag.
+type versionFlagFinder struct {
+	root    Route
+	version BoolV
+}
+
+// FindFlag implements [FlagFinder].
+func (f *versionFlagFinder) FindFlag(name string) (Flag, bool) {
+	if name == "version" {
+		return &f.version, true
+	}
+
+	return f.root.FindFlag(name)
+}
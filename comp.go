@@ -5,7 +5,10 @@ package cli
 
 import (
 	"io"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
@@ -54,6 +57,10 @@ const (
 	CompKindFlagValue
 	CompKindFiles
 	CompKindDirs
+
+	// CompKindMessage is a message shown to the user instead of a completion
+	// candidate, its Value is empty and the Description is the message.
+	CompKindMessage
 )
 
 // A CompItem is a completion suggestion.
@@ -125,6 +132,107 @@ func (CompActionFiles) Suggest(tsk *CompTask) (int, CompState) {
 	return tsk.AddFiles(false), 0
 }
 
+// CompActionSizeUnits appends size unit suffixes (B, KB, MB ...) to the
+// number being completed.
+type CompActionSizeUnits struct{}
+
+// Suggest implements [CompAction].
+func (CompActionSizeUnits) Suggest(tsk *CompTask) (added int, _ CompState) {
+	if !endsWithDigit(tsk.ToComplete) {
+		return
+	}
+
+	added = tsk.AddMatched(false, CompItem{
+		Value: tsk.ToComplete + "B",
+		Kind:  CompKindFlagValue,
+	})
+
+	for i := 1; sizeUnitText(i) != 0; i++ {
+		added += tsk.AddMatched(false, CompItem{
+			Value: tsk.ToComplete + string([]byte{sizeUnitText(i), 'B'}),
+			Kind:  CompKindFlagValue,
+		})
+	}
+
+	return
+}
+
+// CompActionDurationUnits appends duration unit suffixes (ns, us, ms ...) to
+// the number being completed.
+type CompActionDurationUnits struct{}
+
+// Suggest implements [CompAction].
+func (CompActionDurationUnits) Suggest(tsk *CompTask) (added int, _ CompState) {
+	if !endsWithDigit(tsk.ToComplete) {
+		return
+	}
+
+	for _, unit := range durationUnits {
+		added += tsk.AddMatched(false, CompItem{
+			Value: tsk.ToComplete + unit,
+			Kind:  CompKindFlagValue,
+		})
+	}
+
+	return
+}
+
+// CompActionBool suggests `true` and `false`.
+type CompActionBool struct{}
+
+// Suggest implements [CompAction].
+func (CompActionBool) Suggest(tsk *CompTask) (int, CompState) {
+	return tsk.AddMatched(false, CompItem{
+		Value: "true",
+		Kind:  CompKindFlagValue,
+	}, CompItem{
+		Value: "false",
+		Kind:  CompKindFlagValue,
+	}), 0
+}
+
+// CompActionTriState suggests `on`, `off` and `auto`.
+type CompActionTriState struct{}
+
+// Suggest implements [CompAction].
+func (CompActionTriState) Suggest(tsk *CompTask) (int, CompState) {
+	return tsk.AddMatched(false, CompItem{
+		Value: "on",
+		Kind:  CompKindFlagValue,
+	}, CompItem{
+		Value: "off",
+		Kind:  CompKindFlagValue,
+	}, CompItem{
+		Value: "auto",
+		Kind:  CompKindFlagValue,
+	}), 0
+}
+
+// compActionForType returns the CompAction hinting values of the flag type
+// typ (as returned by Flag.Type()):
+//
+//   - bool: CompActionBool
+//   - tristate: CompActionTriState
+//   - size: CompActionSizeUnits
+//   - dur:  CompActionDurationUnits
+//
+// It returns nil for other types (e.g. int), as there is no meaningful
+// suggestion.
+func compActionForType(typ string) CompAction {
+	switch typ {
+	case "bool", "[]bool":
+		return CompActionBool{}
+	case "tristate", "[]tristate":
+		return CompActionTriState{}
+	case "size", "ssum", "[]size":
+		return CompActionSizeUnits{}
+	case "dur", "dsum", "[]dur":
+		return CompActionDurationUnits{}
+	}
+
+	return nil
+}
+
 // CompTask represents a completion tsk.
 type CompTask struct {
 	debug  io.Writer
@@ -176,8 +284,37 @@ type CompTask struct {
 	FlagMissingValue Flag
 	FlagValuePrefix  string
 
-	state CompState
-	want  CompState
+	// BoolNegation is ParseOptions.BoolNegation, when true, AddFlagNames
+	// also adds `--no-<name>` for bool flags.
+	BoolNegation bool
+
+	// IncludeHidden makes AddFlagNames and AddSubcmds include hidden flags
+	// and hidden subcommands.
+	IncludeHidden bool
+
+	// NoFuzzy disables suggesting similar names (see SimilarityThreshold)
+	// in AddSubcmds and AddFlagNames when there is no prefix match, so only
+	// names with tsk.ToComplete prefix are suggested.
+	NoFuzzy bool
+
+	// NoAliases makes AddSubcmds suggest only the primary name of each
+	// subcommand, otherwise aliases of subcommands (names after the first
+	// one in Cmd.Pattern) are suggested as well, with descriptions noting
+	// they are aliases.
+	NoAliases bool
+
+	// Limit caps the count of CompItems written as completion result when
+	// greater than zero.
+	//
+	// When exceeded, items are picked from each CompKind in turn, followed by
+	// a CompItem of CompKindMessage telling the count of items omitted (e.g.
+	// `and 42 more`), which shells show as a message rather than a
+	// selectable completion value.
+	Limit int
+
+	state  CompState
+	want   CompState
+	stream *compStream
 }
 
 // RawToComplete returns the unprocessed arg value to complete.
@@ -234,16 +371,95 @@ func (tsk *CompTask) Nth(i int) (CompItem, bool) {
 	return CompItem{}, false
 }
 
+// IsFlagSet returns true if the flag with name is found in tsk.Route and its
+// value was set by args before the arg to complete.
+func (tsk *CompTask) IsFlagSet(name string) bool {
+	f, ok := tsk.Route.FindFlag(name)
+	return ok && f.State().ValueChanged()
+}
+
+// FlagValue returns the text representation of the current value of the
+// flag with name, as written by its PrintValue method.
+//
+// It returns false if the flag is not found in tsk.Route or has no value.
+func (tsk *CompTask) FlagValue(name string) (string, bool) {
+	f, ok := tsk.Route.FindFlag(name)
+	if !ok || !f.HasValue() {
+		return "", false
+	}
+
+	var sb strings.Builder
+	_, err := f.PrintValue(&sb)
+	if err != nil {
+		return "", false
+	}
+
+	return sb.String(), true
+}
+
+// StringFlag is like FlagValue but returns the value only, it returns
+// empty string if there is no value.
+func (tsk *CompTask) StringFlag(name string) string {
+	value, _ := tsk.FlagValue(name)
+	return value
+}
+
+// applyLimit truncates added CompItems according to tsk.Limit.
+func (tsk *CompTask) applyLimit() {
+	if tsk.Limit <= 0 || len(tsk.result) <= tsk.Limit {
+		return
+	}
+
+	var (
+		picked = make([]bool, len(tsk.result))
+		next   [CompKindDirs + 1]int
+		n      int
+	)
+
+	// take one item from each kind at a time until reaching the limit.
+	for last := -1; n < tsk.Limit && n != last; {
+		last = n
+		for kind := range next {
+			for ; next[kind] < len(tsk.result); next[kind]++ {
+				if tsk.result[next[kind]].Kind == CompKind(kind) {
+					picked[next[kind]] = true
+					next[kind]++
+					n++
+					break
+				}
+			}
+
+			if n == tsk.Limit {
+				break
+			}
+		}
+	}
+
+	omitted := len(tsk.result) - n
+	result := tsk.result[:0]
+	for i, item := range tsk.result {
+		if picked[i] {
+			result = append(result, item)
+		}
+	}
+
+	tsk.result = append(result, CompItem{
+		Description: "and " + strconv.Itoa(omitted) + " more",
+		Kind:        CompKindMessage,
+	})
+}
+
 // Init initializes the CompTask with command-line options.
 //
 // If pos is in range [0, len(args)), args[pos] is the arg to complete.
 //
 // If the args slice is not empty, args[0] is expected to be the executable
-// path.
+// path, it selects the root command from root.MultiCall (if any).
 func (tsk *CompTask) Init(root *Cmd, opts *CmdOptions, at int, args ...string) {
 	if len(args) > 0 {
 		// shift 1 (cannot be completing the executable path)
 		tsk.ExecutablePath, args = args[0], args[1:]
+		root = root.MultiCallTarget(tsk.ExecutablePath)
 		if at >= 0 {
 			at--
 		}
@@ -264,6 +480,9 @@ func (tsk *CompTask) Init(root *Cmd, opts *CmdOptions, at int, args ...string) {
 		end = len(args)
 	}
 
+	tsk.BoolNegation = opts != nil && opts.ParseOptions != nil && opts.ParseOptions.BoolNegation
+	tsk.stream = nil
+
 	var err error
 	tsk.Route, tsk.PosArgs, tsk.DashArgs, err = root.ResolveTarget(opts, args[:end]...)
 	if err != nil {
@@ -281,13 +500,30 @@ func (tsk *CompTask) Init(root *Cmd, opts *CmdOptions, at int, args ...string) {
 		}
 	}
 
+	if tsk.DashArgs != nil { // after the dash
+		target := tsk.Route.Target()
+		switch {
+		case target.DashArgsCompletion != nil:
+			tsk.want = 0
+			return
+		case target.DashArgsFiles:
+			tsk.want = CompStateHasFiles
+			return
+		}
+	}
+
 	switch toComplete := tsk.ToComplete; {
 	case len(toComplete) == 0:
 		tsk.want = CompStateHasFlagNames | CompStateHasSubcmds
 	case !strings.HasPrefix(toComplete, "-"): // no hyphen prefix, cannot be a flag name
 		tsk.want = CompStateHasSubcmds
 	case /* has hyphen prefix && */ len(toComplete) > 1:
-		pos := strings.IndexByte(toComplete, '=')
+		var popts *ParseOptions
+		if opts != nil {
+			popts = opts.ParseOptions
+		}
+
+		pos := strings.Index(toComplete, popts.assignSep())
 		if pos < 0 {
 			// just in case there is sub-command name with hyphen prefix
 			tsk.want = CompStateHasSubcmds | CompStateHasFlagNames
@@ -298,6 +534,9 @@ func (tsk *CompTask) Init(root *Cmd, opts *CmdOptions, at int, args ...string) {
 		flag, value := toComplete[:pos], toComplete[pos+1:]
 		switch {
 		case flag[1] != '-': // can assume shorthand (maybe cluster)
+			// only the last shorthand in the cluster can take the explicit
+			// value (e.g. `c` in `-abc=value`), shorthands before it are
+			// left to the parser to report.
 			_, sz := utf8.DecodeLastRuneInString(flag)
 			f, ok := tsk.Route.FindFlag(flag[len(flag)-sz:])
 			if ok {
@@ -334,10 +573,55 @@ func (tsk *CompTask) Add(force bool, items ...CompItem) (added int) {
 		return
 	}
 
-	tsk.result = append(tsk.result, items...)
+	if tsk.stream != nil {
+		tsk.stream.write(tsk, items...)
+	} else {
+		tsk.result = append(tsk.result, items...)
+	}
 	return len(items)
 }
 
+// compStream writes CompItems as soon as they are added to a CompTask.
+type compStream struct {
+	mu      sync.Mutex
+	out     io.Writer
+	fmt     CompItemFormatter
+	err     error
+	stopped bool
+}
+
+func (s *compStream) write(tsk *CompTask, items ...CompItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped || s.err != nil {
+		return
+	}
+
+	for i := range items {
+		s.err = s.fmt.FormatItem(s.out, tsk, &items[i])
+		if s.err != nil {
+			return
+		}
+	}
+
+	switch f := s.out.(type) {
+	case interface{ Flush() error }: // e.g. *bufio.Writer
+		s.err = f.Flush()
+	case interface{ Flush() }: // e.g. http.Flusher
+		f.Flush()
+	}
+}
+
+// stop prevents further writes and returns the first error happened.
+func (s *compStream) stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	return s.err
+}
+
 // AddMatched filters CompItems and only adds those with tsk.ToComplete prefix.
 func (tsk *CompTask) AddMatched(force bool, items ...CompItem) (added int) {
 	if !force && (tsk.state&(CompStateFailed|CompStateDone) != 0) {
@@ -347,7 +631,11 @@ func (tsk *CompTask) AddMatched(force bool, items ...CompItem) (added int) {
 	for i := range items {
 		if strings.HasPrefix(items[i].Value, tsk.ToComplete) {
 			added++
-			tsk.result = append(tsk.result, items[i])
+			if tsk.stream != nil {
+				tsk.stream.write(tsk, items[i])
+			} else {
+				tsk.result = append(tsk.result, items[i])
+			}
 		}
 	}
 
@@ -362,6 +650,8 @@ func (tsk *CompTask) AddDefault() (added int) {
 		tsk.state |= s
 	}
 
+	added += tsk.addDashArg()
+
 	if tsk.Want().HasFlagValues() {
 		added += tsk.AddFlagValues(false, tsk.FlagMissingValue, "", false)
 	}
@@ -371,9 +661,50 @@ func (tsk *CompTask) AddDefault() (added int) {
 	}
 
 	if tsk.Want().HasSubcmds() {
-		added += tsk.AddSubcmds(false, nil, true)
+		n := tsk.AddSubcmds(false, nil, true)
+		if n == 0 {
+			n = tsk.addPosArg()
+		}
+		added += n
+	}
+
+	if tsk.Want().HasFiles() {
+		added += tsk.AddFiles(false)
+	}
+
+	return
+}
+
+// addDashArg adds CompItems suggested by the DashArgsCompletion of the
+// target Cmd for the arg after the dash.
+func (tsk *CompTask) addDashArg() (added int) {
+	target := tsk.Route.Target()
+	if tsk.DashArgs == nil || target == nil || target.DashArgsCompletion == nil {
+		return
+	}
+
+	var s CompState
+	added, s = target.DashArgsCompletion.Suggest(tsk)
+	tsk.state |= s
+	return
+}
+
+// addPosArg adds CompItems suggested by the PosArgCompletion of the target
+// Cmd for the positional arg to complete.
+func (tsk *CompTask) addPosArg() (added int) {
+	target := tsk.Route.Target()
+	if target == nil {
+		return
+	}
+
+	i := len(tsk.PosArgs)
+	if i >= len(target.PosArgCompletion) || target.PosArgCompletion[i] == nil {
+		return
 	}
 
+	var s CompState
+	added, s = target.PosArgCompletion[i].Suggest(tsk)
+	tsk.state |= s
 	return
 }
 
@@ -393,17 +724,42 @@ func (tsk *CompTask) AddSubcmds(force bool, cmd *Cmd, descr bool) (added int) {
 		cmd = tsk.Route.Target()
 	}
 
+	// only suggest similar names when there is no prefix match.
+	fuzzy := !tsk.NoFuzzy
 	for _, child := range cmd.Children {
-		if child == nil || child.State.Hidden() {
+		if child == nil || (child.State.Hidden() && !tsk.IncludeHidden) {
 			continue
 		}
 
 		var name string
 		names, _, _ := strings.Cut(child.Pattern, " ")
+		if tsk.NoAliases {
+			names, _, _ = strings.Cut(names, "|")
+		}
+
+		for len(names) != 0 && fuzzy {
+			name, names, _ = strings.Cut(names, "|")
+			fuzzy = !strings.HasPrefix(name, tsk.ToComplete)
+		}
+	}
+
+	for _, child := range cmd.Children {
+		if child == nil || (child.State.Hidden() && !tsk.IncludeHidden) {
+			continue
+		}
+
+		var name string
+		names, _, _ := strings.Cut(child.Pattern, " ")
+		primary, _, _ := strings.Cut(names, "|")
 		for len(names) != 0 {
 			name, names, _ = strings.Cut(names, "|")
+			alias := name != primary
+			if alias && tsk.NoAliases {
+				break
+			}
+
 			if !strings.HasPrefix(name, tsk.ToComplete) &&
-				!isSimilar(name, tsk.ToComplete, true) {
+				!(fuzzy && isSimilar(name, tsk.ToComplete, true)) {
 				continue
 			}
 
@@ -413,6 +769,13 @@ func (tsk *CompTask) AddSubcmds(force bool, cmd *Cmd, descr bool) (added int) {
 
 			if descr {
 				item.Description = child.BriefUsage
+				if child.State.Experimental() {
+					item.Description = strings.TrimSuffix("(experimental) "+item.Description, " ")
+				}
+
+				if alias {
+					item.Description = strings.TrimSuffix("(alias of "+primary+") "+item.Description, " ")
+				}
 			}
 
 			added += tsk.Add(force, item)
@@ -425,7 +788,8 @@ func (tsk *CompTask) AddSubcmds(force bool, cmd *Cmd, descr bool) (added int) {
 // AddFlagNames adds flag names, it expects tsk.ToComplete either being an
 // empty string or containing a flag name prefix (`-`, `--`).
 //
-// If the argument `flags` is nil, use the target command's flags (tsk.Route).
+// If the argument `flags` is nil, use the target command's flags (tsk.Route)
+// listed by [Route.AllFlags], so shadowed flags are not added.
 func (tsk *CompTask) AddFlagNames(force bool, flags FlagIndexer, descr bool) (added int) {
 	if !force && (tsk.state&(CompStateHasFlagNames|CompStateFailed|CompStateDone) != 0) {
 		return
@@ -433,20 +797,25 @@ func (tsk *CompTask) AddFlagNames(force bool, flags FlagIndexer, descr bool) (ad
 
 	tsk.state |= CompStateHasFlagNames
 
+	var iter FlagIter = flags
 	if flags == nil {
 		flags = noescape(&tsk.Route)
+		// skip flags shadowed by ones closer to the target.
+		iter = flagInfos(noescape(&tsk.Route).AllFlags())
 	}
 
 	switch toComplete := tsk.ToComplete; {
 	case len(toComplete) == 0 || toComplete == "-": // all flags not hidden can be added
 		for i := 0; ; i++ {
-			info, ok := flags.NthFlag(i)
+			info, ok := iter.NthFlag(i)
 			if !ok {
 				break
 			}
 
+			info = info.normalized()
+
 			_, f, ok := FindFlag(flags, info.Name, info.Shorthand)
-			if !ok || f.State().Hidden() {
+			if !ok || (f.State().Hidden() && !tsk.IncludeHidden) {
 				continue
 			}
 
@@ -461,6 +830,7 @@ func (tsk *CompTask) AddFlagNames(force bool, flags FlagIndexer, descr bool) (ad
 				}
 
 				added += tsk.Add(force, item)
+				added += tsk.addNegatedFlagName(force, info.Name, f, descr)
 			}
 
 			if IsShorthand(info.Shorthand) {
@@ -477,35 +847,77 @@ func (tsk *CompTask) AddFlagNames(force bool, flags FlagIndexer, descr bool) (ad
 			}
 		}
 	case strings.HasPrefix(toComplete, "--"): // long flags not hidden may be added
+		// only suggest similar names when there is no prefix match.
+		fuzzy := !tsk.NoFuzzy
+		for i := 0; fuzzy; i++ {
+			info, ok := iter.NthFlag(i)
+			if !ok {
+				break
+			}
+
+			info = info.normalized()
+			fuzzy = len(info.Name) == 0 || !strings.HasPrefix(info.Name, toComplete[2:]) &&
+				!hasAnyPrefix(info.Aliases, toComplete[2:])
+		}
+
 		for i := 0; ; i++ {
-			info, ok := flags.NthFlag(i)
+			info, ok := iter.NthFlag(i)
 			if !ok {
 				break
 			}
 
-			if len(info.Name) == 0 ||
-				IsShorthand(info.Name) ||
-				!strings.HasPrefix(info.Name, toComplete[2:]) {
-				if !isSimilar(info.Name, toComplete[2:], true) {
-					continue
-				}
+			info = info.normalized()
+
+			long := len(info.Name) != 0 && !IsShorthand(info.Name)
+			nameMatched := long && (strings.HasPrefix(info.Name, toComplete[2:]) ||
+				(fuzzy && isSimilar(info.Name, toComplete[2:], true)))
+			negMatched := long && tsk.BoolNegation && strings.HasPrefix("no-"+info.Name, toComplete[2:])
+			// aliases are only suggested when the name doesn't match.
+			aliasMatched := long && !nameMatched && hasAnyPrefix(info.Aliases, toComplete[2:])
+			if !nameMatched && !negMatched && !aliasMatched {
+				continue
 			}
 
 			_, f, ok := FindFlag(flags, info.Name, info.Shorthand)
-			if !ok || f.State().Hidden() {
+			if !ok || (f.State().Hidden() && !tsk.IncludeHidden) {
 				continue
 			}
 
-			item := CompItem{
-				Value: info.Name,
-				Kind:  CompKindFlagName,
+			if nameMatched {
+				item := CompItem{
+					Value: info.Name,
+					Kind:  CompKindFlagName,
+				}
+
+				if descr {
+					item.Description = f.Usage()
+				}
+
+				added += tsk.Add(force, item)
 			}
 
-			if descr {
-				item.Description = f.Usage()
+			if negMatched {
+				added += tsk.addNegatedFlagName(force, info.Name, f, descr)
 			}
 
-			added += tsk.Add(force, item)
+			if aliasMatched {
+				for _, alias := range info.Aliases {
+					if !strings.HasPrefix(alias, toComplete[2:]) {
+						continue
+					}
+
+					item := CompItem{
+						Value: alias,
+						Kind:  CompKindFlagName,
+					}
+
+					if descr {
+						item.Description = f.Usage()
+					}
+
+					added += tsk.Add(force, item)
+				}
+			}
 		}
 	case strings.HasPrefix(toComplete, "-"):
 		// has hyphen prefix but not dash prefix, and also not just a single
@@ -514,22 +926,20 @@ func (tsk *CompTask) AddFlagNames(force bool, flags FlagIndexer, descr bool) (ad
 		// are always one rune in length, so it is to confirm the existence
 		// of the flag.
 		for i := 0; ; i++ {
-			info, ok := flags.NthFlag(i)
+			info, ok := iter.NthFlag(i)
 			if !ok {
 				break
 			}
 
+			info = info.normalized()
+
 			shorthand := info.Shorthand
 			if !IsShorthand(shorthand) {
-				if IsShorthand(info.Name) {
-					shorthand = info.Name
-				} else {
-					continue
-				}
+				continue
 			}
 
 			_, f, ok := FindFlag(flags, info.Name, info.Shorthand)
-			if !ok || f.State().Hidden() || !strings.Contains(tsk.ToComplete, shorthand) {
+			if !ok || (f.State().Hidden() && !tsk.IncludeHidden) || !strings.Contains(tsk.ToComplete, shorthand) {
 				continue
 			}
 
@@ -549,13 +959,33 @@ func (tsk *CompTask) AddFlagNames(force bool, flags FlagIndexer, descr bool) (ad
 	return
 }
 
+// addNegatedFlagName adds `no-<name>` for bool flag when tsk.BoolNegation is
+// true.
+func (tsk *CompTask) addNegatedFlagName(force bool, name string, f Flag, descr bool) int {
+	if !tsk.BoolNegation || !isBoolFlag(f) {
+		return 0
+	}
+
+	item := CompItem{
+		Value: "no-" + name,
+		Kind:  CompKindFlagName,
+	}
+
+	if descr {
+		item.Description = "disable " + name
+	}
+
+	return tsk.Add(force, item)
+}
+
 // AddFlagValues adds matched values from the specified flag.
 //
 // It retrieves completion suggestions by trying following methods in order:
 //   - cast flag as CompAction.
 //   - cast flag.Extra() as CompAction.
+//   - type-driven hints according to flag.Type() (see compActionForType).
 //
-// If addDefaults is true:
+// If addDefaults is true and the flag is not secret (see FlagStateSecret):
 //   - add value returned by Flag.Default() if matched.
 //   - deduce default value by flag State() unchange and HasValue() true.
 func (tsk *CompTask) AddFlagValues(force bool, flag Flag, def string, addDefaults bool) (added int) {
@@ -567,7 +997,11 @@ func (tsk *CompTask) AddFlagValues(force bool, flag Flag, def string, addDefault
 
 	comp, ok := flag.(CompAction)
 	if !ok {
-		comp, _ = flag.Extra().(CompAction)
+		comp, ok = flag.Extra().(CompAction)
+	}
+	if !ok {
+		typ, _ := flag.Type()
+		comp = compActionForType(typ)
 	}
 	if comp != nil {
 		var s CompState
@@ -575,7 +1009,7 @@ func (tsk *CompTask) AddFlagValues(force bool, flag Flag, def string, addDefault
 		tsk.state |= s
 	}
 
-	if !addDefaults {
+	if !addDefaults || flag.State().Secret() {
 		return
 	}
 
@@ -631,6 +1065,9 @@ DeduceDefault:
 }
 
 // AddFiles adds requests to match filesystem files.
+//
+// A leading `~` in globs is expanded to the home directory of the current
+// user (e.g. `~/.config/*.toml`), as shells do not expand it in filters.
 func (tsk *CompTask) AddFiles(force bool, globs ...string) (added int) {
 	if !force && (tsk.state&(CompStateHasFiles|CompStateFailed|CompStateDone) != 0) {
 		return
@@ -644,7 +1081,7 @@ func (tsk *CompTask) AddFiles(force bool, globs ...string) (added int) {
 		}
 
 		added += tsk.Add(force, CompItem{
-			Value: glob,
+			Value: expandTilde(glob),
 			Kind:  CompKindFiles,
 		})
 	}
@@ -659,6 +1096,8 @@ func (tsk *CompTask) AddFiles(force bool, globs ...string) (added int) {
 }
 
 // AddDirs adds requests to match filesystem dirs.
+//
+// A leading `~` in globs is expanded as in AddFiles.
 func (tsk *CompTask) AddDirs(force bool, globs ...string) (added int) {
 	if !force && (tsk.state&(CompStateHasDirs|CompStateFailed|CompStateDone) != 0) {
 		return
@@ -672,7 +1111,7 @@ func (tsk *CompTask) AddDirs(force bool, globs ...string) (added int) {
 		}
 
 		added += tsk.Add(force, CompItem{
-			Value: glob,
+			Value: expandTilde(glob),
 			Kind:  CompKindDirs,
 		})
 	}
@@ -685,3 +1124,20 @@ func (tsk *CompTask) AddDirs(force bool, globs ...string) (added int) {
 
 	return
 }
+
+// expandTilde replaces the leading `~` (followed by nothing or a slash) in
+// path with the home directory of the current user.
+//
+// path is returned as is if the home directory is unknown.
+func expandTilde(path string) string {
+	if len(path) == 0 || path[0] != '~' || len(path) > 1 && path[1] != '/' {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil || len(home) == 0 {
+		return path
+	}
+
+	return strings.TrimSuffix(home, "/") + path[1:]
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 The Prime Citizens
+
+package cli
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompCache caches completion results to avoid computing expensive
+// completions repeatedly.
+//
+// Keys are built from the route of the CompTask, the count of positional args
+// and all args before the arg to complete, and the arg to complete.
+type CompCache interface {
+	// GetComp returns the cached completion result of key, return false if
+	// there is no such result or it has expired.
+	GetComp(key string) (items []CompItem, state CompState, ok bool)
+
+	// PutComp caches the completion result of key.
+	//
+	// Implementations MUST copy items if they are retained.
+	PutComp(key string, items []CompItem, state CompState)
+}
+
+// CompCacheMem is an in-memory CompCache, its zero value is ready to use
+// and caches results forever.
+type CompCacheMem struct {
+	// TTL is the duration a cached result stays valid, when <= 0, results
+	// never expire.
+	TTL time.Duration
+
+	// Now returns the current time.
+	//
+	// Defaults to nil (use time.Now).
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]compCacheEntry
+}
+
+type compCacheEntry struct {
+	items   []CompItem
+	state   CompState
+	expires time.Time
+}
+
+func (c *CompCacheMem) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+
+	return time.Now()
+}
+
+// GetComp implements [CompCache].
+func (c *CompCacheMem) GetComp(key string) (items []CompItem, state CompState, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	if c.TTL > 0 && !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, 0, false
+	}
+
+	return e.items, e.state, true
+}
+
+// PutComp implements [CompCache].
+func (c *CompCacheMem) PutComp(key string, items []CompItem, state CompState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]compCacheEntry{}
+	}
+
+	var expires time.Time
+	if c.TTL > 0 {
+		expires = c.now().Add(c.TTL)
+	}
+
+	c.entries[key] = compCacheEntry{
+		items:   append([]CompItem(nil), items...),
+		state:   state,
+		expires: expires,
+	}
+}
+
+// compCacheKey returns the CompCache key of an initialized CompTask.
+func compCacheKey(tsk *CompTask) string {
+	var sb strings.Builder
+	_, _ = FormatRoute(&sb, tsk.Route, " ")
+
+	sb.WriteByte(0)
+	sb.WriteString(strconv.FormatUint(uint64(tsk.want), 10))
+	if tsk.IncludeHidden {
+		sb.WriteString("+hidden")
+	}
+	if tsk.NoAliases {
+		sb.WriteString("+noaliases")
+	}
+	if tsk.NoFuzzy {
+		sb.WriteString("+nofuzzy")
+	}
+
+	// the positional index and preceding args (including flag values and
+	// the flag name missing value) decide what to complete.
+	sb.WriteByte(0)
+	sb.WriteString(strconv.Itoa(len(tsk.PosArgs)))
+	for i := 0; i < tsk.At && i < len(tsk.Args); i++ {
+		sb.WriteByte(0)
+		sb.WriteString(tsk.Args[i])
+	}
+
+	sb.WriteByte(0)
+	sb.WriteString(tsk.FlagValuePrefix)
+	sb.WriteString(tsk.ToComplete)
+	return sb.String()
+}
+
+// fromCache loads the completion result from cache, return false if not
+// found.
+func (tsk *CompTask) fromCache(cache CompCache, key string) bool {
+	items, state, ok := cache.GetComp(key)
+	if !ok {
+		return false
+	}
+
+	tsk.result = append(tsk.result[:0], items...)
+	tsk.state |= state
+	return true
+}
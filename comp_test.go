@@ -4,6 +4,9 @@
 package cli
 
 import (
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/primecitizens/cli/internal/assert"
@@ -15,6 +18,7 @@ var (
 	_ CompAction = CompActionDirs{}
 	_ CompAction = CompActionFiles{}
 	_ CompAction = CompActionDisable{}
+	_ CompAction = (*EnumV)(nil)
 )
 
 func TestCompTask_AddDefault(t *testing.T) {
@@ -340,6 +344,64 @@ func TestCompTask_AddDefault(t *testing.T) {
 	}
 }
 
+func TestCompTask_Init_ShorthandClusterValue(t *testing.T) {
+	var (
+		a, b   BoolV
+		output = &StringV{
+			Ext: &FlagHelp{
+				Completion: &CompActionStatic{
+					Suggestions: []CompItem{
+						{Value: "json", Kind: CompKindFlagValue},
+						{Value: "yaml", Kind: CompKindFlagValue},
+					},
+				},
+			},
+		}
+	)
+
+	root := &Cmd{
+		Pattern: "tool",
+		Flags: NewMapIndexer().
+			Add(&a, "all", "a").
+			Add(&b, "brief", "b").
+			Add(output, "output", "c"),
+		Children: []*Cmd{{Pattern: "list"}},
+	}
+
+	for _, test := range []struct {
+		toComplete string
+		flag       Flag
+		prefix     string
+		expected   []string
+	}{
+		{"-abc=", output, "-abc=", []string{"json", "yaml"}},
+		{"-abc=y", output, "-abc=", []string{"yaml"}},
+		{"-c=j", output, "-c=", []string{"json"}},
+		{"-acx=", nil, "", nil},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, 1, "./tool", test.toComplete)
+			tsk.AddDefault()
+
+			assert.Eq(t, test.flag, tsk.FlagMissingValue)
+			assert.Eq(t, test.prefix, tsk.FlagValuePrefix)
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+
+			if test.flag == nil {
+				assert.Eq(t, CompStateHasSubcmds, tsk.want)
+			} else {
+				assert.Eq(t, CompStateHasFlagValues, tsk.want)
+			}
+		})
+	}
+}
+
 func TestCompTask_AddSubcmds(t *testing.T) {
 	const descr = "some description"
 	root := &Cmd{
@@ -387,6 +449,284 @@ func TestCompTask_AddSubcmds(t *testing.T) {
 	}
 }
 
+func TestCompTask_AddSubcmds_NoAliases(t *testing.T) {
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{
+			{Pattern: "remove|rm", BriefUsage: "remove files"},
+			{Pattern: "list"},
+		},
+	}
+
+	for _, test := range []struct {
+		noAliases  bool
+		toComplete string
+		expected   []CompItem
+	}{
+		{true, "", []CompItem{
+			{Value: "remove", Description: "remove files"},
+			{Value: "list"},
+		}},
+		{true, "rm", nil},
+		{false, "", []CompItem{
+			{Value: "remove", Description: "remove files"},
+			{Value: "rm", Description: "(alias of remove) remove files"},
+			{Value: "list"},
+		}},
+		{false, "r", []CompItem{
+			{Value: "remove", Description: "remove files"},
+			{Value: "rm", Description: "(alias of remove) remove files"},
+		}},
+	} {
+		tsk := CompTask{
+			ToComplete: test.toComplete,
+			NoAliases:  test.noAliases,
+		}
+
+		assert.Eq(t, len(test.expected), tsk.AddSubcmds(false, root, true))
+		assert.EqS(t, test.expected, tsk.result)
+	}
+}
+
+func TestCompTask_AddSubcmds_Fuzzy(t *testing.T) {
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{
+			{Pattern: "build"},
+			{Pattern: "test"},
+			{Pattern: "install|i"},
+		},
+	}
+
+	for _, test := range []struct {
+		toComplete string
+		expected   []string
+	}{
+		{"buld", []string{"build"}},
+		{"biuld", []string{"build"}},
+		{"tset", []string{"test"}},
+		{"xyz", nil},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, 1, "./tool", test.toComplete)
+			tsk.AddDefault()
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+}
+
+func TestCompTask_NoFuzzy(t *testing.T) {
+	root := &Cmd{
+		Pattern: "tool",
+		Flags:   NewMapIndexer().Add(&BoolV{}, "verbose"),
+		Children: []*Cmd{
+			{Pattern: "build"},
+			{Pattern: "test"},
+		},
+	}
+
+	for _, test := range []struct {
+		noFuzzy    bool
+		toComplete string
+		expected   []string
+	}{
+		{false, "buld", []string{"build"}},
+		{true, "buld", nil},
+		{true, "bu", []string{"build"}},
+		{false, "--vrebose", []string{"verbose"}},
+		{true, "--vrebose", nil},
+		{true, "--verb", []string{"verbose"}},
+	} {
+		var tsk CompTask
+		tsk.Init(root, nil, 1, "./tool", test.toComplete)
+		tsk.NoFuzzy = test.noFuzzy
+		tsk.AddDefault()
+
+		var actual []string
+		for _, item := range tsk.result {
+			actual = append(actual, item.Value)
+		}
+		assert.EqS(t, test.expected, actual)
+	}
+}
+
+func TestCompTask_AddSubcmds_SimilarityThreshold(t *testing.T) {
+	defer func(f func(string) int) { SimilarityThreshold = f }(SimilarityThreshold)
+	SimilarityThreshold = func(string) int { return 2 }
+
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{
+			{Pattern: "build"},
+			{Pattern: "test"},
+		},
+	}
+
+	for _, test := range []struct {
+		toComplete string
+		expected   []string
+	}{
+		{"buld", []string{"build"}},
+		{"biuld", nil},
+		{"tset", nil},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, 1, "./tool", test.toComplete)
+			tsk.AddDefault()
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+}
+
+func TestCompTask_FlagValue(t *testing.T) {
+	var (
+		region StringV
+		zone   StringV
+	)
+
+	root := &Cmd{
+		Pattern: "tool",
+		Flags: NewMapIndexer().
+			Add(&region, "region", "r").
+			Add(&zone, "zone"),
+		Children: []*Cmd{
+			{
+				Pattern: "deploy",
+				Completion: CompActionFunc(func(tsk *CompTask) (added int, _ CompState) {
+					if tsk.IsFlagSet("region") {
+						added = tsk.Add(true, CompItem{Value: tsk.StringFlag("region") + "-a"})
+					}
+					return added, tsk.State()
+				}),
+			},
+		},
+	}
+
+	var tsk CompTask
+	tsk.Init(root, nil, 4, "./tool", "--region", "us-east", "deploy", "")
+	assert.True(t, tsk.IsFlagSet("region"))
+	assert.True(t, tsk.IsFlagSet("r"))
+	assert.False(t, tsk.IsFlagSet("zone"))
+	assert.False(t, tsk.IsFlagSet("unknown"))
+
+	value, ok := tsk.FlagValue("region")
+	assert.True(t, ok)
+	assert.Eq(t, "us-east", value)
+
+	_, ok = tsk.FlagValue("unknown")
+	assert.False(t, ok)
+	assert.Eq(t, "", tsk.StringFlag("unknown"))
+
+	_, _ = tsk.Route.Target().Completion.Suggest(&tsk)
+	assert.EqS(t, []CompItem{{Value: "us-east-a"}}, tsk.result)
+}
+
+func TestCompTask_AddDefault_PosArgs(t *testing.T) {
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{
+			{
+				Pattern: "deploy",
+				PosArgCompletion: []CompAction{
+					&CompActionStatic{Suggestions: []CompItem{
+						{Value: "dev"}, {Value: "prod"},
+					}},
+					&CompActionStatic{Suggestions: []CompItem{
+						{Value: "api"}, {Value: "web"},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"deploy", ""}, []string{"dev", "prod"}},
+		{[]string{"deploy", "p"}, []string{"prod"}},
+		{[]string{"deploy", "dev", ""}, []string{"api", "web"}},
+		{[]string{"deploy", "dev", "w"}, []string{"web"}},
+		{[]string{"deploy", "dev", "web", ""}, nil},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, len(test.args), append([]string{"./tool"}, test.args...)...)
+			tsk.AddDefault()
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+}
+
+func TestCompTask_AddDefault_DashArgs(t *testing.T) {
+	var opts struct {
+		Verbose bool `cli:"verbose|v"`
+	}
+
+	root := &Cmd{
+		Pattern: "tool",
+		Flags:   NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+		Children: []*Cmd{
+			{Pattern: "exec", DashArgsFiles: true},
+			{
+				Pattern:       "run",
+				DashArgsFiles: true,
+				DashArgsCompletion: &CompActionStatic{Suggestions: []CompItem{
+					{Value: "sh"}, {Value: "bash"},
+				}},
+			},
+			{Pattern: "env"},
+		},
+	}
+
+	type item struct {
+		Value string
+		Kind  CompKind
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected []item
+	}{
+		{[]string{"exec", "--", ""}, []item{{"", CompKindFiles}}},
+		{[]string{"exec", "-v", "--", "a", ""}, []item{{"", CompKindFiles}}},
+		{[]string{"exec", "--", "-"}, []item{{"", CompKindFiles}}},
+		{[]string{"exec", ""}, []item{{"verbose", CompKindFlagName}, {"v", CompKindFlagName}}},
+		{[]string{"run", "--", ""}, []item{{"sh", CompKindText}, {"bash", CompKindText}}},
+		{[]string{"run", "--", "b"}, []item{{"bash", CompKindText}}},
+		{[]string{"env", "--", ""}, []item{{"verbose", CompKindFlagName}, {"v", CompKindFlagName}}},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, len(test.args), append([]string{"./tool"}, test.args...)...)
+			tsk.AddDefault()
+
+			var actual []item
+			for _, it := range tsk.result {
+				actual = append(actual, item{it.Value, it.Kind})
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+}
+
 func TestCompTask_AddFlagNames(t *testing.T) {
 	const descr = "some description"
 	flags := NewMapIndexer().
@@ -448,6 +788,164 @@ func TestCompTask_AddFlagNames(t *testing.T) {
 	}
 }
 
+func TestCompTask_AddFlagNames_Shadowed(t *testing.T) {
+	root := &Cmd{
+		Pattern: "root",
+		Flags: NewMapIndexer().
+			Add(&BoolV{}, "verbose", "v").
+			Add(&StringV{}, "output", "o"),
+	}
+	child := &Cmd{
+		Pattern: "child",
+		Flags: NewMapIndexer().
+			Add(&IntV{}, "level", "o"),
+		LocalFlags: NewMapIndexer().
+			Add(&StringV{}, "verbose"),
+	}
+
+	tsk := CompTask{Route: Route{root, child}}
+	assert.Eq(t, 4, tsk.AddFlagNames(false, nil, false))
+	assert.EqS(t, []CompItem{
+		{Value: "verbose", Kind: CompKindFlagName},
+		{Value: "level", Kind: CompKindFlagName},
+		{Value: "o", Kind: CompKindFlagName},
+		{Value: "output", Kind: CompKindFlagName},
+	}, tsk.result)
+}
+
+func TestCompTask_AddFlagNames_OneRuneName(t *testing.T) {
+	infos := []FlagInfo{
+		{Name: "x"},                 // one-rune name is the shorthand
+		{Name: "y", Shorthand: "z"}, // one-rune name is dropped
+		{Name: "long"},
+	}
+	flags := FuncIndexer(func(name string, index int) (Flag, FlagInfo, bool) {
+		if index >= 0 {
+			if index < len(infos) {
+				return &BoolV{}, infos[index], true
+			}
+
+			return nil, FlagInfo{}, false
+		}
+
+		for _, info := range infos {
+			if info.Name == name || info.Shorthand == name {
+				return &BoolV{}, info, true
+			}
+		}
+
+		return nil, FlagInfo{}, false
+	})
+
+	for _, test := range []struct {
+		toComplete string
+		expected   []CompItem
+	}{
+		{"", []CompItem{
+			{Value: "x", Kind: CompKindFlagName},
+			{Value: "z", Kind: CompKindFlagName},
+			{Value: "long", Kind: CompKindFlagName},
+		}},
+		{"--", []CompItem{
+			{Value: "long", Kind: CompKindFlagName},
+		}},
+		{"-x", []CompItem{
+			{Value: "x", Kind: CompKindFlagName},
+		}},
+		{"-y", nil},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			tsk := CompTask{ToComplete: test.toComplete}
+			assert.Eq(t, len(test.expected), tsk.AddFlagNames(false, flags, true))
+			assert.EqS(t, test.expected, tsk.result)
+		})
+	}
+
+	type Opts struct {
+		Foo bool `cli:"x|y"`
+	}
+
+	var panicked any
+	func() {
+		defer func() { panicked = recover() }()
+		_, _ = NewReflectIndexer(DefaultReflectVPFactory{}, &Opts{}).NthFlag(0)
+	}()
+	assert.Eq[any](t, "invalid multiple shorthands `x` and `y`", panicked)
+}
+
+func TestCompTask_AddFlagNames_BoolNegation(t *testing.T) {
+	flags := NewMapIndexer().
+		Add(&BoolV{}, "verbose", "v").
+		Add(&StringV{}, "output")
+
+	for _, test := range []struct {
+		toComplete string
+		negation   bool
+		expected   []CompItem
+	}{
+		{"--", false, []CompItem{
+			{Value: "verbose", Kind: CompKindFlagName},
+			{Value: "output", Kind: CompKindFlagName},
+		}},
+		{"--", true, []CompItem{
+			{Value: "verbose", Kind: CompKindFlagName},
+			{Value: "no-verbose", Description: "disable verbose", Kind: CompKindFlagName},
+			{Value: "output", Kind: CompKindFlagName},
+		}},
+		{"--no", true, []CompItem{
+			{Value: "no-verbose", Description: "disable verbose", Kind: CompKindFlagName},
+		}},
+		{"--no", false, nil},
+		{"", true, []CompItem{
+			{Value: "verbose", Kind: CompKindFlagName},
+			{Value: "no-verbose", Description: "disable verbose", Kind: CompKindFlagName},
+			{Value: "v", Kind: CompKindFlagName},
+			{Value: "output", Kind: CompKindFlagName},
+		}},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			tsk := CompTask{
+				ToComplete:   test.toComplete,
+				BoolNegation: test.negation,
+			}
+
+			assert.Eq(t, len(test.expected), tsk.AddFlagNames(false, flags, true))
+			assert.EqS(t, test.expected, tsk.result)
+		})
+	}
+}
+
+func TestCompTask_AddFlagNames_Aliases(t *testing.T) {
+	flags := NewMapIndexer().
+		Add(&StringV{}, "color", "colour").
+		Add(&StringV{}, "output")
+
+	for _, test := range []struct {
+		toComplete string
+		expected   []CompItem
+	}{
+		{"--", []CompItem{
+			{Value: "color", Kind: CompKindFlagName},
+			{Value: "output", Kind: CompKindFlagName},
+		}},
+		{"--colo", []CompItem{
+			{Value: "color", Kind: CompKindFlagName},
+		}},
+		{"--colou", []CompItem{
+			{Value: "colour", Kind: CompKindFlagName},
+		}},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			tsk := CompTask{
+				ToComplete: test.toComplete,
+			}
+
+			assert.Eq(t, len(test.expected), tsk.AddFlagNames(false, flags, true))
+			assert.EqS(t, test.expected, tsk.result)
+		})
+	}
+}
+
 func TestCompTask_AddFlagValues(t *testing.T) {
 	flag := &FlagEmptyV{
 		Ext: &FlagHelp{
@@ -495,6 +993,365 @@ func TestCompTask_AddFlagValues(t *testing.T) {
 	}
 }
 
+func TestCompTask_AddFlagValues_Enum(t *testing.T) {
+	flag := &EnumV{
+		VP: VPEnum[string]{Choices: []string{"json", "yaml", "text"}},
+	}
+
+	for _, test := range []struct {
+		toComplete string
+		expected   []CompItem
+	}{
+		{"", []CompItem{
+			{Value: "json", Kind: CompKindFlagValue},
+			{Value: "yaml", Kind: CompKindFlagValue},
+			{Value: "text", Kind: CompKindFlagValue},
+		}},
+		{"y", []CompItem{
+			{Value: "yaml", Kind: CompKindFlagValue},
+		}},
+		{"x", nil},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			tsk := CompTask{
+				ToComplete: test.toComplete,
+			}
+
+			assert.Eq(t, len(test.expected), tsk.AddFlagValues(false, flag, "", true))
+			assert.EqS(t, test.expected, tsk.result)
+		})
+	}
+
+	assert.NoError(t, flag.Decode(nil, "format", "yaml", true))
+	assert.Eq(t, "yaml", flag.Value)
+	assert.Error(t, flag.Decode(nil, "format", "xml", true))
+}
+
+func TestCompTask_AddFlagValues_Units(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		flag       Flag
+		toComplete string
+		expected   []string
+	}{
+		{"size", &SizeV{}, "10", []string{"10B", "10KB", "10MB", "10GB", "10TB", "10PB", "10EB"}},
+		{"size no digit", &SizeV{}, "10K", nil},
+		{"size empty", &SizeV{}, "", nil},
+		{"dur", &DurationV{}, "10", []string{"10ns", "10us", "10ms", "10s", "10m", "10h", "10d", "10w", "10mt", "10y"}},
+		{"dur partial", &DurationV{}, "1h10", []string{"1h10ns", "1h10us", "1h10ms", "1h10s", "1h10m", "1h10h", "1h10d", "1h10w", "1h10mt", "1h10y"}},
+		{"int", &IntV{}, "10", nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tsk := CompTask{
+				ToComplete: test.toComplete,
+			}
+
+			assert.Eq(t, len(test.expected), tsk.AddFlagValues(false, test.flag, "", false))
+
+			var actual []string
+			for _, item := range tsk.result {
+				assert.Eq(t, CompKindFlagValue, item.Kind)
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+}
+
+func TestCompTask_Limit(t *testing.T) {
+	tsk := CompTask{Limit: 10}
+	for i := 0; i < 100; i++ {
+		kind := CompKindText
+		if i%10 == 0 {
+			kind = CompKindFlagName
+		}
+
+		tsk.Add(false, CompItem{Value: strconv.Itoa(i), Kind: kind})
+	}
+
+	tsk.applyLimit()
+
+	var actual []string
+	for i := 0; ; i++ {
+		item, ok := tsk.Nth(i)
+		if !ok {
+			break
+		}
+
+		actual = append(actual, item.Value)
+	}
+
+	assert.EqS(t, []string{"0", "1", "2", "3", "4", "5", "10", "20", "30", "40", ""}, actual)
+
+	item, _ := tsk.Nth(10)
+	assert.Eq(t, CompKindMessage, item.Kind)
+	assert.Eq(t, "and 90 more", item.Description)
+}
+
+func TestCompTask_Limit_Format(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		fmt      CompFmt
+		expected string
+	}{
+		{"bash", &CompFmtBash{Cols: 80, CompType: '?'}, "" +
+			"\n" +
+			"0\n" +
+			"1\n" +
+			"#and 3 more\n"},
+		{"bash not listing", &CompFmtBash{Cols: 80, CompType: '\t'}, "" +
+			"\n" +
+			"0\n" +
+			"1\n"},
+		{"zsh", CompFmtZsh{}, "" +
+			"\n" +
+			"0\n" +
+			"1\n" +
+			"::and 3 more\n"},
+		{"pwsh", &CompFmtPwsh{Mode: "MenuComplete"}, "" +
+			"\n" +
+			"0\n" +
+			"1\n"},
+		{"plain", CompFmtPlain{}, "" +
+			"0\n" +
+			"1\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tsk := CompTask{Limit: 2}
+			for i := 0; i < 5; i++ {
+				tsk.Add(false, CompItem{Value: strconv.Itoa(i)})
+			}
+
+			var sb strings.Builder
+			assert.NoError(t, writeCompletions(&sb, &tsk, test.fmt))
+			assert.Eq(t, test.expected, sb.String())
+		})
+	}
+}
+
+func TestCompTask_AddFlagValues_ReflectMap(t *testing.T) {
+	var opts struct {
+		Labels map[string]string `cli:"label,comp=env=prod,comp=env=dev,comp=tier"`
+		Switch map[string]bool   `cli:"switch,comp=debug"`
+	}
+
+	flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	label, ok := flags.FindFlag("label")
+	assert.True(t, ok)
+	sw, ok := flags.FindFlag("switch")
+	assert.True(t, ok)
+
+	for _, test := range []struct {
+		flag       Flag
+		toComplete string
+		expected   []string
+		state      CompState
+	}{
+		{label, "", []string{"env=", "tier="}, CompStateOptionNospace},
+		{label, "t", []string{"tier="}, CompStateOptionNospace},
+		{label, "x", nil, 0},
+		{label, "env=", []string{"env=prod", "env=dev"}, 0},
+		{label, "env=p", []string{"env=prod"}, 0},
+		{label, "tier=", nil, 0},
+		{sw, "debug=", []string{"debug=true", "debug=false"}, 0},
+		{sw, "debug=f", []string{"debug=false"}, 0},
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			tsk := CompTask{
+				ToComplete: test.toComplete,
+			}
+
+			assert.Eq(t, len(test.expected), tsk.AddFlagValues(false, test.flag, "", false))
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+			assert.Eq(t, test.state, tsk.State()&CompStateOptionNospace)
+		})
+	}
+}
+
+// levelVPFactory handles `value=level` with VPReflectEnum.
+type levelVPFactory struct{ DefaultReflectVPFactory }
+
+func (f levelVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType, valueType string) (VP[*reflect.Value], error) {
+	if valueType == "level" {
+		return VPReflectEnum{Choices: []string{"debug", "info", "warn", "error"}}, nil
+	}
+
+	return f.DefaultReflectVPFactory.GetVPReflectFor(fieldType, keyType, valueType)
+}
+
+func TestCompTask_AddFlagValues_ReflectChoices(t *testing.T) {
+	var opts struct {
+		Level string   `cli:"level,value=level"`
+		Color TriState `cli:"color,value=tristate"`
+		Pick  string   `cli:"pick,value=level,comp=info"`
+	}
+
+	flags := NewReflectIndexer(levelVPFactory{}, &opts)
+	level, ok := flags.FindFlag("level")
+	assert.True(t, ok)
+	color, ok := flags.FindFlag("color")
+	assert.True(t, ok)
+	pick, ok := flags.FindFlag("pick")
+	assert.True(t, ok)
+
+	for _, test := range []struct {
+		flag       Flag
+		toComplete string
+		expected   []string
+	}{
+		{level, "", []string{"debug", "info", "warn", "error"}},
+		{level, "w", []string{"warn"}},
+		{level, "x", nil},
+		{color, "", []string{"on", "off", "auto"}},
+		{pick, "", []string{"info"}}, // comp option wins
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			tsk := CompTask{
+				ToComplete: test.toComplete,
+			}
+
+			assert.Eq(t, len(test.expected), tsk.AddFlagValues(false, test.flag, "", false))
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+
+	assert.ErrorIs(t, &ErrInvalidValue{Type: "enum", Value: "trace"},
+		level.Decode(nil, "level", "trace", true))
+	assert.NoError(t, level.Decode(nil, "level", "warn", true))
+	assert.Eq(t, "warn", opts.Level)
+}
+
+func TestCompTask_AddFlagValues_ReflectBounds(t *testing.T) {
+	var opts struct {
+		Jobs    int     `cli:"jobs,min=1,max=10"`
+		Ratio   float64 `cli:"ratio,min=0.5"`
+		Retries int     `cli:"retries,min=0,max=5,comp=3"`
+	}
+
+	flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	for _, test := range []struct {
+		name       string
+		toComplete string
+		expected   []CompItem
+	}{
+		{"jobs", "", []CompItem{
+			{Value: "1", Description: "min value", Kind: CompKindFlagValue},
+			{Value: "10", Description: "max value", Kind: CompKindFlagValue},
+		}},
+		{"jobs", "1", []CompItem{
+			{Value: "1", Description: "min value", Kind: CompKindFlagValue},
+			{Value: "10", Description: "max value", Kind: CompKindFlagValue},
+		}},
+		{"jobs", "5", nil},
+		{"ratio", "", []CompItem{
+			{Value: "0.5", Description: "min value", Kind: CompKindFlagValue},
+		}},
+		{"retries", "", []CompItem{ // comp option wins
+			{Value: "3", Kind: CompKindFlagValue},
+		}},
+	} {
+		flag, ok := flags.FindFlag(test.name)
+		assert.True(t, ok)
+
+		tsk := CompTask{
+			ToComplete: test.toComplete,
+		}
+
+		assert.Eq(t, len(test.expected), tsk.AddFlagValues(false, flag, "", false))
+		assert.EqS(t, test.expected, tsk.result)
+	}
+}
+
+func TestCompTask_AddDefault_FlagMissingValue(t *testing.T) {
+	var opts struct {
+		Output string `cli:"output|o,comp=@files:*.yaml,comp=@files:*.yml"`
+		Dir    string `cli:"dir,comp=@dirs"`
+		Level  string `cli:"level,value=level"`
+	}
+
+	root := &Cmd{
+		Pattern: "tool",
+		Flags:   NewReflectIndexer(levelVPFactory{}, &opts),
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected []CompItem
+	}{
+		{[]string{"--output", ""}, []CompItem{
+			{Value: "*.yaml", Kind: CompKindFiles},
+			{Value: "*.yml", Kind: CompKindFiles},
+		}},
+		{[]string{"-o", ""}, []CompItem{
+			{Value: "*.yaml", Kind: CompKindFiles},
+			{Value: "*.yml", Kind: CompKindFiles},
+		}},
+		{[]string{"--dir", ""}, []CompItem{
+			{Kind: CompKindDirs},
+		}},
+		{[]string{"--level", ""}, []CompItem{
+			{Value: "debug", Kind: CompKindFlagValue},
+			{Value: "info", Kind: CompKindFlagValue},
+			{Value: "warn", Kind: CompKindFlagValue},
+			{Value: "error", Kind: CompKindFlagValue},
+		}},
+		{[]string{"--level", "e"}, []CompItem{
+			{Value: "error", Kind: CompKindFlagValue},
+		}},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, len(test.args), append([]string{"./tool"}, test.args...)...)
+			assert.True(t, tsk.FlagMissingValue != nil)
+
+			tsk.AddDefault()
+			assert.EqS(t, test.expected, tsk.result)
+		})
+	}
+}
+
+func TestCompTask_AddDefault_TypeHints(t *testing.T) {
+	root := &Cmd{
+		Flags: NewMapIndexer().
+			Add(&BoolV{}, "verbose").
+			Add(&TriStateFlagV{}, "color").
+			Add(&IntV{}, "count"),
+	}
+
+	for _, test := range []struct {
+		arg      string
+		expected []string
+	}{
+		{"--verbose=", []string{"true", "false"}},
+		{"--verbose=f", []string{"false"}},
+		{"--color=", []string{"on", "off", "auto"}},
+		{"--color=a", []string{"auto"}},
+		{"--count=", nil}, // no suggestion for int
+	} {
+		t.Run(test.arg, func(t *testing.T) {
+			var tsk CompTask
+			tsk.Init(root, nil, 1, "./test", test.arg)
+			tsk.AddDefault()
+
+			var actual []string
+			for _, item := range tsk.result {
+				actual = append(actual, item.Value)
+			}
+			assert.EqS(t, test.expected, actual)
+		})
+	}
+}
+
 func TestCompTask_AddFiles(t *testing.T) {
 	var tsk CompTask
 
@@ -508,6 +1365,27 @@ func TestCompTask_AddFiles(t *testing.T) {
 	}, tsk.result)
 }
 
+func TestCompTask_AddFiles_Tilde(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	var tsk CompTask
+	assert.Eq(t, 3, tsk.AddFiles(false, "~/.config/*.toml", "~", "~other/*.toml"))
+	assert.Eq(t, 2, tsk.AddDirs(false, "~/src/*", "./~/*"))
+
+	assert.EqS(t, []CompItem{
+		{Value: "/home/user/.config/*.toml", Kind: CompKindFiles},
+		{Value: "/home/user", Kind: CompKindFiles},
+		{Value: "~other/*.toml", Kind: CompKindFiles},
+		{Value: "/home/user/src/*", Kind: CompKindDirs},
+		{Value: "./~/*", Kind: CompKindDirs},
+	}, tsk.result)
+
+	var sb strings.Builder
+	assert.NoError(t, CompFmtZsh{}.Format(&sb, &tsk))
+	assert.True(t, strings.Contains(sb.String(), "/home/user/.config/*.toml"))
+	assert.False(t, strings.Contains(sb.String(), "~/.config"))
+}
+
 func TestCompTask_AddDirs(t *testing.T) {
 	var tsk CompTask
 
@@ -6,7 +6,6 @@ package cli
 import (
 	"io"
 	"strings"
-	"unicode/utf8"
 )
 
 // CompFmt defines completion result formatter.
@@ -16,11 +15,29 @@ type CompFmt interface {
 	Format(out io.Writer, finishedTask *CompTask) error
 }
 
+// CompItemFormatter is an optional interface for CompFmt to format CompItems
+// one by one, it enables streaming completion output (see
+// CompCmdOpComplete.Stream).
+type CompItemFormatter interface {
+	// FormatItem writes one CompItem added to the unfinished task.
+	FormatItem(out io.Writer, tsk *CompTask, item *CompItem) error
+}
+
+// CompOptionLineOmitter is an optional interface for CompFmt producing bare
+// values without the leading option line (e.g. `nospace,nosort`), the
+// option line is written if a CompFmt doesn't implement it.
+type CompOptionLineOmitter interface {
+	// OmitOptionLine returns true to write CompItems only.
+	OmitOptionLine() bool
+}
+
 // CompFmtBash implements [CompFmt] for bash.
 //
-// It produces two kinds of lines:
+// It produces three kinds of lines:
 //   - ' <value>' (space prefixed) where <value> contains arguments to bash function _filedir.
-//   - others (without space prefix), as bash-completion COMPREPLY element.
+//   - '#<message>' (hash prefixed) for CompKindMessage, printed above the
+//     listed completions, only written when CompType lists completions.
+//   - others (without space or hash prefix), as bash-completion COMPREPLY element.
 type CompFmtBash struct {
 	// Cols is supposed to be the $COLUMNS in bash completion.
 	Cols int
@@ -37,12 +54,16 @@ type CompFmtBash struct {
 	// Refs:
 	//  - https://www.gnu.org/savannah-checkouts/gnu/bash/manual/bash.html#index-COMP_005fTYPE
 	CompType int
+
+	// NoDescriptions omits descriptions of all CompItems, producing
+	// values only.
+	NoDescriptions bool
 }
 
 func (fmt *CompFmtBash) Format(out io.Writer, tsk *CompTask) (err error) {
 	var (
 		indent          int
-		omitDescription bool
+		omitDescription = fmt.NoDescriptions
 		wantFiles       bool
 		wantDirs        bool
 	)
@@ -66,7 +87,7 @@ func (fmt *CompFmtBash) Format(out io.Writer, tsk *CompTask) (err error) {
 				break
 			}
 
-			x := utf8.RuneCountInString(item.Value) + strings.Count(item.Value, "\x20")
+			x := displayWidth(item.Value) + strings.Count(item.Value, "\x20")
 			if item.Kind == CompKindFlagName {
 				if IsShorthand(item.Value) {
 					x += 1
@@ -95,6 +116,19 @@ func (fmt *CompFmtBash) Format(out io.Writer, tsk *CompTask) (err error) {
 			continue
 		case CompKindDirs:
 			wantDirs = true
+			continue
+		case CompKindMessage:
+			switch fmt.CompType {
+			case '?', '!', '@': // listing completions
+			default:
+				continue
+			}
+
+			_, err = writeMessage(out, "#", item.Description)
+			if err != nil {
+				return
+			}
+
 			continue
 		case CompKindFlagValue:
 			if len(item.Value) == 0 {
@@ -137,7 +171,7 @@ func (fmt *CompFmtBash) Format(out io.Writer, tsk *CompTask) (err error) {
 				}
 			}
 
-			spaces := indent - utf8.RuneCountInString(item.Value) - strings.Count(item.Value, "\x20")
+			spaces := indent - displayWidth(item.Value) - strings.Count(item.Value, "\x20")
 			descCap := fmt.Cols - indent
 			if descCap <= 0 {
 				goto newline
@@ -150,20 +184,13 @@ func (fmt *CompFmtBash) Format(out io.Writer, tsk *CompTask) (err error) {
 				}
 			}
 
-			if descLen := utf8.RuneCountInString(item.Description); descCap >= descLen {
+			if descLen := displayWidth(item.Description); descCap >= descLen {
 				_, err = writeline(out, item.Description)
 				if err != nil {
 					return
 				}
 			} else {
-				var j int
-				for j = range item.Description {
-					if j == descCap-3 {
-						break
-					}
-				}
-
-				_, err = writeline(out, item.Description[:j])
+				_, err = writeline(out, truncateWidth(item.Description, descCap-3))
 				if err != nil {
 					return
 				}
@@ -249,23 +276,45 @@ func (fmt *CompFmtBash) Format(out io.Writer, tsk *CompTask) (err error) {
 	return nil
 }
 
-func (fmt *CompFmtBash) EscapeSpaces(out io.Writer, s string, oneline bool) (int, error) {
+// EscapeSpaces escapes spaces and other shell-significant characters (`$`,
+// backtick, `"`, `\` and `#` at the start) in s with backslash, as bash
+// inserts completion values to the command-line as is.
+func (fmt *CompFmtBash) EscapeSpaces(out io.Writer, s string, oneline bool) (n int, err error) {
 	if oneline {
 		s, _, _ = strings.Cut(s, "\n")
 	}
 
-	return replaceFuncW(
-		out, s, filterBashSpaces, replaceBashSpaces,
+	if strings.HasPrefix(s, "#") { // not a comment
+		n, err = wstr(out, "\\#")
+		if err != nil {
+			return
+		}
+
+		s = s[1:]
+	}
+
+	x, err := replaceFuncW(
+		out, s, filterBashSpecialChars, replaceBashSpecialChars,
 	)
+	n += x
+	return
 }
 
-func filterBashSpaces(r rune) bool { return r == '\x20' }
-func replaceBashSpaces(out io.Writer, matched string) (n int, err error) {
+func filterBashSpecialChars(r rune) bool {
+	switch r {
+	case '\x20', '$', '`', '"', '\\':
+		return true
+	}
+
+	return false
+}
+
+func replaceBashSpecialChars(out io.Writer, matched string) (n int, err error) {
 	var x int
 	for _, r := range matched {
 		switch r {
-		case '\x20':
-			x, err = wstr(out, "\\\x20")
+		case '\x20', '$', '`', '"', '\\':
+			x, err = wstr(out, "\\"+string(r))
 		default:
 			panic("unreachable")
 		}
@@ -279,11 +328,17 @@ func replaceBashSpaces(out io.Writer, matched string) (n int, err error) {
 
 // CompFmtZsh implements [CompFmt] for zsh.
 //
-// It produces two kinds of lines:
+// It produces three kinds of lines:
 //   - `<value>:<description>` for zsh function _describe.
+//   - `::<message>` (note the double colon prefix) for zsh function
+//     _message, used for CompKindMessage.
 //   - `:<argument-spec>` (note the colon prefix) for zsh function _arguments,
 //     currently only used for filename and dirname completion.
-type CompFmtZsh struct{}
+type CompFmtZsh struct {
+	// NoDescriptions omits descriptions of all CompItems, producing
+	// values only.
+	NoDescriptions bool
+}
 
 func (fmt CompFmtZsh) Format(out io.Writer, tsk *CompTask) (err error) {
 	var (
@@ -303,6 +358,13 @@ func (fmt CompFmtZsh) Format(out io.Writer, tsk *CompTask) (err error) {
 			continue
 		case CompKindDirs:
 			wantDirs = true
+			continue
+		case CompKindMessage:
+			_, err = writeMessage(out, "::", item.Description)
+			if err != nil {
+				return
+			}
+
 			continue
 		case CompKindFlagValue:
 			if len(item.Value) == 0 {
@@ -343,7 +405,7 @@ func (fmt CompFmtZsh) Format(out io.Writer, tsk *CompTask) (err error) {
 			return
 		}
 
-		if len(item.Description) > 0 {
+		if !fmt.NoDescriptions && len(item.Description) > 0 {
 			_, err = wstr(out, ":")
 			if err != nil {
 				return
@@ -426,6 +488,29 @@ func (fmt CompFmtZsh) Format(out io.Writer, tsk *CompTask) (err error) {
 	return
 }
 
+// writeMessage writes the first line of msg with prefix as a line, it writes
+// nothing if msg is empty.
+func writeMessage(out io.Writer, prefix, msg string) (n int, err error) {
+	msg, _, _ = strings.Cut(msg, "\n")
+	if len(msg) == 0 {
+		return
+	}
+
+	n, err = wstr(out, prefix)
+	if err != nil {
+		return
+	}
+
+	x, err := wstr(out, msg+"\n")
+	n += x
+	return
+}
+
+// EscapeColons escapes colons and backslashes in s with backslash for zsh
+// function _describe.
+//
+// Other shell-significant characters (e.g. `$`) are quoted by zsh when
+// inserting the completion value, thus are not escaped.
 func (CompFmtZsh) EscapeColons(out io.Writer, s string, oneline bool) (int, error) {
 	if oneline {
 		s, _, _ = strings.Cut(s, "\n")
@@ -434,15 +519,15 @@ func (CompFmtZsh) EscapeColons(out io.Writer, s string, oneline bool) (int, erro
 	return replaceFuncW(out, s, filterZshColons, replaceZshColons)
 }
 
-func filterZshColons(r rune) bool { return r == ':' }
+func filterZshColons(r rune) bool { return r == ':' || r == '\\' }
 func replaceZshColons(out io.Writer, matched string) (n int, err error) {
 	var x int
 	for _, r := range matched {
 		switch r {
 		case ':':
 			x, err = wstr(out, "\\:")
-		// case '"':
-		// 	x, err = writeString(out,"\\\"")
+		case '\\':
+			x, err = wstr(out, "\\\\")
 		default:
 			panic("unreachable")
 		}
@@ -461,6 +546,9 @@ func replaceZshColons(out io.Writer, matched string) (n int, err error) {
 //     creating CompletionResult items.
 //   - `;<argument-spec>` (note the unescaped semi-colon prefix) for filesystem
 //     related completion.
+//
+// Items of CompKindMessage are omitted as every CompletionResult is
+// selectable.
 type CompFmtPwsh struct {
 	// Mods is the PowerShell completion mode, possible values are:
 	//
@@ -468,6 +556,10 @@ type CompFmtPwsh struct {
 	//  - Complete (works like bash)
 	//  - MenuComplete (works like zsh)
 	Mode string
+
+	// NoDescriptions omits descriptions of all CompItems, producing
+	// values only.
+	NoDescriptions bool
 }
 
 func (fmt *CompFmtPwsh) Format(out io.Writer, tsk *CompTask) (err error) {
@@ -479,7 +571,7 @@ func (fmt *CompFmtPwsh) Format(out io.Writer, tsk *CompTask) (err error) {
 		indent int
 	)
 
-	if isBash { // needs padding between value and description
+	if isBash && !fmt.NoDescriptions { // needs padding between value and description
 		// find the longest value, assume monospace font
 		for i := 0; ; i++ {
 			item, ok := tsk.Nth(i)
@@ -487,7 +579,7 @@ func (fmt *CompFmtPwsh) Format(out io.Writer, tsk *CompTask) (err error) {
 				break
 			}
 
-			x := utf8.RuneCountInString(item.Value)
+			x := displayWidth(item.Value)
 			if item.Kind == CompKindFlagName {
 				if IsShorthand(item.Value) {
 					x += 1
@@ -517,6 +609,8 @@ func (fmt *CompFmtPwsh) Format(out io.Writer, tsk *CompTask) (err error) {
 		case CompKindDirs:
 			wantDirs = true
 			continue
+		case CompKindMessage:
+			continue
 		case CompKindFlagValue:
 			if len(item.Value) == 0 {
 				continue
@@ -550,7 +644,7 @@ func (fmt *CompFmtPwsh) Format(out io.Writer, tsk *CompTask) (err error) {
 			return
 		}
 
-		if len(item.Description) != 0 {
+		if !fmt.NoDescriptions && len(item.Description) != 0 {
 			_, err = wstr(out, " ;")
 			if err != nil {
 				return
@@ -564,7 +658,7 @@ func (fmt *CompFmtPwsh) Format(out io.Writer, tsk *CompTask) (err error) {
 					}
 				}
 
-				spaces := indent - utf8.RuneCountInString(item.Value)
+				spaces := indent - displayWidth(item.Value)
 				for j := 0; j < spaces; j++ {
 					_, err = wstr(out, " ")
 					if err != nil {
@@ -698,6 +792,142 @@ func replacePwshSpecialChars(out io.Writer, matched string) (n int, err error) {
 	return
 }
 
+// CompFmtPlain implements [CompFmt] for non-shell integrations (e.g.
+// fzf-style pickers).
+//
+// It produces `<value>\t<description>` lines (or `<value>` lines for items
+// without description) without escaping nor the leading option line, flag
+// names are prefixed with dashes and flag values are prefixed with
+// CompTask.FlagValuePrefix.
+//
+// Items of CompKindFiles and CompKindDirs are omitted as there is no file
+// matching, so are items of CompKindMessage.
+type CompFmtPlain struct {
+	// NoDescriptions omits descriptions of all CompItems, producing
+	// values only.
+	NoDescriptions bool
+}
+
+// OmitOptionLine implements [CompOptionLineOmitter].
+func (CompFmtPlain) OmitOptionLine() bool { return true }
+
+func (fmt CompFmtPlain) Format(out io.Writer, tsk *CompTask) (err error) {
+	for i := 0; ; i++ {
+		item, ok := tsk.Nth(i)
+		if !ok {
+			break
+		}
+
+		err = fmt.FormatItem(out, tsk, &item)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// FormatItem implements [CompItemFormatter].
+func (fmt CompFmtPlain) FormatItem(out io.Writer, tsk *CompTask, item *CompItem) (err error) {
+	if len(item.Value) == 0 {
+		return
+	}
+
+	switch item.Kind {
+	case CompKindFiles, CompKindDirs, CompKindMessage:
+		return
+	case CompKindFlagValue:
+		_, err = writeline(out, tsk.FlagValuePrefix)
+	case CompKindFlagName:
+		if IsShorthand(item.Value) {
+			_, err = wstr(out, "-")
+		} else {
+			_, err = wstr(out, "--")
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	_, err = writeline(out, item.Value)
+	if err != nil {
+		return
+	}
+
+	if !fmt.NoDescriptions && len(item.Description) != 0 {
+		_, err = wstr(out, "\t")
+		if err != nil {
+			return
+		}
+
+		_, err = writeline(out, item.Description)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = wstr(out, "\n")
+	return
+}
+
+// CompFmtFirst implements [CompFmt] for non-interactive use (e.g.
+// `$(tool completion zsh complete --format first ...)`).
+//
+// It writes the value of the only CompItem matching CompTask.ToComplete by
+// prefix in the same way as CompFmtPlain but without description, nothing is
+// written when there are zero or multiple matches.
+type CompFmtFirst struct{}
+
+// OmitOptionLine implements [CompOptionLineOmitter].
+func (CompFmtFirst) OmitOptionLine() bool { return true }
+
+func (CompFmtFirst) Format(out io.Writer, tsk *CompTask) (err error) {
+	var (
+		matched CompItem
+		count   int
+	)
+
+	for i := 0; ; i++ {
+		item, ok := tsk.Nth(i)
+		if !ok {
+			break
+		}
+
+		if len(item.Value) == 0 {
+			continue
+		}
+
+		value := item.Value
+		switch item.Kind {
+		case CompKindFiles, CompKindDirs:
+			continue
+		case CompKindFlagName:
+			if IsShorthand(value) {
+				value = "-" + value
+			} else {
+				value = "--" + value
+			}
+		}
+
+		if !strings.HasPrefix(value, tsk.ToComplete) {
+			continue
+		}
+
+		count++
+		if count > 1 {
+			return nil
+		}
+
+		matched = item
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return CompFmtPlain{NoDescriptions: true}.FormatItem(out, tsk, &matched)
+}
+
 func writeline(out io.Writer, s string) (int, error) {
 	s, _, _ = strings.Cut(s, "\n")
 	return wstr(out, s)
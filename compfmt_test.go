@@ -250,3 +250,178 @@ func testCompFormatter(t *testing.T, fmt CompFmt, spec CompFmtTestSpec) {
 	assert.NoError(t, fmt.Format(&buf, &CompTask{result: items}))
 	assert.Eq(t, spec.fileMatch2, buf.String())
 }
+
+func TestCompFmt_ShellSpecialChars(t *testing.T) {
+	items := []CompItem{
+		{Value: "$HOME"},
+		{Value: "#tag"},
+		{Value: "a#b"},
+		{Value: "`cmd` \"q\" \\"},
+	}
+
+	for _, test := range []struct {
+		name     string
+		fmt      CompFmt
+		expected string
+	}{
+		{"bash", &CompFmtBash{CompType: '%'}, "" +
+			"\\$HOME\n" +
+			"\\#tag\n" +
+			"a#b\n" +
+			"\\`cmd\\`\\ \\\"q\\\"\\ \\\\\n"},
+		{"zsh", CompFmtZsh{}, "" +
+			"$HOME\n" +
+			"#tag\n" +
+			"a#b\n" +
+			"`cmd` \"q\" \\\\\n"},
+		{"pwsh", &CompFmtPwsh{Mode: "MenuComplete"}, "" +
+			"`$HOME\n" +
+			"`#tag\n" +
+			"a`#b\n" +
+			"``cmd``` `\"q`\"` `\\\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, test.fmt.Format(&buf, &CompTask{result: items}))
+			assert.Eq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestCompFmt_WideChars(t *testing.T) {
+	items := []CompItem{
+		{Value: "构建", Description: "build"},
+		{Value: "test", Description: "运行测试用例"},
+	}
+
+	for _, test := range []struct {
+		name     string
+		fmt      CompFmt
+		expected string
+	}{
+		{"bash", &CompFmtBash{Cols: 18, CompType: '\t'}, "" +
+			"构建    build\n" +
+			"test    运行测...\n"},
+		{"pwsh", &CompFmtPwsh{Mode: "Complete"}, "" +
+			"构建 ;    build\n" +
+			"test ;    运行测试用例\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, test.fmt.Format(&buf, &CompTask{result: items}))
+			assert.Eq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestCompFmt_NoDescriptions(t *testing.T) {
+	items := []CompItem{
+		{Value: "build", Description: "build the project"},
+		{Value: "v", Description: "verbose output", Kind: CompKindFlagName},
+	}
+
+	for _, test := range []struct {
+		name     string
+		fmt      CompFmt
+		expected string
+	}{
+		{"bash", &CompFmtBash{Cols: 80, CompType: '\t', NoDescriptions: true}, "" +
+			"build\n" +
+			"-v\n"},
+		{"zsh", CompFmtZsh{NoDescriptions: true}, "" +
+			"build\n" +
+			"-v\n"},
+		{"pwsh", &CompFmtPwsh{Mode: "Complete", NoDescriptions: true}, "" +
+			"build\n" +
+			"-v\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, test.fmt.Format(&buf, &CompTask{result: items}))
+			assert.Eq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestCompFmtPlain(t *testing.T) {
+	items := []CompItem{
+		{Value: "build", Description: "build the project"},
+		{Value: "v", Description: "verbose output", Kind: CompKindFlagName},
+		{Value: "output", Kind: CompKindFlagName},
+		{Value: "a b$c", Description: "value with spaces", Kind: CompKindFlagValue},
+		{Value: "*.go", Kind: CompKindFiles},
+		{Kind: CompKindDirs},
+	}
+
+	for _, test := range []struct {
+		name     string
+		fmt      CompFmtPlain
+		expected string
+	}{
+		{"Descriptions", CompFmtPlain{}, "" +
+			"build\tbuild the project\n" +
+			"-v\tverbose output\n" +
+			"--output\n" +
+			"--out=a b$c\tvalue with spaces\n"},
+		{"NoDescriptions", CompFmtPlain{NoDescriptions: true}, "" +
+			"build\n" +
+			"-v\n" +
+			"--output\n" +
+			"--out=a b$c\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, test.fmt.Format(&buf, &CompTask{
+				result:          items,
+				FlagValuePrefix: "--out=",
+			}))
+			assert.Eq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestCompFmtFirst(t *testing.T) {
+	items := []CompItem{
+		{Value: "build", Description: "build the project"},
+		{Value: "bundle"},
+		{Value: "test"},
+		{Value: "verbose", Kind: CompKindFlagName},
+		{Value: "version", Kind: CompKindFlagName},
+		{Value: "output", Kind: CompKindFlagName},
+		{Value: "*.go", Kind: CompKindFiles},
+	}
+
+	for _, test := range []struct {
+		toComplete string
+		expected   string
+	}{
+		{"bui", "build\n"},
+		{"t", "test\n"},
+		{"--o", "--output\n"},
+		{"b", ""},     // multiple matches
+		{"--ver", ""}, // multiple matches
+		{"x", ""},     // no match
+		{"*", ""},     // files are not matched
+	} {
+		t.Run(test.toComplete, func(t *testing.T) {
+			var buf bytes.Buffer
+			tsk := &CompTask{
+				result:     items,
+				ToComplete: test.toComplete,
+				state:      CompStateOptionNospace,
+			}
+
+			assert.NoError(t, writeCompletions(&buf, tsk, CompFmtFirst{}))
+			assert.Eq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Eq(t, 4, displayWidth("test"))
+	assert.Eq(t, 4, displayWidth("构建"))
+	assert.Eq(t, 3, displayWidth("a한"))
+	assert.Eq(t, 2, displayWidth("🚀"))
+	assert.Eq(t, "运行", truncateWidth("运行测试", 5))
+	assert.Eq(t, "abc", truncateWidth("abc", 5))
+}
@@ -4,8 +4,11 @@
 package cli
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -18,17 +21,19 @@ import (
 //   - bash {,complete}
 //   - zsh {,complete}
 //   - pwsh {,complete}
+//   - install
 //
 // To use it, the return value of (&CompCmdShells{}).Setup(...) should
 // be a direct child to your application's root command.
 type CompCmdShells struct {
 	self Cmd
 
-	bash CompCmdBash
-	zsh  CompCmdZsh
-	pwsh CompCmdPwsh
+	bash    CompCmdBash
+	zsh     CompCmdZsh
+	pwsh    CompCmdPwsh
+	install CompCmdInstall
 
-	shells [3]*Cmd
+	children [4]*Cmd
 
 	// opComp shared by all shell sub-commands
 	opComp CompCmdOpComplete
@@ -59,15 +64,16 @@ func (cc *CompCmdShells) Setup(name string, defaultTimeout time.Duration, hide b
 			Pattern:    name,
 			State:      state,
 			BriefUsage: "shell completion",
-			Children:   cc.shells[:],
+			Children:   cc.children[:],
 		},
 	}
 	opCompCmd := cc.opComp.Setup(defaultTimeout)
 
-	cc.shells = [3]*Cmd{
+	cc.children = [4]*Cmd{
 		cc.bash.Setup(opCompCmd),
 		cc.zsh.Setup(opCompCmd),
 		cc.pwsh.Setup(opCompCmd),
+		cc.install.Setup(),
 	}
 
 	return &cc.self
@@ -97,6 +103,30 @@ type CompCmdOpComplete struct {
 	// for pwsh, it should be arg index base on $cursorPosition
 	At UintV
 
+	// NoDescriptions omits descriptions in the completion result.
+	NoDescriptions BoolV
+
+	// IncludeHidden includes hidden flags and subcommands in the completion
+	// result (see CompTask.IncludeHidden).
+	IncludeHidden BoolV
+
+	// Cache caches completion results when not nil, it can be set after
+	// calling Setup.
+	Cache CompCache
+
+	// Format overrides the shell specific output format when set to
+	// `plain` (see CompFmtPlain) or `first` (see CompFmtFirst), other values
+	// are ignored.
+	Format StringV
+
+	// Stream makes CompItems written (and flushed if the stdout has a Flush
+	// method) as soon as they are added, it can be set after calling Setup.
+	//
+	// It only takes effect when the format supports it (currently only
+	// `plain`), in which case completion options (e.g. nospace) are not
+	// written, CompTask.Limit is not applied and results are not cached.
+	Stream bool
+
 	self     Cmd
 	flagRule RuleAllOf
 
@@ -124,6 +154,16 @@ func (cc *CompCmdOpComplete) Setup(defaultTimeout time.Duration) *Cmd {
 		At: UintV{
 			BriefUsage: "set arg index the cursor currently at",
 		},
+		NoDescriptions: BoolV{
+			BriefUsage: "omit descriptions in completion result",
+		},
+		IncludeHidden: BoolV{
+			BriefUsage: "include hidden flags and subcommands in completion result",
+		},
+		Format: StringV{
+			BriefUsage: "set the output format (plain, first)",
+			State_:     FlagStateHidden,
+		},
 		strBuf:   [16]string{0: "at"},
 		flagRule: RuleAllOf{Keys: cc.strBuf[:1]},
 		ctx: opCompContext{
@@ -185,11 +225,43 @@ func runOpComplete(opts *CmdOptions, route Route, posArgs, dashArgs []string) er
 			self.ctx.tsk.SetDebugOutput(file)
 			defer file.Close()
 		}
+	} else if compDebugEnabled(opts) {
+		self.ctx.tsk.SetDebugOutput(opts.PickStderr(os.Stderr))
 	}
 
+	self.ctx.tsk.IncludeHidden = self.IncludeHidden.Value
 	return route.Up().Target().Run(&self.ctx.copts, route, posArgs, dashArgs)
 }
 
+// compDebugEnabled returns true if the environment variable CompDebugEnv is
+// set to a true value.
+func compDebugEnabled(opts *CmdOptions) bool {
+	if len(CompDebugEnv) == 0 {
+		return false
+	}
+
+	var popts *ParseOptions
+	if opts != nil {
+		popts = opts.ParseOptions
+	}
+
+	value, _ := popts.lookupEnv(CompDebugEnv)
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
+
+// pickFmt returns the CompFmt selected by cc.Format, defaults to fmt.
+func (cc *CompCmdOpComplete) pickFmt(fmt CompFmt) CompFmt {
+	switch cc.Format.Value {
+	case "plain":
+		return CompFmtPlain{NoDescriptions: cc.NoDescriptions.Value}
+	case "first":
+		return CompFmtFirst{}
+	}
+
+	return fmt
+}
+
 // NthFlag implements [FlagIter].
 func (cc *CompCmdOpComplete) NthFlag(i int) (info FlagInfo, ok bool) {
 	switch i {
@@ -199,6 +271,12 @@ func (cc *CompCmdOpComplete) NthFlag(i int) (info FlagInfo, ok bool) {
 		return FlagInfo{Name: "timeout"}, true
 	case 2:
 		return FlagInfo{Name: "debug-file"}, true
+	case 3:
+		return FlagInfo{Name: "no-descriptions"}, true
+	case 4:
+		return FlagInfo{Name: "format", State: FlagStateHidden}, true
+	case 5:
+		return FlagInfo{Name: "include-hidden"}, true
 	default:
 		return
 	}
@@ -213,6 +291,12 @@ func (cc *CompCmdOpComplete) FindFlag(name string) (Flag, bool) {
 		return &cc.Timeout, true
 	case "debug-file":
 		return &cc.DebugFile, true
+	case "no-descriptions":
+		return &cc.NoDescriptions, true
+	case "format":
+		return &cc.Format, true
+	case "include-hidden":
+		return &cc.IncludeHidden, true
 	default:
 		return nil, false
 	}
@@ -293,9 +377,13 @@ func generateBashCompletion(opts *CmdOptions, route Route, posArgs, dashArgs []s
 		tsk.Debug("cols = 80 compType = 30")
 	}
 
-	fmt := CompFmtBash{Cols: int(cols), CompType: int(compType)}
+	fmt := CompFmtBash{
+		Cols:           int(cols),
+		CompType:       int(compType),
+		NoDescriptions: op.NoDescriptions.Value,
+	}
 	return generateCompletion(
-		tsk, route[0], opts, dashArgs, op.At.Value, op.Timeout.Value, noescape(&fmt),
+		tsk, route[0], opts, dashArgs, op.At.Value, op.Timeout.Value, op.Cache, op.Stream, op.pickFmt(noescape(&fmt)),
 	)
 }
 
@@ -334,7 +422,8 @@ func generateZshCompletion(opts *CmdOptions, route Route, posArgs, dashArgs []st
 	)
 
 	return generateCompletion(
-		op.Task(), route[0], opts, dashArgs, op.At.Value, op.Timeout.Value, CompFmtZsh{},
+		op.Task(), route[0], opts, dashArgs, op.At.Value, op.Timeout.Value, op.Cache, op.Stream,
+		op.pickFmt(CompFmtZsh{NoDescriptions: op.NoDescriptions.Value}),
 	)
 }
 
@@ -379,13 +468,154 @@ func generatePwshCompletion(opts *CmdOptions, route Route, posArgs, dashArgs []s
 	}
 
 	tsk.Debug("mode =", mode)
-	fmt = CompFmtPwsh{Mode: mode}
+	fmt = CompFmtPwsh{Mode: mode, NoDescriptions: op.NoDescriptions.Value}
 
 	return generateCompletion(
-		tsk, route[0], opts, dashArgs, op.At.Value, op.Timeout.Value, noescape(&fmt),
+		tsk, route[0], opts, dashArgs, op.At.Value, op.Timeout.Value, op.Cache, op.Stream, op.pickFmt(noescape(&fmt)),
 	)
 }
 
+// CompCmdInstall is the `install` command writing the completion script of
+// a shell to the conventional location for the current user:
+//
+//   - bash: $XDG_DATA_HOME/bash-completion/completions/<root>
+//     ($XDG_DATA_HOME defaults to ~/.local/share), loaded by bash-completion.
+//   - zsh: ~/.zsh/completions/_<root>, the directory should be in $fpath.
+//   - pwsh: ~/.config/powershell/completions/<root>.ps1, it should be
+//     dot-sourced in $PROFILE.
+//
+// It prints the path written and instructions to stdout.
+type CompCmdInstall struct {
+	// Shell is the target shell, defaults to the basename of $SHELL.
+	Shell StringV
+
+	// Path overrides the file to write the script.
+	Path StringV
+
+	self Cmd
+}
+
+// Setup returns the initialized *Cmd.
+func (cc *CompCmdInstall) Setup() *Cmd {
+	*cc = CompCmdInstall{
+		Shell: StringV{
+			BriefUsage: "set the shell to install completion for (bash, zsh, pwsh)",
+		},
+		Path: StringV{
+			BriefUsage: "set the file to write the completion script",
+		},
+		self: Cmd{
+			Pattern:    "install",
+			BriefUsage: "Install the completion script for current user",
+			LocalFlags: cc,
+			Run:        runInstall,
+		},
+	}
+
+	return &cc.self
+}
+
+// NthFlag implements [FlagIter].
+func (cc *CompCmdInstall) NthFlag(i int) (info FlagInfo, ok bool) {
+	switch i {
+	case 0:
+		return FlagInfo{Name: "shell"}, true
+	case 1:
+		return FlagInfo{Name: "path"}, true
+	default:
+		return
+	}
+}
+
+// FindFlag implements [FlagFinder].
+func (cc *CompCmdInstall) FindFlag(name string) (Flag, bool) {
+	switch name {
+	case "shell":
+		return &cc.Shell, true
+	case "path":
+		return &cc.Path, true
+	default:
+		return nil, false
+	}
+}
+
+func runInstall(opts *CmdOptions, route Route, posArgs, dashArgs []string) (err error) {
+	self := route.Target().LocalFlags.(*CompCmdInstall)
+
+	shell := self.Shell.Value
+	if len(shell) == 0 {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+
+	var (
+		rootCmdName = route[0].Name()
+		path        = self.Path.Value
+		home        string
+		write       func(out io.Writer, rootCmdName, completionCmdName string) (int, error)
+		hint        string
+	)
+
+	if len(path) == 0 {
+		home, err = os.UserHomeDir()
+		if err != nil {
+			return
+		}
+	}
+
+	switch shell {
+	case "bash":
+		write = WriteShellCompScriptBash
+		if len(path) == 0 {
+			dataHome := os.Getenv("XDG_DATA_HOME")
+			if len(dataHome) == 0 {
+				dataHome = filepath.Join(home, ".local", "share")
+			}
+
+			path = filepath.Join(dataHome, "bash-completion", "completions", rootCmdName)
+		}
+		hint = "restart your shell to load it (requires bash-completion)"
+	case "zsh":
+		write = WriteShellCompScriptZsh
+		if len(path) == 0 {
+			path = filepath.Join(home, ".zsh", "completions", "_"+rootCmdName)
+		}
+		hint = "make sure its directory is in $fpath before compinit, then restart your shell"
+	case "pwsh", "powershell":
+		write = WriteShellCompScriptPwsh
+		if len(path) == 0 {
+			path = filepath.Join(home, ".config", "powershell", "completions", rootCmdName+".ps1")
+		}
+		hint = "dot-source it in your $PROFILE to load it"
+	default:
+		return &ErrInvalidValue{
+			Type:  "shell",
+			Value: shell,
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+
+	_, err = write(file, rootCmdName, route[1].Name())
+	if err2 := file.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return
+	}
+
+	out := opts.PickStdout(os.Stdout)
+	_, err = wstr(out, shell+" completion script written to "+path+"\n"+hint+"\n")
+	return
+}
+
 func writeScript(
 	opts *CmdOptions,
 	route Route,
@@ -402,10 +632,25 @@ func generateCompletion(
 	dashArgs []string,
 	at uint,
 	timeout time.Duration,
+	cache CompCache,
+	stream bool,
 	fmt CompFmt,
 ) (err error) {
 	tsk.Init(root, opts, int(at), dashArgs...)
 
+	var cacheKey string
+	if cache != nil {
+		cacheKey = compCacheKey(tsk)
+		if tsk.fromCache(cache, cacheKey) {
+			tsk.Debug("cache hit")
+			goto write
+		}
+	}
+
+	if f, ok := fmt.(CompItemFormatter); ok && stream {
+		return streamCompletion(tsk, opts.PickStdout(os.Stdout), timeout, f)
+	}
+
 	if timeout > 0 {
 		done := make(chan struct{})
 		go func() {
@@ -431,6 +676,11 @@ func generateCompletion(
 		tsk.AddDefault()
 	}
 
+	if cache != nil && tsk.State()&CompStateFailed == 0 {
+		cache.PutComp(cacheKey, tsk.result, tsk.State())
+	}
+
+write:
 	err = writeCompletions(opts.PickStdout(os.Stdout), tsk, fmt)
 	if err != nil {
 		tsk.Debug("error writing completion result:", err.Error())
@@ -441,12 +691,69 @@ func generateCompletion(
 	return
 }
 
+// streamCompletion is generateCompletion writing CompItems as they are
+// added.
+func streamCompletion(tsk *CompTask, out io.Writer, timeout time.Duration, f CompItemFormatter) (err error) {
+	if o, ok := f.(CompOptionLineOmitter); !ok || !o.OmitOptionLine() {
+		// no completion option can be known in advance.
+		_, err = wstr(out, "\n")
+		if err != nil {
+			return
+		}
+	}
+
+	s := &compStream{out: out, fmt: f}
+	tsk.stream = s
+
+	if timeout > 0 {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			tsk.AddDefault()
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer func() {
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}()
+
+		select {
+		case <-timer.C:
+			_ = s.stop()
+			tsk.Debug("timeout after", timeout.String())
+			return ErrTimeout{}
+		case <-done:
+		}
+	} else {
+		tsk.AddDefault()
+	}
+
+	err = s.stop()
+	tsk.stream = nil
+	if err != nil {
+		tsk.Debug("error writing completion result:", err.Error())
+		return
+	}
+
+	tsk.Debug("done.")
+	return
+}
+
 func writeCompletions(out io.Writer, tsk *CompTask, fmt CompFmt) (err error) {
 	s := tsk.State()
 	if s&CompStateFailed != 0 {
 		return nil
 	}
 
+	tsk.applyLimit()
+
+	if o, ok := fmt.(CompOptionLineOmitter); ok && o.OmitOptionLine() {
+		return fmt.Format(out, noescape(tsk))
+	}
+
 	addComma := false
 	if s&CompStateOptionNospace != 0 {
 		_, err = wstr(out, "nospace")
@@ -484,6 +791,13 @@ func writeCompletions(out io.Writer, tsk *CompTask, fmt CompFmt) (err error) {
 	return fmt.Format(out, noescape(tsk))
 }
 
+// CompDebugEnv is the name of the environment variable enabling debug
+// messages of the `complete` command to be written to stderr when set to a
+// true value (e.g. `1`) and no `--debug-file` is given.
+//
+// Set it to empty to disable.
+var CompDebugEnv = "CLI_COMP_DEBUG"
+
 var (
 	//go:embed scripts/bash-usage.txt
 	bashCompUsage string
@@ -501,14 +815,60 @@ var (
 	pwshCompScript string
 )
 
-// WriteShellCompScriptBash writes the bash completion script to out.
-func WriteShellCompScriptBash(out io.Writer, rootCmdName, completionCmdName string) (int, error) {
-	return replaceFuncWEx(
-		out, bashCompScript, placeholders{
+// ShellCompScriptVersionPrefix prefixes the first line of completion
+// scripts written by WriteShellCompScript{Bash, Zsh, Pwsh}, followed by the
+// value of ShellCompScriptVersion.
+const ShellCompScriptVersionPrefix = "# completion script version: "
+
+// ShellCompScriptVersion returns a stable hash of the embedded completion
+// script of the shell (one of `bash`, `zsh` and `pwsh`), it changes only
+// when the script content changes.
+//
+// It returns an empty string for unknown shells.
+func ShellCompScriptVersion(shell string) string {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompScript
+	case "zsh":
+		script = zshCompScript
+	case "pwsh":
+		script = pwshCompScript
+	default:
+		return ""
+	}
+
+	return compScriptVersion(script)
+}
+
+// compScriptVersion returns the first 8 bytes of the sha256 sum of script
+// in hex.
+func compScriptVersion(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:8])
+}
+
+// writeCompScript writes the version line and the script with placeholders
+// replaced.
+func writeCompScript(out io.Writer, script, rootCmdName, completionCmdName string) (n int, err error) {
+	n, err = wstr(out, ShellCompScriptVersionPrefix+compScriptVersion(script)+"\n")
+	if err != nil {
+		return
+	}
+
+	x, err := replaceFuncWEx(
+		out, script, placeholders{
 			rootCmdName:       rootCmdName,
 			completionCmdName: completionCmdName,
 		}, placeholderFilterFunc, placeholderReplaceFunc,
 	)
+	n += x
+	return
+}
+
+// WriteShellCompScriptBash writes the bash completion script to out.
+func WriteShellCompScriptBash(out io.Writer, rootCmdName, completionCmdName string) (int, error) {
+	return writeCompScript(out, bashCompScript, rootCmdName, completionCmdName)
 }
 
 // WriteShellCompScriptBash writes the usage of bash completion script to out.
@@ -523,12 +883,7 @@ func WriteShellCompUsageBash(out io.Writer, rootCmdName, completionCmdName strin
 
 // WriteShellCompScriptBash writes the zsh completion script to out.
 func WriteShellCompScriptZsh(out io.Writer, rootCmdName, completionCmdName string) (int, error) {
-	return replaceFuncWEx(
-		out, zshCompScript, placeholders{
-			rootCmdName:       rootCmdName,
-			completionCmdName: completionCmdName,
-		}, placeholderFilterFunc, placeholderReplaceFunc,
-	)
+	return writeCompScript(out, zshCompScript, rootCmdName, completionCmdName)
 }
 
 // WriteShellCompScriptBash writes the usage of zsh completion script to out.
@@ -541,14 +896,142 @@ func WriteShellCompUsageZsh(out io.Writer, rootCmdName, completionCmdName string
 	)
 }
 
+// WriteZshArgumentsSpec writes a zsh `_arguments` call completing flags
+// of cmd (including its LocalFlags), as a static alternative to the dynamic
+// completion done by the `complete` command.
+//
+// Every name, shorthand and alias of a flag not hidden has its own spec:
+//
+//   - a flag requiring value (see ValueModeOf) takes a value (`:name: `).
+//   - all forms of the same flag exclude each other, so do forms of flags
+//     in the same RuleOneOf of cmd.FlagRule (directly or in a MultiRule).
+func WriteZshArgumentsSpec(out io.Writer, cmd *Cmd) error {
+	var (
+		route = Route{cmd}
+		keys  []string   // keys of flags
+		forms [][]string // forms of flags, e.g. [-o, --output]
+		specs []string
+	)
+
+	for i := 0; ; i++ {
+		info, ok := route.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		_, flag, ok := FindFlag(&route, info.Name, info.Shorthand)
+		if !ok || flag.State().Hidden() || sliceContains(keys, info.key()) {
+			continue
+		}
+
+		var f []string
+		if len(info.Shorthand) != 0 {
+			f = append(f, "-"+info.Shorthand)
+		}
+		if len(info.Name) != 0 {
+			f = append(f, "--"+info.Name)
+		}
+		for _, alias := range info.Aliases {
+			f = append(f, "--"+alias)
+		}
+
+		if len(f) == 0 {
+			continue
+		}
+
+		keys = append(keys, info.key())
+		forms = append(forms, f)
+	}
+
+	oneOfs := collectRuleOneOf(cmd.FlagRule, nil, nil)
+
+	var sb strings.Builder
+	for i, f := range forms {
+		_, flag, _ := FindFlag(&route, keys[i])
+
+		// exclusion group
+		excl := append([]string(nil), f...)
+		for _, oneOf := range oneOfs {
+			if !sliceContains(oneOf.Keys, keys[i]) {
+				continue
+			}
+
+			for j, key := range keys {
+				if j != i && sliceContains(oneOf.Keys, key) {
+					excl = append(excl, forms[j]...)
+				}
+			}
+		}
+
+		for _, form := range f {
+			sb.Reset()
+			sb.WriteString("(" + strings.Join(excl, " ") + ")" + form)
+			if usage := flag.Usage(); len(usage) != 0 {
+				sb.WriteString("[" + zshSpecEscape(usage, "[]:") + "]")
+			}
+
+			if ValueModeOf(flag) == FlagValueRequired {
+				sb.WriteString(":" + zshSpecEscape(keys[i], ":") + ": ")
+			}
+
+			specs = append(specs, sb.String())
+		}
+	}
+
+	buf := []byte("_arguments -s -S")
+	for _, spec := range specs {
+		buf = append(buf, " \\\n  '"...)
+		buf = append(buf, strings.ReplaceAll(spec, "'", `'\''`)...)
+		buf = append(buf, '\'')
+	}
+	buf = append(buf, '\n')
+
+	_, err := out.Write(buf)
+	return err
+}
+
+// collectRuleOneOf appends every *RuleOneOf in rule (directly or in a
+// MultiRule) to ret.
+func collectRuleOneOf(rule Rule, visiting []Rule, ret []*RuleOneOf) []*RuleOneOf {
+	switch r := rule.(type) {
+	case *RuleOneOf:
+		if r != nil {
+			ret = append(ret, r)
+		}
+	case *MultiRule:
+		if r == nil || sliceContainsRule(visiting, r) {
+			break
+		}
+
+		visiting = append(visiting, r)
+		for _, sub := range r.Rules {
+			ret = collectRuleOneOf(sub, visiting, ret)
+		}
+	}
+
+	return ret
+}
+
+// zshSpecEscape escapes chars in s with backslash.
+func zshSpecEscape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
 // WriteShellCompScriptBash writes the powershell completion script to out.
 func WriteShellCompScriptPwsh(out io.Writer, rootCmdName, completionCmdName string) (int, error) {
-	return replaceFuncWEx(
-		out, pwshCompScript, placeholders{
-			rootCmdName:       rootCmdName,
-			completionCmdName: completionCmdName,
-		}, placeholderFilterFunc, placeholderReplaceFunc,
-	)
+	return writeCompScript(out, pwshCompScript, rootCmdName, completionCmdName)
 }
 
 // WriteShellCompScriptBash writes the usage of powershell completion script to out.
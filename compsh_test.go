@@ -4,8 +4,12 @@
 package cli
 
 import (
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/primecitizens/cli/internal/assert"
 )
@@ -77,6 +81,61 @@ func TestCompCmdShells(t *testing.T) {
 			}, err)
 		})
 
+		t.Run("NoDescriptions", func(t *testing.T) {
+			var sb strings.Builder
+			err := root.Exec(
+				&CmdOptions{
+					Stdout: &sb,
+				},
+				"completion", shell, "complete", "--at", "2", "--no-descriptions",
+				"--",
+				"arg0", "",
+			)
+			assert.NoError(t, err)
+			assert.True(t, strings.Contains(sb.String(), "dirs"))
+			assert.False(t, strings.Contains(sb.String(), "complete dirs"))
+		})
+
+		t.Run("PlainFormat", func(t *testing.T) {
+			cc.opComp.NoDescriptions.Value = false // reset
+
+			var sb strings.Builder
+			err := root.Exec(
+				&CmdOptions{
+					Stdout: &sb,
+				},
+				"completion", shell, "complete", "--at", "2", "--format", "plain",
+				"--",
+				"arg0", "d",
+			)
+			assert.NoError(t, err)
+			assert.Eq(t, "dirs\tcomplete dirs\nfiles-and-dirs\nnone\tcomplete nothing\n", sb.String())
+		})
+
+		t.Run("FirstFormat", func(t *testing.T) {
+			for _, test := range []struct {
+				toComplete string
+				expected   string
+			}{
+				{"di", "dirs\n"},
+				{"", ""}, // multiple matches
+			} {
+				var sb strings.Builder
+				err := root.Exec(
+					&CmdOptions{
+						Stdout: &sb,
+					},
+					"completion", shell, "complete", "--at", "1", "--format", "first",
+					"--",
+					"arg0", test.toComplete,
+				)
+				assert.NoError(t, err)
+				assert.Eq(t, test.expected, sb.String())
+			}
+
+			cc.opComp.Format.Value = "" // reset
+		})
+
 		t.Run("GoodRequest", func(t *testing.T) {
 			var sb strings.Builder
 			err := root.Exec(
@@ -92,11 +151,55 @@ func TestCompCmdShells(t *testing.T) {
 	}
 }
 
+func TestCompCmdInstall(t *testing.T) {
+	var cc CompCmdShells
+	root := &Cmd{
+		Pattern:  "foo",
+		Children: []*Cmd{cc.Setup("", -1, true)},
+	}
+
+	dir := t.TempDir()
+	for _, test := range []struct {
+		shell string
+		write func(out io.Writer, rootCmdName, completionCmdName string) (int, error)
+	}{
+		{"bash", WriteShellCompScriptBash},
+		{"zsh", WriteShellCompScriptZsh},
+		{"pwsh", WriteShellCompScriptPwsh},
+	} {
+		t.Run(test.shell, func(t *testing.T) {
+			path := filepath.Join(dir, test.shell, "foo")
+
+			var sb strings.Builder
+			err := root.Exec(&CmdOptions{Stdout: &sb},
+				"completion", "install", "--shell", test.shell, "--path", path,
+			)
+			assert.NoError(t, err)
+			assert.True(t, strings.Contains(sb.String(), path))
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+
+			var expected strings.Builder
+			_, err = test.write(&expected, "foo", "completion")
+			assert.NoError(t, err)
+			assert.Eq(t, expected.String(), string(data))
+		})
+	}
+
+	t.Run("UnknownShell", func(t *testing.T) {
+		err := root.Exec(&CmdOptions{Stdout: io.Discard},
+			"completion", "install", "--shell", "fish", "--path", filepath.Join(dir, "fish"),
+		)
+		assert.ErrorIs(t, &ErrInvalidValue{Type: "shell", Value: "fish"}, err)
+	})
+}
+
 func TestCompCmdOpComplete(t *testing.T) {
 	var cmd CompCmdOpComplete
 	cmd.Setup(0)
 
-	flags := []string{"debug-file", "at", "timeout"}
+	flags := []string{"debug-file", "at", "timeout", "no-descriptions", "format", "include-hidden"}
 	i := 0
 	for ; i < len(flags); i++ {
 		_, ok := cmd.NthFlag(i)
@@ -112,3 +215,293 @@ func TestCompCmdOpComplete(t *testing.T) {
 	f, ok := cmd.FindFlag("non-existing")
 	assertNoflagFalse(t, f, ok)
 }
+
+func TestGenerateCompletion_Cache(t *testing.T) {
+	var (
+		calls int
+		now   = time.Unix(0, 0)
+	)
+
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{{
+			Pattern: "deploy",
+			Completion: CompActionFunc(func(tsk *CompTask) (int, CompState) {
+				calls++
+				return tsk.AddMatched(false, CompItem{Value: "api"}, CompItem{Value: "web"}), 0
+			}),
+		}},
+	}
+
+	cache := &CompCacheMem{
+		TTL: time.Minute,
+		Now: func() time.Time { return now },
+	}
+
+	complete := func(toComplete string) string {
+		var (
+			sb  strings.Builder
+			tsk CompTask
+		)
+
+		err := generateCompletion(
+			&tsk, root, &CmdOptions{Stdout: &sb},
+			[]string{"./tool", "deploy", toComplete}, 2, 0, cache, false, CompFmtZsh{},
+		)
+		assert.NoError(t, err)
+		return sb.String()
+	}
+
+	expected := complete("")
+	assert.Eq(t, 1, calls)
+	assert.Eq(t, "\napi\nweb\n", expected)
+
+	now = now.Add(30 * time.Second)
+	assert.Eq(t, expected, complete(""))
+	assert.Eq(t, 1, calls)
+
+	assert.Eq(t, "\nweb\n", complete("w"))
+	assert.Eq(t, 2, calls)
+
+	now = now.Add(time.Minute)
+	assert.Eq(t, expected, complete(""))
+	assert.Eq(t, 3, calls)
+}
+
+func TestGenerateCompletion_CachePositions(t *testing.T) {
+	var calls int
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{{
+			Pattern: "copy",
+			Completion: CompActionFunc(func(tsk *CompTask) (int, CompState) {
+				calls++
+				if len(tsk.PosArgs) == 0 {
+					return tsk.AddMatched(false, CompItem{Value: "src"}), 0
+				}
+
+				return tsk.AddMatched(false, CompItem{Value: "dst-of-" + tsk.PosArgs[0]}), 0
+			}),
+		}},
+	}
+
+	cache := &CompCacheMem{}
+	complete := func(args ...string) string {
+		var (
+			sb  strings.Builder
+			tsk CompTask
+		)
+
+		args = append([]string{"./tool", "copy"}, args...)
+		err := generateCompletion(
+			&tsk, root, &CmdOptions{Stdout: &sb},
+			args, uint(len(args)-1), 0, cache, false, CompFmtZsh{},
+		)
+		assert.NoError(t, err)
+		return sb.String()
+	}
+
+	assert.Eq(t, "\nsrc\n", complete(""))
+	assert.Eq(t, "\ndst-of-a\n", complete("a", ""))
+	assert.Eq(t, "\ndst-of-b\n", complete("b", ""))
+	assert.Eq(t, 3, calls)
+
+	assert.Eq(t, "\ndst-of-a\n", complete("a", ""))
+	assert.Eq(t, 3, calls)
+}
+
+type flushRecorder struct {
+	strings.Builder
+	flushed []string
+}
+
+func (r *flushRecorder) Flush() { r.flushed = append(r.flushed, r.String()) }
+
+func TestGenerateCompletion_Stream(t *testing.T) {
+	var (
+		out      flushRecorder
+		streamed []string
+	)
+
+	root := &Cmd{
+		Pattern: "tool",
+		Children: []*Cmd{{
+			Pattern: "deploy",
+			Completion: CompActionFunc(func(tsk *CompTask) (added int, _ CompState) {
+				for _, v := range []string{"api", "web"} {
+					added += tsk.AddMatched(false, CompItem{Value: v, Description: "deploy " + v})
+					streamed = append(streamed, out.String())
+				}
+				return
+			}),
+		}},
+	}
+
+	for _, fmt := range []CompFmt{CompFmtPlain{}, CompFmtZsh{}} {
+		out, streamed = flushRecorder{}, nil
+		err := generateCompletion(
+			&CompTask{}, root, &CmdOptions{Stdout: &out},
+			[]string{"./tool", "deploy", ""}, 2, 0, nil, true, fmt,
+		)
+		assert.NoError(t, err)
+
+		switch fmt.(type) {
+		case CompFmtPlain:
+			assert.EqS(t, []string{
+				"api\tdeploy api\n",
+				"api\tdeploy api\nweb\tdeploy web\n",
+			}, streamed)
+			assert.EqS(t, streamed, out.flushed)
+		default: // not supporting streaming
+			assert.EqS(t, []string{"", ""}, streamed)
+			assert.Eq(t, 0, len(out.flushed))
+			assert.Eq(t, "\napi:deploy api\nweb:deploy web\n", out.String())
+		}
+	}
+}
+
+func TestGenerateCompletion_MultiCall(t *testing.T) {
+	ls := &Cmd{
+		Pattern: "ls",
+		Flags:   NewMapIndexer().Add(&BoolV{}, "long"),
+	}
+	root := &Cmd{
+		Pattern:   "box",
+		Children:  []*Cmd{ls, {Pattern: "cat"}},
+		MultiCall: map[string]*Cmd{"ls": ls},
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"./box", ""}, "ls\ncat\n"},
+		{[]string{"/bin/ls", "--"}, "--long\n"},
+	} {
+		var sb strings.Builder
+		err := generateCompletion(
+			&CompTask{}, root, &CmdOptions{Stdout: &sb},
+			test.args, uint(len(test.args)-1), 0, nil, false, CompFmtPlain{},
+		)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, sb.String())
+	}
+}
+
+func TestCompCmdOpComplete_IncludeHidden(t *testing.T) {
+	complete := func(toComplete string, extra ...string) string {
+		var cc CompCmdShells
+		root := &Cmd{
+			Pattern: "foo",
+			Flags: NewMapIndexer().
+				Add(&BoolV{}, "verbose").
+				Add(&BoolV{State_: FlagStateHidden}, "trace"),
+			Children: []*Cmd{
+				cc.Setup("", -1, true),
+				{Pattern: "debug", State: CmdStateHidden},
+			},
+		}
+
+		var sb strings.Builder
+		args := append([]string{"completion", "zsh", "complete", "--at", "1", "--format", "plain"}, extra...)
+		err := root.Exec(&CmdOptions{Stdout: &sb}, append(args, "--", "foo", toComplete)...)
+		assert.NoError(t, err)
+		return sb.String()
+	}
+
+	assert.Eq(t, "--verbose\n", complete("--"))
+	assert.False(t, strings.Contains(complete(""), "debug"))
+
+	assert.Eq(t, "--verbose\n--trace\n", complete("--", "--include-hidden"))
+	assert.True(t, strings.Contains(complete("", "--include-hidden"), "\ndebug\n"))
+}
+
+func TestWriteZshArgumentsSpec(t *testing.T) {
+	cmd := &Cmd{
+		Pattern: "tool",
+		Flags: NewMapIndexer().
+			Add(&BoolV{BriefUsage: "print more"}, "verbose", "v").
+			Add(&StringV{BriefUsage: "write to [file]"}, "output", "o").
+			Add(&BoolV{}, "json").
+			Add(&BoolV{}, "yaml").
+			Add(&BoolV{State_: FlagStateHidden}, "trace"),
+		LocalFlags: NewMapIndexer().
+			Add(&StringV{BriefUsage: "user's name"}, "name"),
+		FlagRule: MergeFlagRules(AnyOf("verbose"), OneOf("json", "yaml")),
+	}
+
+	var sb strings.Builder
+	assert.NoError(t, WriteZshArgumentsSpec(&sb, cmd))
+	assert.Eq(t, `_arguments -s -S \
+  '(--name)--name[user'\''s name]:name: ' \
+  '(-v --verbose)-v[print more]' \
+  '(-v --verbose)--verbose[print more]' \
+  '(-o --output)-o[write to \[file\]]:output: ' \
+  '(-o --output)--output[write to \[file\]]:output: ' \
+  '(--json --yaml)--json' \
+  '(--yaml --json)--yaml'
+`, sb.String())
+}
+
+func TestCompCmdOpComplete_DebugEnv(t *testing.T) {
+	complete := func(env string) string {
+		var cc CompCmdShells
+		root := &Cmd{
+			Pattern:  "foo",
+			Children: []*Cmd{cc.Setup("", -1, true)},
+		}
+
+		var stderr strings.Builder
+		err := root.Exec(
+			&CmdOptions{
+				Stdout: io.Discard,
+				Stderr: &stderr,
+				ParseOptions: &ParseOptions{
+					LookupEnv: func(key string) (string, bool) {
+						if key == CompDebugEnv && len(env) != 0 {
+							return env, true
+						}
+						return "", false
+					},
+				},
+			},
+			"completion", "bash", "complete", "--at", "1", "--", "foo", "",
+		)
+		assert.NoError(t, err)
+		return stderr.String()
+	}
+
+	assert.Eq(t, "", complete(""))
+	assert.Eq(t, "", complete("0"))
+	assert.True(t, strings.HasPrefix(complete("1"), "[app] cols = 80 compType = 30\n"))
+
+	defer func(name string) { CompDebugEnv = name }(CompDebugEnv)
+	CompDebugEnv = ""
+	assert.Eq(t, "", complete("1"))
+}
+
+func TestShellCompScriptVersion(t *testing.T) {
+	for _, test := range []struct {
+		shell string
+		write func(out io.Writer, rootCmdName, completionCmdName string) (int, error)
+	}{
+		{"bash", WriteShellCompScriptBash},
+		{"zsh", WriteShellCompScriptZsh},
+		{"pwsh", WriteShellCompScriptPwsh},
+	} {
+		version := ShellCompScriptVersion(test.shell)
+		assert.Eq(t, 16, len(version))
+		assert.Eq(t, version, ShellCompScriptVersion(test.shell))
+
+		var sb strings.Builder
+		n, err := test.write(&sb, "foo", "completion")
+		assert.NoError(t, err)
+		assert.Eq(t, sb.Len(), n)
+
+		line, _, _ := strings.Cut(sb.String(), "\n")
+		assert.Eq(t, ShellCompScriptVersionPrefix+version, line)
+	}
+
+	assert.True(t, ShellCompScriptVersion("bash") != ShellCompScriptVersion("zsh"))
+	assert.Eq(t, "", ShellCompScriptVersion("fish"))
+}
@@ -4,7 +4,9 @@
 package cli
 
 import (
+	"io"
 	"strconv"
+	"strings"
 )
 
 // A FlagViolation represents a rule violation caused by flag.
@@ -16,24 +18,24 @@ func (err *FlagViolation) Error() string {
 		prefix = "-"
 	}
 
-	reason := ""
-	switch err.Reason {
+	return "flag rule violation found on `" + prefix + err.Key + "`: " + violationReason(err.Reason)
+}
+
+func violationReason(code ViolationCode) string {
+	switch code {
 	case ViolationCodeNoViolation:
-		reason = "no violation"
+		return "no violation"
 	case ViolationCodeEmptyAllOf:
-		reason = "all flags in the group are required, but none set"
+		return "all flags in the group are required, but none set"
 	case ViolationCodePartialAllOf, ViolationCodePartialAllOrNone:
-		reason = "not set along with other flags in the same group"
+		return "not set along with other flags in the same group"
 	case ViolationCodeExcessiveOneOf:
-		reason = "conflict with other flags in the same group"
+		return "conflict with other flags in the same group"
 	case ViolationCodeEmptyOneOf, ViolationCodeEmptyAnyOf:
-		reason = "at least one flag in the group must be set"
-
+		return "at least one flag in the group must be set"
 	default:
-		reason = "unknown (internal error)"
+		return "unknown (internal error)"
 	}
-
-	return "flag rule violation found on `" + prefix + err.Key + "`: " + reason
 }
 
 // ErrFlagSetAtMostOnce for flags marked once but appeared more than once.
@@ -126,6 +128,20 @@ func (err *ErrFlagUndefined) Error() string {
 		" (index: " + strconv.FormatInt(int64(err.At), 10) + ")"
 }
 
+// ErrMalformedFlag for a flag arg without flag name before `=` (e.g.
+// `-=value`, `--=value`).
+type ErrMalformedFlag struct {
+	// Arg is the malformed arg.
+	Arg string
+	// At is the index of the Arg.
+	At int
+}
+
+func (err *ErrMalformedFlag) Error() string {
+	return "malformed flag " + err.Arg + ": missing flag name" +
+		" (index: " + strconv.FormatInt(int64(err.At), 10) + ")"
+}
+
 // ErrFlagValueMissing
 type ErrFlagValueMissing struct {
 	// Name is a single flag name without standard hyphen prefix.
@@ -146,6 +162,22 @@ func (err *ErrFlagValueMissing) Error() string {
 		" (index: " + strconv.FormatInt(int64(err.At), 10) + ")"
 }
 
+// ErrFlagValueRequiresEq is returned when a long flag is given a separate
+// value arg with ParseOptions.RequireEqForLongValues set.
+type ErrFlagValueRequiresEq struct {
+	// Name is a single flag name without standard hyphen prefix.
+	Name string
+
+	// At is the arg index into the full arg list.
+	At int
+}
+
+// Error implements error.
+func (err *ErrFlagValueRequiresEq) Error() string {
+	return "value for flag --" + err.Name + " must be attached with `=`" +
+		" (index: " + strconv.FormatInt(int64(err.At), 10) + ")"
+}
+
 // ErrFlagValueInvalid
 type ErrFlagValueInvalid struct {
 	// Name of the flag having invalid value.
@@ -195,6 +227,100 @@ func (err *ErrCmdNotRunnable) Error() string {
 	return "command " + err.Name + " is not runnable (not having function Run)"
 }
 
+// ErrUnexpectedPosArgs for positional args passed to a Cmd with NoPosArgs.
+type ErrUnexpectedPosArgs struct {
+	Args []string
+}
+
+func (err *ErrUnexpectedPosArgs) Error() string {
+	return "unexpected positional args: " + strings.Join(err.Args, " ")
+}
+
+// ErrCommandCycle for a Cmd found among its own descendants (through
+// Cmd.Children).
+type ErrCommandCycle struct {
+	// Name of the Cmd causing the cycle.
+	Name string
+}
+
+func (err *ErrCommandCycle) Error() string {
+	return "command " + err.Name + " is a child of itself"
+}
+
+// ErrContradictoryRule for a branch of RuleDepends can never be satisfied
+// when it is used.
+type ErrContradictoryRule struct {
+	// Branch is the contradictory branch, either "then" or "else".
+	Branch string
+	// If is the condition contradicted by the Branch.
+	If Rule
+	// Keys are the keys required by the Branch causing the contradiction.
+	Keys []string
+}
+
+func (err *ErrContradictoryRule) Error() string {
+	var sb strings.Builder
+	sb.WriteString("contradictory rule: `" + err.Branch + "` requires ")
+	_, _ = formatFlagRuleTags(&sb, "", "", err.Keys)
+	sb.WriteString(" but `if` is ")
+	_, _ = err.If.WriteFlagRule(&sb)
+	return sb.String()
+}
+
+// ErrUnterminatedQuote for a quote without its closing quote when
+// splitting a line into args.
+type ErrUnterminatedQuote struct {
+	// Quote is the quote character.
+	Quote byte
+	// At is the byte offset of the quote in the line.
+	At int
+}
+
+func (err *ErrUnterminatedQuote) Error() string {
+	return "unterminated quote " + string(err.Quote) +
+		" (offset: " + strconv.FormatInt(int64(err.At), 10) + ")"
+}
+
+// ErrValueOutOfRange for a numeric flag value outside of its bounds.
+type ErrValueOutOfRange struct {
+	// Name of the flag.
+	Name string
+	// Min is the lower bound, empty when there is no lower bound.
+	Min string
+	// Max is the upper bound, empty when there is no upper bound.
+	Max string
+	// Got is the value out of range.
+	Got string
+}
+
+func (err *ErrValueOutOfRange) Error() string {
+	prefix := "--"
+	if IsShorthand(err.Name) {
+		prefix = "-"
+	}
+
+	switch {
+	case len(err.Max) == 0:
+		return "value " + err.Got + " of flag " + prefix + err.Name + " is less than " + err.Min
+	case len(err.Min) == 0:
+		return "value " + err.Got + " of flag " + prefix + err.Name + " is greater than " + err.Max
+	default:
+		return "value " + err.Got + " of flag " + prefix + err.Name +
+			" is out of range [" + err.Min + ", " + err.Max + "]"
+	}
+}
+
+// ErrDuplicateMapKey for a map flag value with a key already set when the
+// map is using MapDupError.
+type ErrDuplicateMapKey struct {
+	// Key is the duplicate key.
+	Key string
+}
+
+func (err *ErrDuplicateMapKey) Error() string {
+	return "duplicate map key " + err.Key
+}
+
 // ErrHelpPending for help but no help handle func could be found.
 type ErrHelpPending struct {
 	// HelpArg is the arg value that requested the help handling.
@@ -213,6 +339,12 @@ type ErrHelpHandled struct{}
 
 func (ErrHelpHandled) Error() string { return "help request handled" }
 
+// ErrVersionHandled is used to notify caller the version request has been
+// handled.
+type ErrVersionHandled struct{}
+
+func (ErrVersionHandled) Error() string { return "version request handled" }
+
 // ErrTimeout
 type ErrTimeout struct{}
 
@@ -242,3 +374,45 @@ func (v *ErrInvalidValue) Error() string {
 
 	return v.Value + " is not a valid " + v.Type + " value"
 }
+
+// DefaultFormatError writes err as a user-friendly message to out, it can be
+// used as CmdOptions.FormatError.
+//
+// Errors not known to this package are written as is.
+func DefaultFormatError(err error, out io.Writer) {
+	var msg string
+	switch e := err.(type) {
+	case *ErrFlagUndefined:
+		msg = "unknown flag " + flagWithPrefix(e.Name)
+	case *ErrFlagValueMissing:
+		msg = "flag " + flagWithPrefix(e.Name) + " requires a value"
+	case *ErrFlagValueRequiresEq:
+		msg = "flag " + flagWithPrefix(e.Name) + " requires its value attached, use " +
+			flagWithPrefix(e.Name) + "=<value>"
+	case *ErrFlagValueInvalid:
+		msg = "invalid value " + strconv.Quote(e.Value) + " for flag " + flagWithPrefix(e.Name)
+		if e.Reason != nil {
+			msg += ": " + e.Reason.Error()
+		}
+	case *ErrAmbiguousArgs:
+		msg = "ambiguous value " + strconv.Quote(e.Value) + " for flag " + flagWithPrefix(e.Name) +
+			", use " + flagWithPrefix(e.Name) + "=" + e.Value + " instead"
+	case *FlagViolation:
+		msg = "flag " + flagWithPrefix(e.Key) + ": " + violationReason(e.Reason)
+	case *ErrCmdNotRunnable:
+		msg = "command " + e.Name + " requires a subcommand"
+	default:
+		msg = err.Error()
+	}
+
+	_, _ = wstr(out, "error: "+msg+"\n")
+}
+
+// flagWithPrefix returns name with `-` prefix for shorthand, `--` otherwise.
+func flagWithPrefix(name string) string {
+	if IsShorthand(name) {
+		return "-" + name
+	}
+
+	return "--" + name
+}
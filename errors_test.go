@@ -4,6 +4,8 @@
 package cli
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/primecitizens/cli/internal/assert"
@@ -28,17 +30,67 @@ func TestErrors(t *testing.T) {
 			"undefined flag --foo (index: 0)"},
 		{&ErrFlagUndefined{Name: "f", At: -1},
 			"undefined flag -f"},
+		{&ErrMalformedFlag{Arg: "--=x", At: 1},
+			"malformed flag --=x: missing flag name (index: 1)"},
 		{&ErrFlagValueMissing{Name: "foo", At: 1},
 			"missing value for flag --foo (index: 1)"},
 		{&ErrFlagValueMissing{Name: "f", At: 1},
 			"missing value for flag -f (index: 1)"},
+		{&ErrFlagValueRequiresEq{Name: "out", At: 1},
+			"value for flag --out must be attached with `=` (index: 1)"},
 		{&ErrCmdNotRunnable{Name: "foo"},
 			"command foo is not runnable (not having function Run)"},
+		{&ErrUnexpectedPosArgs{Args: []string{"a", "b"}},
+			"unexpected positional args: a b"},
+		{&ErrCommandCycle{Name: "foo"},
+			"command foo is a child of itself"},
+		{&ErrContradictoryRule{Branch: "then", If: OneOf("a", "b"), Keys: []string{"a", "b"}},
+			"contradictory rule: `then` requires -a, -b but `if` is oneof[-a, -b]"},
 		{&ErrHelpPending{HelpArg: "foo", At: 1},
 			"help requested by arg `foo` (index: 1) but not handled"},
 		{&ErrHelpHandled{},
 			"help request handled"},
+		{&ErrVersionHandled{},
+			"version request handled"},
+		{&ErrUnterminatedQuote{Quote: '"', At: 4},
+			"unterminated quote \" (offset: 4)"},
+		{&ErrDuplicateMapKey{Key: "a"},
+			"duplicate map key a"},
+		{&ErrValueOutOfRange{Name: "port", Min: "1", Max: "65535", Got: "0"},
+			"value 0 of flag --port is out of range [1, 65535]"},
+		{&ErrValueOutOfRange{Name: "n", Min: "1", Got: "0"},
+			"value 0 of flag -n is less than 1"},
+		{&ErrValueOutOfRange{Name: "n", Max: "9", Got: "10"},
+			"value 10 of flag -n is greater than 9"},
 	} {
 		assert.Eq(t, test.msg, test.err.Error())
 	}
 }
+
+func TestDefaultFormatError(t *testing.T) {
+	for _, test := range []struct {
+		err error
+		msg string
+	}{
+		{&ErrFlagUndefined{Name: "foo", At: 1},
+			"error: unknown flag --foo\n"},
+		{&ErrFlagValueMissing{Name: "f", At: 1},
+			"error: flag -f requires a value\n"},
+		{&ErrFlagValueInvalid{Name: "size", Value: "1x", Reason: &ErrInvalidValue{Type: "size", Value: "1x"}},
+			"error: invalid value \"1x\" for flag --size: 1x is not a valid size value\n"},
+		{&ErrFlagValueRequiresEq{Name: "out", At: 1},
+			"error: flag --out requires its value attached, use --out=<value>\n"},
+		{&ErrAmbiguousArgs{Name: "foo", Value: "-1"},
+			"error: ambiguous value \"-1\" for flag --foo, use --foo=-1 instead\n"},
+		{&FlagViolation{Key: "foo", Reason: ViolationCodeExcessiveOneOf},
+			"error: flag --foo: conflict with other flags in the same group\n"},
+		{&ErrCmdNotRunnable{Name: "foo"},
+			"error: command foo requires a subcommand\n"},
+		{errors.New("other"),
+			"error: other\n"},
+	} {
+		var buf strings.Builder
+		DefaultFormatError(test.err, &buf)
+		assert.Eq(t, test.msg, buf.String())
+	}
+}
@@ -5,6 +5,7 @@ package cli
 
 import (
 	"io"
+	"reflect"
 	"unicode/utf8"
 )
 
@@ -23,11 +24,19 @@ const (
 	// FlagStateSetAtMostOnce marks the flag should only enjoy successful
 	// decoding with set=true at most once.
 	FlagStateSetAtMostOnce
+
+	// FlagStateSecret marks the flag value secret, its default value is
+	// shown as `<redacted>` in help and never suggested in completion.
+	FlagStateSecret
 )
 
 func (m FlagState) ValueChanged() bool  { return m&FlagStateValueChanged != 0 }
 func (m FlagState) Hidden() bool        { return m&FlagStateHidden != 0 }
 func (m FlagState) SetAtMostOnce() bool { return m&FlagStateSetAtMostOnce != 0 }
+func (m FlagState) Secret() bool        { return m&FlagStateSecret != 0 }
+
+// redacted is written in place of secret values.
+const redacted = "<redacted>"
 
 // IsShorthand returns true if s is a single rune string and is not a hyphen.
 func IsShorthand(s string) bool {
@@ -43,6 +52,40 @@ func IsShorthand(s string) bool {
 	return len(s) == sz
 }
 
+// FlagValueMode tells whether a flag requires a value on command-line.
+type FlagValueMode uint8
+
+const (
+	// FlagValueRequired means the flag requires a value (e.g. `--out file`).
+	FlagValueRequired FlagValueMode = iota
+
+	// FlagValueOptional means the value is implied when absent (e.g.
+	// `--verbose` of a bool flag, `-vvv` of a sum flag), an explicit value
+	// has to be attached (e.g. `--verbose=false`).
+	FlagValueOptional
+)
+
+// String returns one of "required" and "optional".
+func (m FlagValueMode) String() string {
+	if m == FlagValueOptional {
+		return "optional"
+	}
+
+	return "required"
+}
+
+// ValueModeOf returns the FlagValueMode of f according to f.ImplyValue.
+//
+// For flags in this package, it is derived from the VPType: bool, tristate,
+// bool slice and sum flags have optional values, others require values.
+func ValueModeOf(f Flag) FlagValueMode {
+	if _, ok := f.ImplyValue(); ok {
+		return FlagValueOptional
+	}
+
+	return FlagValueRequired
+}
+
 // AnyMaybeCompActionAndHelperTerminal is an alias of `any` and indicates
 // some component may try to cast the value as a CompAction, HelperTerminal.
 type AnyMaybeCompActionAndHelperTerminal = any
@@ -50,7 +93,7 @@ type AnyMaybeCompActionAndHelperTerminal = any
 // Flag defines the interface of an entry used by FlagIndexer and ParseFlag.
 //
 // NOTE: In this package, a Flag's default value is provided by the FlagInfo
-// and will only be assign to Flags inside Cmd.Exec.
+// (or a LazyDefault) and will only be assign to Flags inside Cmd.Exec.
 type Flag interface {
 	// Type returns (typename, true) if there is type information for this
 	// flag.
@@ -84,6 +127,33 @@ type Flag interface {
 	Usage() string
 }
 
+// A LazyDefault provides the default value of a flag at runtime, it is
+// consulted by AssignFlagsDefaultValue when there is no static default value.
+//
+// It is found by casting the Flag or Flag.Extra() as a LazyDefault.
+type LazyDefault interface {
+	// LazyDefault returns the default value, return false to indicate there
+	// is no default value.
+	LazyDefault(opts *ParseOptions) (string, bool)
+}
+
+// DefaultFunc wraps a function as LazyDefault implementation.
+type DefaultFunc func(opts *ParseOptions) (string, bool)
+
+// LazyDefault implements [LazyDefault].
+func (fn DefaultFunc) LazyDefault(opts *ParseOptions) (string, bool) {
+	return fn(opts)
+}
+
+// A FlagResetter can reset its value to the value before it was first set
+// (e.g. a value pre-set by the application) and clear FlagStateValueChanged,
+// so that the flag can be parsed again as if it was never set.
+//
+// See ResetFlags.
+type FlagResetter interface {
+	ResetFlag()
+}
+
 // FlagBase holds a pointer to the actual value.
 type FlagBase[T any, P VP[*T]] struct {
 	// BriefUsage is the help text for terminal user.
@@ -99,12 +169,26 @@ type FlagBase[T any, P VP[*T]] struct {
 
 	// State_ of the flag.
 	State_ FlagState
+
+	// Source_ of the current value.
+	Source_ ValueSource
+
+	// initial is the value before the flag was first set, restored by
+	// ResetFlag when snapshotted is true.
+	initial     T
+	snapshotted bool
 }
 
 func (f *FlagBase[T, P]) State() FlagState { return f.State_ }
 func (f *FlagBase[T, P]) Usage() string    { return f.BriefUsage }
 func (f *FlagBase[T, P]) Extra() any       { return f.Ext }
 
+// ValueSource implements [ValueSourcer].
+func (f *FlagBase[T, P]) ValueSource() ValueSource { return f.Source_ }
+
+// SetValueSource implements [ValueSourcer].
+func (f *FlagBase[T, P]) SetValueSource(src ValueSource) { f.Source_ = src }
+
 func (f *FlagBase[T, P]) Type() (string, bool) {
 	t := f.VP.Type().String()
 	return t, len(t) != 0
@@ -127,6 +211,10 @@ func (f *FlagBase[T, P]) Decode(opts *ParseOptions, name, arg string, set bool)
 		return ErrFlagSetAtMostOnce{}
 	}
 
+	if set && !f.snapshotted && f.Value != nil {
+		f.initial, f.snapshotted = snapshotValue(*f.Value), true
+	}
+
 	err := f.VP.ParseValue(opts, arg, f.Value, set)
 	if err != nil {
 		return err
@@ -134,11 +222,22 @@ func (f *FlagBase[T, P]) Decode(opts *ParseOptions, name, arg string, set bool)
 
 	if set {
 		f.State_ |= FlagStateValueChanged
+		f.Source_ = ValueSourceArg
 	}
 
 	return nil
 }
 
+// ResetFlag implements [FlagResetter].
+func (f *FlagBase[T, P]) ResetFlag() {
+	if f.Value != nil && f.snapshotted {
+		*f.Value = snapshotValue(f.initial)
+	}
+
+	f.State_ &^= FlagStateValueChanged
+	f.Source_ = ValueSourceUnset
+}
+
 // FlagBaseV is FlagBase but with value embedded.
 type FlagBaseV[T any, P VP[*T]] struct {
 	// BriefUsage is the help text for terminal user.
@@ -150,16 +249,30 @@ type FlagBaseV[T any, P VP[*T]] struct {
 	// State_ of the flag.
 	State_ FlagState
 
+	// Source_ of the current value.
+	Source_ ValueSource
+
 	// Value of the flag.
 	Value T
 
 	VP P
+
+	// initial is the value before the flag was first set, restored by
+	// ResetFlag when snapshotted is true.
+	initial     T
+	snapshotted bool
 }
 
 func (f *FlagBaseV[T, P]) State() FlagState { return f.State_ }
 func (f *FlagBaseV[T, P]) Usage() string    { return f.BriefUsage }
 func (f *FlagBaseV[T, P]) Extra() any       { return f.Ext }
 
+// ValueSource implements [ValueSourcer].
+func (f *FlagBaseV[T, P]) ValueSource() ValueSource { return f.Source_ }
+
+// SetValueSource implements [ValueSourcer].
+func (f *FlagBaseV[T, P]) SetValueSource(src ValueSource) { f.Source_ = src }
+
 func (f *FlagBaseV[T, P]) Type() (string, bool) {
 	t := f.VP.Type().String()
 	return t, len(t) != 0
@@ -182,6 +295,10 @@ func (f *FlagBaseV[T, P]) Decode(opts *ParseOptions, name, arg string, set bool)
 		return ErrFlagSetAtMostOnce{}
 	}
 
+	if set && !f.snapshotted {
+		f.initial, f.snapshotted = snapshotValue(f.Value), true
+	}
+
 	err := f.VP.ParseValue(opts, arg, &f.Value, set)
 	if err != nil {
 		return err
@@ -189,11 +306,28 @@ func (f *FlagBaseV[T, P]) Decode(opts *ParseOptions, name, arg string, set bool)
 
 	if set {
 		f.State_ |= FlagStateValueChanged
+		f.Source_ = ValueSourceArg
 	}
 
 	return nil
 }
 
+// ResetFlag implements [FlagResetter].
+func (f *FlagBaseV[T, P]) ResetFlag() {
+	if f.snapshotted {
+		f.Value = snapshotValue(f.initial)
+	}
+	f.State_ &^= FlagStateValueChanged
+	f.Source_ = ValueSourceUnset
+}
+
+// snapshotValue returns a copy of v not sharing slice, map or pointee storage
+// with v (see cloneValue).
+func snapshotValue[T any](v T) T {
+	ret, _ := cloneValue(reflect.ValueOf(&v).Elem()).Interface().(T)
+	return ret
+}
+
 // FlagEmptyV is a flag without value.
 type FlagEmptyV FlagBaseV[struct{}, VPNop[*struct{}]]
 
@@ -208,6 +342,69 @@ func (f *FlagEmptyV) Decode(opts *ParseOptions, name, arg string, set bool) erro
 	return ((*FlagBaseV[struct{}, VPNop[*struct{}]])(f)).Decode(opts, name, arg, set)
 }
 
+// ResetFlag implements [FlagResetter].
+func (f *FlagEmptyV) ResetFlag() {
+	((*FlagBaseV[struct{}, VPNop[*struct{}]])(f)).ResetFlag()
+}
+
+// ValueSource implements [ValueSourcer].
+func (f *FlagEmptyV) ValueSource() ValueSource { return f.Source_ }
+
+// SetValueSource implements [ValueSourcer].
+func (f *FlagEmptyV) SetValueSource(src ValueSource) { f.Source_ = src }
+
+// EnumV is a string flag only accepting values in VP.Choices.
+//
+// It implements [CompAction] to suggest all choices.
+type EnumV FlagBaseV[string, VPEnum[string]]
+
+func (f *EnumV) State() FlagState           { return f.State_ }
+func (f *EnumV) Usage() string              { return f.BriefUsage }
+func (f *EnumV) Extra() any                 { return f.Ext }
+func (f *EnumV) ImplyValue() (string, bool) { return "", false }
+func (f *EnumV) Type() (string, bool)       { return "enum", true }
+func (f *EnumV) HasValue() bool             { return f != nil && f.VP.HasValue(&f.Value) }
+
+func (f *EnumV) PrintValue(out io.Writer) (int, error) {
+	return f.VP.PrintValue(out, &f.Value)
+}
+
+func (f *EnumV) Decode(opts *ParseOptions, name, arg string, set bool) error {
+	return ((*FlagBaseV[string, VPEnum[string]])(f)).Decode(opts, name, arg, set)
+}
+
+// ResetFlag implements [FlagResetter].
+func (f *EnumV) ResetFlag() {
+	((*FlagBaseV[string, VPEnum[string]])(f)).ResetFlag()
+}
+
+// ValueSource implements [ValueSourcer].
+func (f *EnumV) ValueSource() ValueSource { return f.Source_ }
+
+// SetValueSource implements [ValueSourcer].
+func (f *EnumV) SetValueSource(src ValueSource) { f.Source_ = src }
+
+// Suggest implements [CompAction].
+//
+// It adds all choices matching tsk.ToComplete, then calls f.Ext if it is a
+// CompAction.
+func (f *EnumV) Suggest(tsk *CompTask) (added int, state CompState) {
+	for _, c := range f.VP.Choices {
+		added += tsk.AddMatched(false, CompItem{
+			Value: c,
+			Kind:  CompKindFlagValue,
+		})
+	}
+
+	if comp, ok := f.Ext.(CompAction); ok {
+		x, s := comp.Suggest(tsk)
+		added += x
+		state |= s
+	}
+
+	return
+}
+
 func implyFromVPType(t VPType) (string, bool) {
 	switch t & VPTypeVariantMASK {
 	case VPTypeVariantSum:
@@ -226,6 +423,8 @@ func implyFromVPType(t VPType) (string, bool) {
 		switch t {
 		case VPTypeBool:
 			return "true", true
+		case VPTypeTriState:
+			return "on", true
 		}
 	}
 
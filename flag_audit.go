@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 The Prime Citizens
+
+package cli
+
+import (
+	"strings"
+)
+
+// ValueSource tells where the current value of a flag comes from.
+type ValueSource uint8
+
+const (
+	ValueSourceUnset   ValueSource = iota // value not set.
+	ValueSourceDefault                    // set by AssignFlagsDefaultValue.
+	ValueSourceEnv                        // set from environment variables.
+	ValueSourceConfig                     // set from config files.
+	ValueSourceArg                        // set from command-line args.
+)
+
+// String returns one of "unset", "default", "env", "config" and "arg".
+func (s ValueSource) String() string {
+	switch s {
+	case ValueSourceDefault:
+		return "default"
+	case ValueSourceEnv:
+		return "env"
+	case ValueSourceConfig:
+		return "config"
+	case ValueSourceArg:
+		return "arg"
+	default:
+		return "unset"
+	}
+}
+
+// A ValueSourcer records the ValueSource of the flag value.
+//
+// All flag implementations in this package record ValueSourceArg in
+// Flag.Decode when set is true, use DecodeWithSource for other sources.
+type ValueSourcer interface {
+	ValueSource() ValueSource
+	SetValueSource(src ValueSource)
+}
+
+// DecodeWithSource calls flag.Decode with set = true, and records src as
+// the ValueSource on success if flag is a ValueSourcer.
+//
+// It is meant for loaders setting flag values from sources other than
+// command-line args (e.g. environment variables, config files).
+func DecodeWithSource(flag Flag, src ValueSource, opts *ParseOptions, name, arg string) error {
+	err := flag.Decode(opts, name, arg, true)
+	if err != nil {
+		return err
+	}
+
+	if s, ok := flag.(ValueSourcer); ok {
+		s.SetValueSource(src)
+	}
+
+	return nil
+}
+
+// FlagAudit is the result of AuditFlags for a flag.
+type FlagAudit struct {
+	Name      string
+	Shorthand string
+
+	// Value is the text written by Flag.PrintValue, empty if the flag has
+	// no value.
+	Value string
+
+	// Source is ValueSourceUnset if the flag is not a ValueSourcer.
+	Source ValueSource
+}
+
+// AuditFlags reports values and their sources of all flags in flags.
+func AuditFlags(flags FlagIndexer) (ret []FlagAudit) {
+	var sb strings.Builder
+	for i := 0; ; i++ {
+		info, ok := flags.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		_, flag, ok := FindFlag(flags, info.Name, info.Shorthand)
+		if !ok {
+			continue
+		}
+
+		audit := FlagAudit{
+			Name:      info.Name,
+			Shorthand: info.Shorthand,
+		}
+
+		if flag.HasValue() {
+			sb.Reset()
+			_, _ = flag.PrintValue(&sb)
+			audit.Value = sb.String()
+		}
+
+		if s, ok := flag.(ValueSourcer); ok {
+			audit.Source = s.ValueSource()
+		}
+
+		ret = append(ret, audit)
+	}
+
+	return
+}
@@ -4,6 +4,7 @@
 package cli
 
 import (
+	"reflect"
 	"sort"
 	"strings"
 )
@@ -15,6 +16,9 @@ type FlagInfo struct {
 	Name string
 	// Shorthand is the flag shorthand.
 	Shorthand string
+	// Aliases are additional long flag names of the same flag
+	// (e.g. `colour` for `color`).
+	Aliases []string
 
 	// DefaultValue is the default value used for the flag.
 	//
@@ -30,6 +34,30 @@ type FlagInfo struct {
 	State FlagState
 }
 
+// key returns the Name or the Shorthand if Name is empty.
+func (info *FlagInfo) key() string {
+	if len(info.Name) != 0 {
+		return info.Name
+	}
+
+	return info.Shorthand
+}
+
+// normalized returns a copy of info with a one-rune Name treated as the
+// shorthand: it is moved to Shorthand when Shorthand is empty, and the Name
+// is left empty.
+func (info FlagInfo) normalized() FlagInfo {
+	if len(info.Name) != 0 && IsShorthand(info.Name) {
+		if len(info.Shorthand) == 0 {
+			info.Shorthand = info.Name
+		}
+
+		info.Name = ""
+	}
+
+	return info
+}
+
 // FlagIter
 type FlagIter interface {
 	// NthFlag returns the i-th flag's info this iterator can find.
@@ -102,6 +130,18 @@ func (fn FuncIndexer) NthFlag(i int) (info FlagInfo, ok bool) {
 	return
 }
 
+// flagInfos is a FlagIter over a fixed list of FlagInfo.
+type flagInfos []FlagInfo
+
+// NthFlag implements [FlagIter].
+func (infos flagInfos) NthFlag(i int) (info FlagInfo, ok bool) {
+	if i < 0 || i >= len(infos) {
+		return info, false
+	}
+
+	return infos[i], true
+}
+
 // NewMapIndexer creates a new MapIndexer.
 func NewMapIndexer() *MapIndexer {
 	return &MapIndexer{
@@ -163,6 +203,8 @@ func (m *MapIndexer) AddWithDefaultValue(defaultValue string, flag Flag, names .
 		} else {
 			if len(fb.info.Name) == 0 {
 				fb.info.Name = name
+			} else {
+				fb.info.Aliases = append(fb.info.Aliases, name)
 			}
 		}
 	}
@@ -196,9 +238,128 @@ func (m *MapIndexer) NthFlag(i int) (info FlagInfo, ok bool) {
 	return
 }
 
+// MapFlagIndexer creates a FlagIndexer over m, where map keys are flag names
+// (single-rune keys are shorthands).
+//
+// Keys referencing the same flag are combined into one FlagInfo (the
+// smallest long name is the Name, other long names are Aliases), and
+// FlagInfos are sorted by name (or shorthand if there is no name).
+//
+// NOTE: NthFlag only reports keys present when this function is called.
+func MapFlagIndexer(m map[string]Flag) FlagIndexer {
+	var (
+		infos []FlagInfo
+		flags []Flag
+	)
+
+Keys:
+	for name, flag := range m {
+		shorthand := IsShorthand(name)
+
+		if flag != nil && reflect.TypeOf(flag).Comparable() {
+			for i := range flags {
+				if flags[i] != flag {
+					continue
+				}
+
+				if shorthand && len(infos[i].Shorthand) == 0 {
+					infos[i].Shorthand = name
+					continue Keys
+				} else if !shorthand {
+					if len(infos[i].Name) == 0 {
+						infos[i].Name = name
+					} else {
+						infos[i].Aliases = append(infos[i].Aliases, name)
+					}
+					continue Keys
+				}
+			}
+		}
+
+		if shorthand {
+			infos = append(infos, FlagInfo{Shorthand: name})
+		} else {
+			infos = append(infos, FlagInfo{Name: name})
+		}
+		flags = append(flags, flag)
+	}
+
+	for i := range infos {
+		if len(infos[i].Aliases) == 0 {
+			continue
+		}
+
+		// pick the smallest long name as Name for stable output.
+		names := append(infos[i].Aliases, infos[i].Name)
+		sort.Strings(names)
+		infos[i].Name, infos[i].Aliases = names[0], names[1:]
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].key() < infos[j].key()
+	})
+
+	return &mapFlagIndexer{m: m, infos: infos}
+}
+
+type mapFlagIndexer struct {
+	m     map[string]Flag
+	infos []FlagInfo
+}
+
+// FindFlag implements [FlagFinder].
+func (m *mapFlagIndexer) FindFlag(name string) (Flag, bool) {
+	f, ok := m.m[name]
+	return f, ok && f != nil
+}
+
+// NthFlag implements [FlagIter].
+func (m *mapFlagIndexer) NthFlag(i int) (info FlagInfo, ok bool) {
+	if i < 0 || i >= len(m.infos) {
+		return
+	}
+
+	info = m.infos[i]
+	if f, ok := m.FindFlag(info.key()); ok {
+		info.State = f.State()
+	}
+
+	return info, true
+}
+
+// CombinedFinder combines finders into one for layered lookup, FindFlag
+// returns the first match across finders in order.
+//
+// If all finders implement FlagIndexer, the returned FlagFinder is a
+// FlagIndexer (a *MultiIndexer with Dedup set), its NthFlag lists flags of
+// all finders in order, except those shadowed by earlier finders.
+func CombinedFinder(finders ...FlagFinder) FlagFinder {
+	for _, f := range finders {
+		if _, ok := f.(FlagIter); !ok {
+			return combinedFinder(finders)
+		}
+	}
+
+	return &MultiIndexer{Flags: finders, Dedup: true}
+}
+
+// combinedFinder is the FlagFinder returned by CombinedFinder when not all
+// finders are FlagIndexers.
+type combinedFinder []FlagFinder
+
+// FindFlag implements [FlagFinder].
+func (c combinedFinder) FindFlag(name string) (Flag, bool) {
+	return (&MultiIndexer{Flags: c}).FindFlag(name)
+}
+
 // MultiIndexer combines multiple FlagFinders into one.
 type MultiIndexer struct {
 	Flags []FlagFinderMaybeIter
+
+	// Dedup makes NthFlag skip flags whose name (or shorthand if there is
+	// no name) can be found in earlier Flags, as FindFlag never returns
+	// them.
+	Dedup bool
 }
 
 // FindFlag implements [FlagFinder].
@@ -215,6 +376,10 @@ func (m *MultiIndexer) FindFlag(name string) (f Flag, ok bool) {
 
 // NthFlag implements [FlagIter].
 func (m *MultiIndexer) NthFlag(i int) (info FlagInfo, ok bool) {
+	if m.Dedup {
+		return m.nthFlagDedup(i)
+	}
+
 	for _, fi := range m.Flags {
 		var iter FlagIter
 		iter, ok = fi.(FlagIter)
@@ -234,6 +399,74 @@ func (m *MultiIndexer) NthFlag(i int) (info FlagInfo, ok bool) {
 	return
 }
 
+// nthFlagDedup is NthFlag with m.Dedup set.
+func (m *MultiIndexer) nthFlagDedup(i int) (info FlagInfo, ok bool) {
+	for k, fi := range m.Flags {
+		iter, isIter := fi.(FlagIter)
+		if !isIter {
+			continue
+		}
+
+		for j := 0; ; j++ {
+			info, ok = iter.NthFlag(j)
+			if !ok {
+				break
+			}
+
+			key := info.Name
+			if len(key) == 0 {
+				key = info.Shorthand
+			}
+
+			if _, shadowed := (&MultiIndexer{Flags: m.Flags[:k]}).FindFlag(key); shadowed {
+				continue
+			}
+
+			if i == 0 {
+				return info, true
+			}
+
+			i--
+		}
+	}
+
+	return FlagInfo{}, false
+}
+
+// ObservingFinder is a FlagFinder wrapper reporting names that cannot be
+// found in the Inner FlagFinder, useful for detecting typos and deprecated
+// flag names.
+type ObservingFinder struct {
+	// Inner is the FlagFinder to delegate to.
+	Inner FlagFinderMaybeIter
+
+	// OnMiss is called with the flag name when Inner.FindFlag returned false.
+	OnMiss func(name string)
+}
+
+// FindFlag implements [FlagFinder].
+func (o *ObservingFinder) FindFlag(name string) (f Flag, ok bool) {
+	if o.Inner != nil {
+		f, ok = o.Inner.FindFlag(name)
+	}
+
+	if !ok && o.OnMiss != nil {
+		o.OnMiss(name)
+	}
+
+	return
+}
+
+// NthFlag implements [FlagIter].
+func (o *ObservingFinder) NthFlag(i int) (info FlagInfo, ok bool) {
+	iter, ok := o.Inner.(FlagIter)
+	if !ok {
+		return
+	}
+
+	return iter.NthFlag(i)
+}
+
 // FlagLevel
 type FlagLevel interface {
 	// TrimAllLevelPrefixes tirms all prefixes belonging to each level.
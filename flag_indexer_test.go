@@ -4,9 +4,13 @@
 package cli
 
 import (
+	"io"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/primecitizens/cli/internal/assert"
 )
@@ -18,6 +22,8 @@ var (
 	_ FlagIndexer = (*LevelIndexer)(nil)
 	_ FlagLevel   = (*LevelIndexer)(nil)
 	_ FlagIndexer = (*ReflectIndexer)(nil)
+	_ FlagIndexer = (*ObservingFinder)(nil)
+	_ FlagIndexer = (*mapFlagIndexer)(nil)
 )
 
 func assertFlagTrue(t *testing.T, f Flag, ok bool) {
@@ -88,6 +94,51 @@ func TestMapIndexer(t *testing.T) {
 	testIndexer(t, indexer)
 }
 
+func TestMapFlagIndexer(t *testing.T) {
+	var (
+		verbose BoolV
+		output  StringV
+		quiet   BoolV
+		n       IntV
+	)
+
+	flags := MapFlagIndexer(map[string]Flag{
+		"verbose": &verbose,
+		"v":       &verbose,
+		"output":  &output,
+		"quiet":   &quiet,
+		"n":       &n,
+	})
+
+	f, ok := flags.FindFlag("v")
+	assertFlagTrue(t, f, ok)
+	assert.Eq[Flag](t, &verbose, f)
+
+	f, ok = flags.FindFlag("output")
+	assertFlagTrue(t, f, ok)
+	assert.Eq[Flag](t, &output, f)
+
+	f, ok = flags.FindFlag("non-existing")
+	assertNoflagFalse(t, f, ok)
+
+	var infos []FlagInfo
+	for i := 0; ; i++ {
+		info, ok := flags.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		infos = append(infos, info)
+	}
+
+	assert.DeepEq(t, []FlagInfo{
+		{Shorthand: "n"},
+		{Name: "output"},
+		{Name: "quiet"},
+		{Name: "verbose", Shorthand: "v"},
+	}, infos)
+}
+
 func TestMultiIndexer(t *testing.T) {
 	indexer := &MultiIndexer{
 		Flags: []FlagFinderMaybeIter{
@@ -105,6 +156,69 @@ func TestMultiIndexer(t *testing.T) {
 	testIndexer(t, indexer)
 }
 
+func TestCombinedFinder(t *testing.T) {
+	var opts struct {
+		Output  string `cli:"output|o"`
+		Verbose bool   `cli:"verbose|v"`
+	}
+
+	explicit := &String{}
+	finder := CombinedFinder(
+		NewMapIndexer().Add(explicit, "output").Add(&Bool{}, "dry-run", "n"),
+		NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+	)
+
+	f, ok := finder.FindFlag("output")
+	assert.True(t, ok)
+	assert.True(t, f == Flag(explicit))
+
+	f, ok = finder.FindFlag("o")
+	assert.True(t, ok)
+	_, isReflect := f.(*FlagReflect)
+	assert.True(t, isReflect)
+
+	indexer, ok := finder.(FlagIndexer)
+	assert.True(t, ok)
+
+	var infos []FlagInfo
+	for i := 0; ; i++ {
+		info, ok := indexer.NthFlag(i)
+		if !ok {
+			break
+		}
+		infos = append(infos, info)
+	}
+	assert.DeepEq(t, []FlagInfo{
+		{Name: "output"},
+		{Name: "dry-run", Shorthand: "n"},
+		{Name: "verbose", Shorthand: "v"},
+	}, infos)
+
+	// only FindFlag is exposed through the embedded interface.
+	finder = CombinedFinder(finder, struct{ FlagFinder }{NewMapIndexer().Add(&Bool{}, "extra")})
+	_, ok = finder.(FlagIter)
+	assert.False(t, ok)
+	_, ok = finder.FindFlag("extra")
+	assert.True(t, ok)
+	_, ok = finder.FindFlag("dry-run")
+	assert.True(t, ok)
+}
+
+func TestObservingFinder(t *testing.T) {
+	var missed []string
+	indexer := &ObservingFinder{
+		Inner: NewMapIndexer().
+			Add(&Bool{}, "foo", "f").
+			Add(&Bool{}, "bar", "b"),
+		OnMiss: func(name string) {
+			missed = append(missed, name)
+		},
+	}
+
+	testIndexer(t, indexer)
+	assert.EqS(t, []string{"non-existing"}, missed)
+}
+
 func testIndexer(t *testing.T, indexer FlagIndexer) {
 	f, ok := indexer.FindFlag("f")
 	assertFlagTrue(t, f, ok)
@@ -244,3 +358,399 @@ func TestLevelIndexer(t *testing.T) {
 		)
 	})
 }
+
+func TestDefaultReflectVPFactory_SupportedTypes(t *testing.T) {
+	var lister ReflectVPTypeLister = DefaultReflectVPFactory{}
+	types := lister.SupportedTypes()
+	for _, typ := range []string{
+		"size", "dur", "dur-csv", "sum", "ssum", "dsum", "range", "regexp", "regexp-nocase",
+		"time", "unix-ts", "unix-ms", "unix-us", "unix-ns",
+	} {
+		found := false
+		for _, v := range types {
+			found = found || v == typ
+		}
+		assert.True(t, found)
+	}
+
+	type Opts struct {
+		Foo int `cli:"foo,value=duration"`
+	}
+
+	var panicked any
+	func() {
+		defer func() { panicked = recover() }()
+		r := NewReflectIndexer(DefaultReflectVPFactory{}, &Opts{})
+		r.CheckTypes = true
+		_, _ = r.FindFlag("foo")
+	}()
+	assert.Eq[any](t, "unsupported type: value=duration", panicked)
+
+	// custom types of a factory embedding DefaultReflectVPFactory are not
+	// checked by default.
+	type LevelOpts struct {
+		Level string `cli:"level,value=level"`
+	}
+
+	flag, ok := NewReflectIndexer(levelVPFactory{}, &LevelOpts{}).FindFlag("level")
+	assert.True(t, ok)
+	assert.NoError(t, flag.Decode(nil, "level", "warn", true))
+}
+
+type reflectOpts30 struct {
+	F00 string `cli:"f00"`
+	F01 string `cli:"f01"`
+	F02 string `cli:"f02"`
+	F03 string `cli:"f03"`
+	F04 string `cli:"f04"`
+	F05 string `cli:"f05"`
+	F06 string `cli:"f06"`
+	F07 string `cli:"f07"`
+	F08 string `cli:"f08"`
+	F09 string `cli:"f09"`
+	F10 string `cli:"f10"`
+	F11 string `cli:"f11"`
+	F12 string `cli:"f12"`
+	F13 string `cli:"f13"`
+	F14 string `cli:"f14"`
+	F15 string `cli:"f15"`
+	F16 string `cli:"f16"`
+	F17 string `cli:"f17"`
+	F18 string `cli:"f18"`
+	F19 string `cli:"f19"`
+	F20 string `cli:"f20"`
+	F21 string `cli:"f21"`
+	F22 string `cli:"f22"`
+	F23 string `cli:"f23"`
+	F24 string `cli:"f24"`
+	F25 string `cli:"f25"`
+	F26 string `cli:"f26"`
+	F27 string `cli:"f27"`
+	F28 string `cli:"f28"`
+	F29 string `cli:"f29"`
+}
+
+var reflectOpts30Names = func() (names []string) {
+	for i := 0; i < 30; i++ {
+		names = append(names, "f"+strconv.Itoa(i/10)+strconv.Itoa(i%10))
+	}
+	return
+}()
+
+func TestReflectIndexer_Preindex(t *testing.T) {
+	var opts reflectOpts30
+	flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	flags.Preindex = true
+
+	f, ok := flags.FindFlag("f00")
+	assertFlagTrue(t, f, ok)
+	assert.Eq(t, 30, flags.TotalFlags)
+	assert.Eq(t, 30, len(flags.Refs))
+
+	for i, name := range reflectOpts30Names {
+		info, ok := flags.NthFlag(i)
+		assert.True(t, ok)
+		assert.Eq(t, name, info.Name)
+
+		f, ok := flags.FindFlag(name)
+		assertFlagTrue(t, f, ok)
+		assert.NoError(t, f.Decode(nil, name, name, true))
+	}
+	assert.Eq(t, "f29", opts.F29)
+
+	_, ok = flags.NthFlag(30)
+	assert.False(t, ok)
+	f, ok = flags.FindFlag("non-existing")
+	assertNoflagFalse(t, f, ok)
+}
+
+func TestReflectIndexer_Concurrent(t *testing.T) {
+	for _, preindex := range []bool{false, true} {
+		var opts reflectOpts30
+		flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+		flags.Preindex = preindex
+
+		var (
+			wg    sync.WaitGroup
+			errs  = make([]string, 8)
+			names = reflectOpts30Names
+		)
+		for g := range errs {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+
+				for k := range names {
+					// walk names in different orders per goroutine
+					i := (k + g*7) % len(names)
+					if g%2 == 0 {
+						f, ok := flags.FindFlag(names[i])
+						if !ok || f == nil {
+							errs[g] = "flag not found: " + names[i]
+							return
+						}
+					} else {
+						info, ok := flags.NthFlag(i)
+						if !ok || info.Name != names[i] {
+							errs[g] = "unexpected nth flag: " + info.Name
+							return
+						}
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			assert.Eq(t, "", err)
+		}
+		assert.Eq(t, 30, flags.TotalFlags)
+		assert.Eq(t, 30, len(flags.Refs))
+	}
+}
+
+func BenchmarkReflectIndexer_FindFlag(b *testing.B) {
+	for _, preindex := range []bool{false, true} {
+		b.Run("Preindex="+strconv.FormatBool(preindex), func(b *testing.B) {
+			var opts reflectOpts30
+			for i := 0; i < b.N; i++ {
+				flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+				flags.Preindex = preindex
+				for _, name := range reflectOpts30Names {
+					_, _ = flags.FindFlag(name)
+				}
+			}
+		})
+	}
+}
+
+func TestFlagSet(t *testing.T) {
+	var (
+		verbose bool
+		output  string
+		count   int
+		timeout time.Duration
+		tags    []string
+	)
+
+	flags := NewFlagSet().
+		BoolVar(&verbose, "verbose output", "verbose", "v").
+		StringVar(&output, "output file", "output", "o").Default("-").
+		IntVar(&count, "", "count", "n").
+		DurationVar(&timeout, "", "timeout").
+		StringSliceVar(&tags, "", "tag")
+
+	root := &Cmd{
+		Flags: flags,
+		Run:   func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+	}
+
+	err := root.Exec(nil, "-vn", "3", "--timeout", "1m", "--tag", "a", "--tag=b")
+	assert.NoError(t, err)
+	assert.True(t, verbose)
+	assert.Eq(t, "-", output)
+	assert.Eq(t, 3, count)
+	assert.Eq(t, time.Minute, timeout)
+	assert.EqS(t, []string{"a", "b"}, tags)
+
+	f, ok := flags.FindFlag("o")
+	assertFlagTrue(t, f, ok)
+	assert.Eq(t, "output file", f.Usage())
+}
+
+func TestNewReflectIndexerWithDefaults(t *testing.T) {
+	var opts struct {
+		Output string `cli:"output|o,#output file"`
+		Level  int    `cli:"level,def=3"`
+		Quiet  bool   `cli:"q"`
+	}
+
+	indexer := NewReflectIndexerWithDefaults(DefaultReflectVPFactory{}, &opts, map[string]string{
+		"output": "out.txt",
+		"level":  "5", // overridden by tag
+		"q":      "true",
+	})
+
+	var defaults []string
+	for i := 0; ; i++ {
+		info, ok := indexer.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		defaults = append(defaults, info.DefaultValue)
+	}
+	assert.EqS(t, []string{"out.txt", "3", "true"}, defaults)
+
+	err := (&Cmd{
+		Pattern: "test",
+		Flags:   indexer,
+		Run:     func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+	}).Exec(nil)
+	assert.NoError(t, err)
+	assert.Eq(t, "out.txt", opts.Output)
+	assert.Eq(t, 3, opts.Level)
+	assert.True(t, opts.Quiet)
+}
+
+func TestReflectIndexer_DurCSV(t *testing.T) {
+	var opts struct {
+		Backoff []time.Duration `cli:"backoff,value=dur-csv"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+
+	_, _, err := ParseFlags([]string{"--backoff", "1s,2s,5s"}, indexer, nil)
+	assert.NoError(t, err)
+	assert.EqS(t, []time.Duration{time.Second, 2 * time.Second, 5 * time.Second}, opts.Backoff)
+
+	_, _, err = ParseFlags([]string{"--backoff", "10s,1x,3s"}, indexer, nil)
+	assert.ErrorIs(t, &ErrFlagValueInvalid{
+		Name:    "backoff",
+		Value:   "10s,1x,3s",
+		ValueAt: 1,
+		Reason:  &ErrInvalidValue{Type: "dur", Value: "1x"},
+	}, err)
+	assert.EqS(t, []time.Duration{time.Second, 2 * time.Second, 5 * time.Second}, opts.Backoff)
+}
+
+func TestReflectIndexer_FlagRule(t *testing.T) {
+	var opts struct {
+		JSON bool `cli:"json,rule=oneof:json|yaml"`
+		YAML bool `cli:"yaml"`
+
+		User     string   `cli:"user"`
+		Password string   `cli:"password"`
+		_        struct{} `cli:",rule=allornone:user|password"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	rule := indexer.FlagRule()
+	multi, ok := rule.(*MultiRule)
+	assert.True(t, ok)
+	assert.Eq(t, 2, len(multi.Rules))
+	_, ok = multi.Rules[0].(*RuleOneOf)
+	assert.True(t, ok)
+	_, ok = multi.Rules[1].(*RuleAllOrNone)
+	assert.True(t, ok)
+
+	for _, test := range []struct {
+		args []string
+		bad  error
+	}{
+		{[]string{"--json"}, nil},
+		{[]string{"--json", "--yaml"}, &FlagViolation{Key: "yaml", Reason: ViolationCodeExcessiveOneOf}},
+		{[]string{"--user", "foo"}, &FlagViolation{Key: "json", Reason: ViolationCodeEmptyOneOf}},
+		{[]string{"--yaml", "--user", "foo"}, &FlagViolation{Key: "password", Reason: ViolationCodePartialAllOrNone}},
+	} {
+		indexer = NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+		cmd := &Cmd{
+			Pattern:  "test",
+			Flags:    indexer,
+			FlagRule: indexer.FlagRule(),
+			Run:      func(opts *CmdOptions, route Route, posArgs, dashArgs []string) error { return nil },
+		}
+
+		err := cmd.Exec(&CmdOptions{Stderr: io.Discard}, test.args...)
+		if test.bad == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, test.bad, err)
+		}
+	}
+}
+
+func TestReflectIndexer_Normalize(t *testing.T) {
+	var opts struct {
+		Lower string   `cli:"lower,normalize=lower"`
+		Upper []string `cli:"upper,normalize=upper"`
+		Trim  string   `cli:"trim,normalize=trim,normalize=lower"`
+		Level string   `cli:"level,value=level,normalize=lower"`
+		Plain string   `cli:"plain,value=level"`
+	}
+
+	indexer := NewReflectIndexer(levelVPFactory{}, &opts)
+	_, _, err := ParseFlags([]string{
+		"--lower", "MiXeD",
+		"--upper", "a", "--upper", "Bc",
+		"--trim", "  Spaced\t",
+		"--level", "WARN",
+	}, indexer, nil)
+	assert.NoError(t, err)
+	assert.Eq(t, "mixed", opts.Lower)
+	assert.EqS(t, []string{"A", "BC"}, opts.Upper)
+	assert.Eq(t, "spaced", opts.Trim)
+	assert.Eq(t, "warn", opts.Level)
+
+	_, _, err = ParseFlags([]string{"--plain=WARN"}, indexer, nil)
+	assert.Error(t, err)
+	assert.Eq(t, "", opts.Plain)
+}
+
+func TestDefaultReflectVPFactory_Duration(t *testing.T) {
+	type Timeout time.Duration
+
+	var opts struct {
+		Dur      time.Duration            `cli:"dur"`
+		Durs     []time.Duration          `cli:"durs"`
+		DurMap   map[string]time.Duration `cli:"dur-map"`
+		Timeout  Timeout                  `cli:"timeout,value=dur"`
+		Timeouts []Timeout                `cli:"timeouts,value=dur"`
+		Int      int64                    `cli:"int"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	_, _, err := ParseFlags([]string{
+		"--dur", "5s",
+		"--durs", "1s", "--durs", "1m",
+		"--dur-map", "a=2s",
+		"--timeout", "5s",
+		"--timeouts", "1h",
+		"--int", "5",
+	}, indexer, nil)
+	assert.NoError(t, err)
+	assert.Eq(t, 5*time.Second, opts.Dur)
+	assert.EqS(t, []time.Duration{time.Second, time.Minute}, opts.Durs)
+	assert.Eq(t, 2*time.Second, opts.DurMap["a"])
+	assert.Eq(t, Timeout(5*time.Second), opts.Timeout)
+	assert.EqS(t, []Timeout{Timeout(time.Hour)}, opts.Timeouts)
+	assert.Eq(t, int64(5), opts.Int)
+
+	_, _, err = ParseFlags([]string{"--int=5s"}, indexer, nil)
+	assert.Error(t, err)
+
+	type Count int64
+
+	var untagged struct {
+		Timeout    Timeout            `cli:"timeout"`
+		TimeoutPtr *Timeout           `cli:"timeout-ptr"`
+		Timeouts   []Timeout          `cli:"timeouts"`
+		TimeoutMap map[string]Timeout `cli:"timeout-map"`
+		Count      Count              `cli:"count"`
+	}
+
+	factory := DefaultReflectVPFactory{
+		DurationTypes: []reflect.Type{reflect.TypeOf(Timeout(0)), reflect.TypeOf("")},
+	}
+	indexer = NewReflectIndexer(factory, &untagged)
+	_, _, err = ParseFlags([]string{
+		"--timeout", "5s",
+		"--timeout-ptr", "1m",
+		"--timeouts", "1h",
+		"--timeout-map", "a=2s",
+		"--count", "5",
+	}, indexer, nil)
+	assert.NoError(t, err)
+	assert.Eq(t, Timeout(5*time.Second), untagged.Timeout)
+	assert.Eq(t, Timeout(time.Minute), *untagged.TimeoutPtr)
+	assert.EqS(t, []Timeout{Timeout(time.Hour)}, untagged.Timeouts)
+	assert.Eq(t, Timeout(2*time.Second), untagged.TimeoutMap["a"])
+	assert.Eq(t, Count(5), untagged.Count)
+
+	_, _, err = ParseFlags([]string{"--count=5s"}, indexer, nil)
+	assert.Error(t, err)
+
+	// not listed
+	_, _, err = ParseFlags([]string{"--timeout=5s"}, NewReflectIndexer(DefaultReflectVPFactory{}, &untagged), nil)
+	assert.Error(t, err)
+}
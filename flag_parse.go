@@ -4,6 +4,8 @@
 package cli
 
 import (
+	"io"
+	"os"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -21,6 +23,12 @@ type ParseOptions struct {
 	// StartTime is assumed to be the time of parsing start.
 	StartTime time.Time
 
+	// TimeLocation is the location used to parse time values, it takes
+	// precedence over StartTime.Location().
+	//
+	// Defaults to nil (use the location of StartTime or time.Now()).
+	TimeLocation *time.Location
+
 	// HandleParseError is the function to handle flag parsing errors.
 	HandleParseError ParseErrorHandleFunc
 
@@ -35,12 +43,268 @@ type ParseOptions struct {
 	//	- otherwise, use the supplied HelpArgs to match args.
 	HelpArgs []string
 
+	// HelpArgSpecs are HelpArgs with scopes, it takes precedence over
+	// HelpArgs when not nil (e.g. `help` only as a command and `-h` only
+	// as a flag).
+	//
+	//	- HelpArgSpecs = []HelpArgSpec{} will disable the help system.
+	HelpArgSpecs []HelpArgSpec
+
+	// PosixStrict disables GNU style conveniences to parse args like classic
+	// POSIX utilities:
+	//
+	//	- args prefixed with `--` (except the standalone dash) are operands
+	//	  (positional args) instead of long flags.
+	//	- flag parsing stops at the first operand, all remaining args are
+	//	  operands (dash still applies).
+	//	- an attached flag value is everything after the shorthand, so `-o=x`
+	//	  sets `=x` to `-o`.
+	PosixStrict bool
+
+	// BoolNegation enables `--no-<name>` to set bool flag `<name>` to false
+	// when there is no flag named `no-<name>`.
+	//
+	// It only applies to long names without an attached value.
+	BoolNegation bool
+
+	// AssignChar is the character separating flag name and attached value
+	// (e.g. ':' for `--out:file` and `-o:file`).
+	//
+	// Defaults to 0 (use '=').
+	//
+	// NOTE: It only changes how dash-prefixed flags are split, slash-prefixed
+	// (Windows style) flags like `/out:file` are not supported and are
+	// treated as positional args.
+	AssignChar byte
+
+	// MapAssignChar makes map flag values (`key=value`) also split by
+	// AssignChar instead of '='.
+	MapAssignChar bool
+
+	// RequireEqForLongValues requires values of long flags to be attached
+	// with `=` (or AssignChar), `--out file` fails with
+	// ErrFlagValueRequiresEq instead of consuming `file`.
+	//
+	// Flags with implied value (e.g. bool flags) are not affected.
+	RequireEqForLongValues bool
+
+	// CollectUnknown, when not nil, collects undefined flags verbatim in
+	// order instead of failing with ErrFlagUndefined, known flags are still
+	// parsed (e.g. for forwarding unknown flags to a plugin).
+	//
+	// An undefined flag without attached value consumes the next arg as its
+	// value unless the next arg looks like a flag or is the dash, use
+	// `--name=value` to be unambiguous.
+	//
+	// A shorthand cluster with undefined shorthands is collected as a whole
+	// while its known shorthands before the undefined one are parsed.
+	CollectUnknown *[]string
+
+	// Stdin is where values from stdin are read (e.g. secret value `-` of
+	// VPSecretSource).
+	//
+	// Defaults to nil (use os.Stdin).
+	Stdin io.Reader
+
+	// LookupEnv looks up environment variables (e.g. secret value `env:VAR`
+	// of VPSecretSource, flag values in LoadFlagsFromEnv).
+	//
+	// Defaults to nil (use os.LookupEnv).
+	LookupEnv func(key string) (string, bool)
+
+	// Trace, when not nil, is called by ParseFlagsLowLevel for each decision
+	// made on args (e.g. an arg classified as long flag or positional arg),
+	// it is a diagnostic hook and SHOULD NOT modify parsing state.
+	Trace func(event ParseEvent)
+
 	// Extra custom data.
 	Extra any
 }
 
-// IsHelpArg returns true if x is supposed to be an arg requesting help.
+// trace calls c.Trace if set.
+func (c *ParseOptions) trace(kind ParseEventKind, at int, arg string, err error) {
+	if c == nil || c.Trace == nil {
+		return
+	}
+
+	c.Trace(ParseEvent{Kind: kind, At: at, Arg: arg, Err: err})
+}
+
+// stdin returns c.Stdin, or os.Stdin if it is nil.
+func (c *ParseOptions) stdin() io.Reader {
+	if c == nil || c.Stdin == nil {
+		return os.Stdin
+	}
+
+	return c.Stdin
+}
+
+// lookupEnv calls c.LookupEnv, or os.LookupEnv if it is nil.
+func (c *ParseOptions) lookupEnv(key string) (string, bool) {
+	if c == nil || c.LookupEnv == nil {
+		return os.LookupEnv(key)
+	}
+
+	return c.LookupEnv(key)
+}
+
+// baseTime returns the time used to parse time and duration values.
+func (c *ParseOptions) baseTime() (t time.Time) {
+	if c == nil || c.StartTime.IsZero() {
+		t = time.Now()
+	} else {
+		t = c.StartTime
+	}
+
+	if c != nil && c.TimeLocation != nil {
+		t = t.In(c.TimeLocation)
+	}
+
+	return
+}
+
+// assignSep returns the separator of flag name and attached value.
+func (c *ParseOptions) assignSep() string {
+	if c == nil || c.AssignChar == 0 {
+		return "="
+	}
+
+	return string(c.AssignChar)
+}
+
+// mapAssignSep returns the separator of map key and value.
+func (c *ParseOptions) mapAssignSep() string {
+	if c == nil || !c.MapAssignChar {
+		return "="
+	}
+
+	return c.assignSep()
+}
+
+// collectUnknown appends the undefined flag args[i] and its value to
+// c.CollectUnknown, returns true if the next arg is consumed as the value.
+func (c *ParseOptions) collectUnknown(args []string, i int) (shiftNext bool) {
+	*c.CollectUnknown = append(*c.CollectUnknown, args[i])
+	if strings.Contains(strings.TrimLeft(args[i], "-"), c.assignSep()) || i+1 == len(args) {
+		return false
+	}
+
+	next := args[i+1]
+	if next == "--" || (len(next) > 1 && next[0] == '-') {
+		return false
+	}
+
+	*c.CollectUnknown = append(*c.CollectUnknown, next)
+	return true
+}
+
+// ParseEventKind classifies a ParseEvent.
+type ParseEventKind uint8
+
+const (
+	ParseEventPosArg      ParseEventKind = iota + 1 // positional arg found.
+	ParseEventLongFlag                              // long flag matched and parsed.
+	ParseEventShortFlags                            // shorthand (cluster) matched and parsed.
+	ParseEventFlagValue                             // arg consumed as the value of the previous flag.
+	ParseEventUnknownFlag                           // undefined flag collected by ParseOptions.CollectUnknown.
+	ParseEventHelpArg                               // help arg found, parsing stops.
+	ParseEventDash                                  // dash (`--`) found, parsing stops.
+	ParseEventError                                 // error parsing the arg.
+)
+
+// String returns one of "posarg", "long-flag", "short-flags", "flag-value",
+// "unknown-flag", "help-arg", "dash" and "error".
+func (k ParseEventKind) String() string {
+	switch k {
+	case ParseEventPosArg:
+		return "posarg"
+	case ParseEventLongFlag:
+		return "long-flag"
+	case ParseEventShortFlags:
+		return "short-flags"
+	case ParseEventFlagValue:
+		return "flag-value"
+	case ParseEventUnknownFlag:
+		return "unknown-flag"
+	case ParseEventHelpArg:
+		return "help-arg"
+	case ParseEventDash:
+		return "dash"
+	case ParseEventError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseEvent describes a decision made by ParseFlagsLowLevel on an arg, it
+// is passed to ParseOptions.Trace.
+type ParseEvent struct {
+	// Kind is how the arg is classified.
+	Kind ParseEventKind
+
+	// At is the index of the arg in args.
+	At int
+
+	// Arg is the arg value.
+	Arg string
+
+	// Err is the error parsing the arg, only set for ParseEventError, it is
+	// reported before HandleParseError is called.
+	Err error
+}
+
+// HelpArgScope is a bitmask of arg positions where a help arg is recognized.
+type HelpArgScope uint8
+
+const (
+	// HelpArgScopeFlag is where a flag is expected (a hyphen-prefixed arg
+	// not consumed as flag value).
+	HelpArgScopeFlag HelpArgScope = 1 << iota
+
+	// HelpArgScopeCommand is the first positional arg of a command, where a
+	// subcommand is expected.
+	HelpArgScopeCommand
+
+	// HelpArgScopePosArg is a positional arg other than the first one.
+	HelpArgScopePosArg
+
+	// HelpArgScopeAny is all positions.
+	HelpArgScopeAny = HelpArgScopeFlag | HelpArgScopeCommand | HelpArgScopePosArg
+)
+
+// HelpArgSpec is a help arg with its scope.
+type HelpArgSpec struct {
+	// Arg is the arg value initiating help request.
+	Arg string
+
+	// Scope is where Arg is recognized, zero value means HelpArgScopeAny.
+	Scope HelpArgScope
+}
+
+// IsHelpArg returns true if x is supposed to be an arg requesting help
+// regardless of its position.
 func (c *ParseOptions) IsHelpArg(x string) bool {
+	return c.IsHelpArgAt(x, HelpArgScopeAny)
+}
+
+// IsHelpArgAt is like IsHelpArg, but only returns true if x is recognized
+// in any position of scope.
+func (c *ParseOptions) IsHelpArgAt(x string, scope HelpArgScope) bool {
+	if c != nil && c.HelpArgSpecs != nil {
+		for _, spec := range c.HelpArgSpecs {
+			if spec.Arg != x {
+				continue
+			}
+
+			if spec.Scope == 0 || spec.Scope&scope != 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+
 	if c == nil || c.HelpArgs == nil {
 		switch x {
 		case "--help", "-h", "help":
@@ -94,6 +358,56 @@ func ParseFlags(args []string, flags FlagFinder, opts *ParseOptions) (posArgs, d
 	return
 }
 
+// ParseOneFlag parses the single flag token (e.g. `--foo=bar`, `-abc`,
+// `-o=1`) and sets the value of the flag found in flags.
+//
+// Unlike ParseFlags, it never takes another arg as flag value, so values
+// of flags without implied value MUST be attached with `=`.
+//
+// The return value name is the flag name in token (the last shorthand for
+// a shorthand cluster, or the undefined one on *ErrFlagUndefined), set is
+// true if the value has been set.
+//
+// It returns an *ErrInvalidValue if token is not a flag (e.g. a positional
+// arg or `--`), or an *ErrMalformedFlag if the flag name is empty (e.g.
+// `--=value`).
+func ParseOneFlag(token string, flags FlagFinder, opts *ParseOptions) (name string, set bool, err error) {
+	if len(token) < 2 || token[0] != '-' {
+		return "", false, &ErrInvalidValue{
+			Type:  "flag",
+			Value: token,
+		}
+	}
+
+	args := [1]string{token}
+	if token[1] == '-' {
+		name, _, _ = strings.Cut(token[2:], opts.assignSep())
+		if len(name) == 0 && len(token) == 2 { // `--`
+			return "", false, &ErrInvalidValue{
+				Type:  "flag",
+				Value: token,
+			}
+		}
+
+		_, err = parseLongFlag(flags, opts, args[:], 0, true)
+	} else {
+		name, _, _ = strings.Cut(token[1:], opts.assignSep())
+		_, sz := utf8.DecodeLastRuneInString(name)
+		name = name[len(name)-sz:]
+		_, err = parseShortFlags(flags, opts, args[:], 0, true)
+	}
+
+	if err != nil {
+		if e, ok := err.(*ErrFlagUndefined); ok {
+			name = e.Name
+		}
+
+		return name, false, err
+	}
+
+	return name, true, nil
+}
+
 // ParseFlagsLowLevel is the low-level version of ParseFlags with more
 // options for flag parsing control.
 //
@@ -104,9 +418,9 @@ func ParseFlags(args []string, flags FlagFinder, opts *ParseOptions) (posArgs, d
 // positional arg (before the dash), that arg is not added to nParsed.
 //
 // If len(opts.HelpArgs) > 0, this function returns on reaching the first flag
-// that matches any of help flags, in which case, the return value helpArgAt
-// is expected to be greater or equal to zero, and args[helpArgAt] is the arg
-// triggered this return.
+// that matches any of help flags (in its scope, see opts.HelpArgSpecs), in
+// which case, the return value helpArgAt is expected to be greater or equal
+// to zero, and args[helpArgAt] is the arg triggered this return.
 //
 // If setFlagValue is false, this function calls Flag.Decode() with set = false.
 //
@@ -142,14 +456,30 @@ func ParseFlagsLowLevel(
 		arg := args[i]
 
 		szArg := len(arg)
-		if szArg == 0 || arg[0] != '-' || szArg == 1 /* '-' */ {
+		isPosArg := szArg == 0 || arg[0] != '-' || szArg == 1 /* '-' */
+		if !isPosArg && opts != nil && opts.PosixStrict {
+			isPosArg = (foundPosArg && arg != "--") || (arg[1] == '-' && szArg > 2)
+		}
+
+		if isPosArg {
+			scope := HelpArgScopeCommand
+			if foundPosArg {
+				scope = HelpArgScopePosArg
+			}
 			foundPosArg = true
 
 			if appendPosArgs {
 				posArgs = append(posArgs, arg)
 			}
 
-			if isHelpArg := opts.IsHelpArg(arg); isHelpArg || stopAtFirstPosArg {
+			isHelpArg := opts.IsHelpArgAt(arg, scope)
+			if isHelpArg {
+				opts.trace(ParseEventHelpArg, i, arg, nil)
+			} else {
+				opts.trace(ParseEventPosArg, i, arg, nil)
+			}
+
+			if isHelpArg || stopAtFirstPosArg {
 				if isHelpArg {
 					helpArgAt = i
 				}
@@ -163,15 +493,18 @@ func ParseFlagsLowLevel(
 		}
 
 		var shiftNext bool
+		kind := ParseEventShortFlags
 		if arg[1] == '-' {
 			if szArg == 2 {
 				// dash
+				opts.trace(ParseEventDash, i, arg, nil)
 				nParsed = len(args)
 				posDash = i
 				return
 			}
 
-			if opts.IsHelpArg(arg) {
+			if opts.IsHelpArgAt(arg, HelpArgScopeFlag) {
+				opts.trace(ParseEventHelpArg, i, arg, nil)
 				helpArgAt = i
 				if _, ok := flags.FindFlag(arg[2:]); ok {
 					// there is real help flag, parse it as application may expect
@@ -185,9 +518,11 @@ func ParseFlagsLowLevel(
 				return
 			}
 
+			kind = ParseEventLongFlag
 			shiftNext, err = parseLongFlag(flags, opts, args, i, setFlagValue)
 		} else {
-			if opts.IsHelpArg(arg) {
+			if opts.IsHelpArgAt(arg, HelpArgScopeFlag) {
+				opts.trace(ParseEventHelpArg, i, arg, nil)
 				helpArgAt = i
 				if _, ok := flags.FindFlag(arg[1:]); ok {
 					// there is real help flag, parse it as application may expect
@@ -208,7 +543,18 @@ func ParseFlagsLowLevel(
 			shiftNext, err = parseShortFlags(flags, opts, args, i, setFlagValue)
 		}
 
-		if err != nil {
+		if err != nil && opts != nil && opts.CollectUnknown != nil {
+			if _, undefined := err.(*ErrFlagUndefined); undefined {
+				shiftNext, err = opts.collectUnknown(args, i), nil
+				kind = ParseEventUnknownFlag
+			}
+		}
+
+		if err == nil {
+			opts.trace(kind, i, arg, nil)
+		} else {
+			opts.trace(ParseEventError, i, arg, err)
+
 			if opts == nil || opts.HandleParseError == nil { // no error handler
 				nParsed = i + 1 - offset
 				return
@@ -225,6 +571,9 @@ func ParseFlagsLowLevel(
 
 		if shiftNext {
 			i++
+			if i < len(args) {
+				opts.trace(ParseEventFlagValue, i, args[i], nil)
+			}
 		}
 	}
 
@@ -240,16 +589,22 @@ func parseLongFlag(
 	i int,
 	set bool,
 ) (shiftNext bool, err error) {
-	name, value, hasValue := strings.Cut(args[i][2:], "=")
+	name, value, hasValue := strings.Cut(args[i][2:], opts.assignSep())
 
 	// name MUST not be empty
 	if len(name) == 0 {
-		// defensive check, should never happen as the caller always checks the size.
-		panic("unexpected empty arg name")
+		// e.g. `--=value`
+		return false, &ErrMalformedFlag{Arg: args[i], At: i}
 	}
 
 	f, ok := flags.FindFlag(name)
 	if !ok {
+		if opts != nil && opts.BoolNegation && !hasValue && strings.HasPrefix(name, "no-") {
+			if f, ok = flags.FindFlag(name[3:]); ok && isBoolFlag(f) {
+				return false, f.Decode(opts, name[3:], "false", set)
+			}
+		}
+
 		return false, &ErrFlagUndefined{
 			Name: name,
 			At:   i,
@@ -272,6 +627,14 @@ func parseLongFlag(
 	}
 
 	// --foo case
+	if opts != nil && opts.RequireEqForLongValues {
+		if _, ok = f.ImplyValue(); !ok {
+			return false, &ErrFlagValueRequiresEq{Name: name, At: i}
+		}
+
+		goto TryImplied
+	}
+
 	if i == len(args)-1 || args[i+1] == "--" /* never use standalone dash as value */ {
 		// when not having arg value, it MUST be an implicit arg
 		goto TryImplied
@@ -347,7 +710,14 @@ func parseShortFlags(
 		ok       bool
 	)
 
-	s, value, hasValue := strings.Cut(args[i][1:], "=")
+	s := args[i][1:]
+	if opts == nil || !opts.PosixStrict {
+		s, value, hasValue = strings.Cut(s, opts.assignSep())
+		if len(s) == 0 {
+			// e.g. `-=value`
+			return false, &ErrMalformedFlag{Arg: args[i], At: i}
+		}
+	}
 
 	for sz = len(s); offset < sz; {
 		_, width = utf8.DecodeRuneInString(s[offset:])
@@ -485,3 +855,9 @@ func parseShortFlags(
 
 	return false, nil
 }
+
+// isBoolFlag returns true if the flag is of type bool.
+func isBoolFlag(f Flag) bool {
+	typ, ok := f.Type()
+	return ok && typ == "bool"
+}
@@ -7,7 +7,9 @@ import (
 	"io"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,7 +22,34 @@ type FlagReflect struct {
 	BriefUsage   string
 	DefaultValue string
 	Comp         []string
+	Examples     []string
 	State_       FlagState
+	Source_      ValueSource
+
+	// Invert makes the flag imply `false` instead of `true` for bool fields.
+	Invert bool
+
+	// Min and Max are the inclusive bounds of numeric values, in the same
+	// format as the flag value (e.g. `1MB` for size values), empty string
+	// means no bound.
+	Min, Max string
+
+	// bounds are Min and Max parsed as the flag value, a bound is invalid
+	// when not set or not parsed yet.
+	bounds [2]reflect.Value
+
+	// Metavar is the placeholder of the flag value shown in help (e.g.
+	// `FILE` in `--output FILE`), empty string means the value type.
+	Metavar string
+
+	// Normalize are normalizations applied in order to the arg before
+	// parsing, it can contain `lower`, `upper` and `trim` (trims leading and
+	// trailing white spaces).
+	Normalize []string
+
+	// initial is the value before the flag was first set, restored by
+	// ResetFlag when valid.
+	initial reflect.Value
 }
 
 func (f *FlagReflect) Type() (string, bool) {
@@ -29,6 +58,10 @@ func (f *FlagReflect) Type() (string, bool) {
 }
 
 func (f *FlagReflect) ImplyValue() (string, bool) {
+	if f.Invert {
+		return "false", true
+	}
+
 	return implyFromVPType(f.VP.Type())
 }
 
@@ -38,11 +71,38 @@ func (f *FlagReflect) State() FlagState { return f.State_ }
 func (f *FlagReflect) Default() string  { return f.DefaultValue }
 func (f *FlagReflect) Usage() string    { return f.BriefUsage }
 
+// FlagMetavar implements [FlagMetavarer].
+func (f *FlagReflect) FlagMetavar() string { return f.Metavar }
+
+// FlagExamples implements [FlagExampler].
+func (f *FlagReflect) FlagExamples() []string { return f.Examples }
+
+// ValueSource implements [ValueSourcer].
+func (f *FlagReflect) ValueSource() ValueSource { return f.Source_ }
+
+// SetValueSource implements [ValueSourcer].
+func (f *FlagReflect) SetValueSource(src ValueSource) { f.Source_ = src }
+
 func (f *FlagReflect) PrintValue(out io.Writer) (int, error) {
 	return f.VP.PrintValue(out, &f.Value)
 }
 
 func (f *FlagReflect) Decode(opts *ParseOptions, name, arg string, set bool) error {
+	for _, n := range f.Normalize {
+		arg = normalizeArg(n, arg)
+	}
+
+	if len(f.Min) != 0 || len(f.Max) != 0 {
+		err := f.checkRange(opts, name, arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if set && !f.initial.IsValid() && f.Value.IsValid() {
+		f.initial = cloneValue(f.Value)
+	}
+
 	err := f.VP.ParseValue(opts, arg, &f.Value, set)
 	if err != nil {
 		return err
@@ -50,16 +110,257 @@ func (f *FlagReflect) Decode(opts *ParseOptions, name, arg string, set bool) err
 
 	if set {
 		f.State_ |= FlagStateValueChanged
+		f.Source_ = ValueSourceArg
+	}
+
+	return nil
+}
+
+// normalizeArg returns arg normalized as `lower`, `upper` or `trim`, it
+// returns arg unchanged for unknown normalization.
+func normalizeArg(normalize, arg string) string {
+	switch normalize {
+	case "lower":
+		return strings.ToLower(arg)
+	case "upper":
+		return strings.ToUpper(arg)
+	case "trim":
+		return strings.TrimSpace(arg)
+	default:
+		return arg
+	}
+}
+
+// checkRange returns ErrValueOutOfRange if arg is out of the range of
+// [f.Min, f.Max].
+func (f *FlagReflect) checkRange(opts *ParseOptions, name, arg string) error {
+	got, err := f.parseNumber(opts, arg)
+	if err != nil {
+		return err
+	}
+
+	// flags created by ReflectIndexer have bounds parsed already.
+	err = f.parseBounds(opts)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range f.bounds {
+		if !b.IsValid() {
+			continue
+		}
+
+		if cmp := compareNumbers(got, b); (i == 0 && cmp < 0) || (i == 1 && cmp > 0) {
+			return &ErrValueOutOfRange{
+				Name: name,
+				Min:  f.Min,
+				Max:  f.Max,
+				Got:  arg,
+			}
+		}
 	}
 
 	return nil
 }
 
+// parseBounds parses f.Min and f.Max into f.bounds if not parsed yet.
+func (f *FlagReflect) parseBounds(opts *ParseOptions) (err error) {
+	for i, bound := range [2]string{f.Min, f.Max} {
+		if len(bound) == 0 || f.bounds[i].IsValid() {
+			continue
+		}
+
+		f.bounds[i], err = f.parseNumber(opts, bound)
+		if err != nil {
+			f.bounds[i] = reflect.Value{}
+			return
+		}
+	}
+
+	return
+}
+
+// parseNumber parses arg into a temporary value without touching f.Value.
+func (f *FlagReflect) parseNumber(opts *ParseOptions, arg string) (reflect.Value, error) {
+	tmp := reflect.New(noptr(f.Value.Type())).Elem()
+	err := f.VP.ParseValue(opts, arg, noescape(&tmp), true)
+	return tmp, err
+}
+
+// compareNumbers returns -1, 0 or 1 when a is less than, equal to or
+// greater than b, a and b MUST be of the same numeric kind.
+func compareNumbers(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, y := a.Int(), b.Int()
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, y := a.Uint(), b.Uint()
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// ResetFlag implements [FlagResetter].
+func (f *FlagReflect) ResetFlag() {
+	if f.initial.IsValid() && f.Value.CanSet() {
+		f.Value.Set(cloneValue(f.initial))
+	}
+
+	f.State_ &^= FlagStateValueChanged
+	f.Source_ = ValueSourceUnset
+}
+
 // Suggest implements [CompAction].
-func (f *FlagReflect) Suggest(tsk *CompTask) (added int, _ CompState) {
+//
+// For map fields, see suggestMapEntry.
+//
+// Without any completion value, it suggests the Min and Max as hints if set,
+// otherwise according to the flag type like CompTask.AddFlagValues does.
+func (f *FlagReflect) Suggest(tsk *CompTask) (added int, state CompState) {
+	if f.VP.Type()&VPTypeVariantMASK == VPTypeVariantMap {
+		return f.suggestMapEntry(tsk)
+	}
+
+	if len(f.Comp) == 0 {
+		if c, ok := f.VP.(VPChoices); ok {
+			if choices := c.ValueChoices(); len(choices) != 0 {
+				for _, v := range choices {
+					added += tsk.AddMatched(false, CompItem{
+						Value: v,
+						Kind:  CompKindFlagValue,
+					})
+				}
+
+				return
+			}
+		}
+
+		if len(f.Min) != 0 || len(f.Max) != 0 {
+			return f.suggestBounds(tsk), 0
+		}
+
+		typ, _ := f.Type()
+		if comp := compActionForType(typ); comp != nil {
+			return comp.Suggest(tsk)
+		}
+
+		return
+	}
+
+	var (
+		files, dirs       []string
+		hasFiles, hasDirs bool
+	)
 	for _, v := range f.Comp {
+		switch kind, glob, _ := strings.Cut(v, ":"); kind {
+		case "@files":
+			hasFiles, files = true, append(files, glob)
+		case "@dirs":
+			hasDirs, dirs = true, append(dirs, glob)
+		default:
+			added += tsk.AddMatched(false, CompItem{
+				Value: v,
+				Kind:  CompKindFlagValue,
+			})
+		}
+	}
+
+	if hasFiles {
+		added += tsk.AddFiles(false, files...)
+	}
+
+	if hasDirs {
+		added += tsk.AddDirs(false, dirs...)
+	}
+
+	return
+}
+
+// suggestBounds suggests the Min and Max as hints of numeric values.
+func (f *FlagReflect) suggestBounds(tsk *CompTask) (added int) {
+	if len(f.Min) != 0 {
+		added += tsk.AddMatched(false, CompItem{
+			Value:       f.Min,
+			Description: "min value",
+			Kind:        CompKindFlagValue,
+		})
+	}
+
+	if len(f.Max) != 0 && f.Max != f.Min {
+		added += tsk.AddMatched(false, CompItem{
+			Value:       f.Max,
+			Description: "max value",
+			Kind:        CompKindFlagValue,
+		})
+	}
+
+	return
+}
+
+// suggestMapEntry completes the key part of `<key>=<value>` when there is no
+// `=` in tsk.ToComplete, otherwise the value part.
+//
+// Each completion value is either `<key>` or `<key>=<value>`, for boolean map
+// values, `true` and `false` are always suggested after the `=`.
+func (f *FlagReflect) suggestMapEntry(tsk *CompTask) (added int, state CompState) {
+	key, _, hasValue := strings.Cut(tsk.ToComplete, "=")
+	if !hasValue {
+	Keys:
+		for i, v := range f.Comp {
+			k, _, _ := strings.Cut(v, "=")
+			for _, prev := range f.Comp[:i] {
+				if p, _, _ := strings.Cut(prev, "="); p == k {
+					continue Keys
+				}
+			}
+
+			added += tsk.AddMatched(false, CompItem{
+				Value: k + "=",
+				Kind:  CompKindFlagValue,
+			})
+		}
+
+		if added != 0 {
+			// do not add space after `=`
+			state = CompStateOptionNospace
+		}
+
+		return
+	}
+
+	for _, v := range f.Comp {
+		if k, _, ok := strings.Cut(v, "="); ok && k == key {
+			added += tsk.AddMatched(false, CompItem{
+				Value: v,
+				Kind:  CompKindFlagValue,
+			})
+		}
+	}
+
+	typ := f.VP.Type()
+	if typ&VPTypeMapElemVariantMASK == 0 && typ&VPTypeElemScalarMASK == VPTypeBool {
 		added += tsk.AddMatched(false, CompItem{
-			Value: v,
+			Value: key + "=true",
+			Kind:  CompKindFlagValue,
+		}, CompItem{
+			Value: key + "=false",
 			Kind:  CompKindFlagValue,
 		})
 	}
@@ -93,15 +394,50 @@ func (e *ErrUnsupportedType) Error() string {
 	return ""
 }
 
+// ReflectVPTypeLister is an optional interface for ReflectVPFactory to list
+// all `value=<type>` and `key=<type>` values it recognizes.
+//
+// When ReflectIndexer.CheckTypes is true and its Factory implements it,
+// unknown types in struct tags are reported as soon as the tag is indexed.
+type ReflectVPTypeLister interface {
+	SupportedTypes() []string
+}
+
 // DefaultReflectVPFactory is the ReflectVPFactory implementation referenced
 // from comments of ReflectIndexer.
-type DefaultReflectVPFactory struct{}
+type DefaultReflectVPFactory struct {
+	// DurationTypes are named types based on time.Duration (e.g.
+	// `type Timeout time.Duration`) decoded as `dur` without option `value`,
+	// like time.Duration itself.
+	//
+	// Reflection cannot tell such types from other int64 types, thus they
+	// MUST be listed here to be detected, types not convertible to
+	// time.Duration are ignored.
+	DurationTypes []reflect.Type
+}
+
+// SupportedTypes implements ReflectVPTypeLister.
+func (DefaultReflectVPFactory) SupportedTypes() []string {
+	return []string{
+		"size", "dur", "dur-csv", "endpoint-csv", "sum", "ssum", "dsum", "range", "tristate", "flagset", "quantity",
+		"regexp", "regexp-nocase", "secret-src", "email", "glob", "semver",
+		"time", "unix-ts", "unix-ms", "unix-us", "unix-ns",
+	}
+}
 
-func (DefaultReflectVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType, valueType string) (vp VP[*reflect.Value], err error) {
+func (f DefaultReflectVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType, valueType string) (vp VP[*reflect.Value], err error) {
 	ft := noptr(fieldType)
 	switch ft.Kind() {
 	case reflect.Map:
-		kp := getScalarOrSliceVP(keyType, ft.Key(), false)
+		if valueType == "flagset" {
+			if len(keyType) == 0 && ft.Key().Kind() == reflect.String && ft.Elem().Kind() == reflect.Bool {
+				vp = VPReflectStringSetBool{}
+			}
+
+			break
+		}
+
+		kp := getScalarOrSliceVP(f.durationHint(keyType, ft.Key()), ft.Key(), false)
 		if kp == nil {
 			break
 		}
@@ -109,14 +445,14 @@ func (DefaultReflectVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType,
 		rawVt := ft.Elem()
 		vt := noptr(rawVt)
 		if vt.Kind() == reflect.Slice {
-			if svp := getScalarOrSliceVP(valueType, vt.Elem(), true); svp != nil {
+			if svp := getScalarOrSliceVP(f.durationHint(valueType, vt.Elem()), vt.Elem(), true); svp != nil {
 				vp = &VPReflectMap[VP[*reflect.Value], VP[*reflect.Value]]{
 					Key:  kp,
 					Elem: svp,
 				}
 			}
 		} else {
-			if svp := getScalarOrSliceVP(valueType, rawVt, false); svp != nil {
+			if svp := getScalarOrSliceVP(f.durationHint(valueType, rawVt), rawVt, false); svp != nil {
 				vp = &VPReflectMap[VP[*reflect.Value], VP[*reflect.Value]]{
 					Key:  kp,
 					Elem: svp,
@@ -125,9 +461,9 @@ func (DefaultReflectVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType,
 		}
 
 	case reflect.Slice:
-		vp = getScalarOrSliceVP(valueType, ft.Elem(), true)
+		vp = getScalarOrSliceVP(f.durationHint(valueType, ft.Elem()), ft.Elem(), true)
 	default:
-		vp = getScalarOrSliceVP(valueType, fieldType, false)
+		vp = getScalarOrSliceVP(f.durationHint(valueType, fieldType), fieldType, false)
 	}
 
 	if vp == nil {
@@ -141,6 +477,23 @@ func (DefaultReflectVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType,
 	return vp, nil
 }
 
+// durationHint returns `dur` if req is empty and typ (or the type it points
+// to) is one of f.DurationTypes, otherwise it returns req.
+func (f DefaultReflectVPFactory) durationHint(req string, typ reflect.Type) string {
+	if len(req) != 0 || len(f.DurationTypes) == 0 {
+		return req
+	}
+
+	typ = noptr(typ)
+	for _, t := range f.DurationTypes {
+		if t == typ && typ.ConvertibleTo(durationType) {
+			return "dur"
+		}
+	}
+
+	return req
+}
+
 func getScalarOrSliceVP(req string, rawFt reflect.Type, slice bool) VP[*reflect.Value] {
 	ft := noptr(rawFt)
 	sum := strings.HasSuffix(req, "sum")
@@ -160,6 +513,22 @@ func getScalarOrSliceVP(req string, rawFt reflect.Type, slice bool) VP[*reflect.
 			return VPReflectSlice[VPReflectDuration]{}
 		}
 		return VPReflectDuration{}
+	case "dur-csv":
+		if !slice {
+			return nil
+		}
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			return nil
+		}
+		return VPReflectDurationCSV{}
+	case "endpoint-csv":
+		if !slice || rawFt != ft || !isEndpointStruct(ft) {
+			return nil
+		}
+		return VPReflectEndpointCSV{}
 	case "size", "ssum":
 		switch ft.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -174,6 +543,33 @@ func getScalarOrSliceVP(req string, rawFt reflect.Type, slice bool) VP[*reflect.
 			return VPReflectSlice[VPReflectSize]{}
 		}
 		return VPReflectSize{}
+	case "quantity":
+		if sum || ft.Kind() != reflect.Int64 {
+			return nil
+		}
+		if slice {
+			return VPReflectSlice[VPReflectQuantity]{}
+		}
+		return VPReflectQuantity{}
+	case "tristate":
+		if sum || ft.Kind() != reflect.Uint8 {
+			return nil
+		}
+		if slice {
+			return VPReflectSlice[VPReflectTriState]{}
+		}
+		return VPReflectTriState{}
+	case "range":
+		if !slice || rawFt != ft {
+			return nil
+		}
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			return nil
+		}
+		return VPReflectIntRange{}
 	case "unix-ts":
 		if sum || ft.Kind() != reflect.Int64 {
 			return nil
@@ -230,6 +626,38 @@ func getScalarOrSliceVP(req string, rawFt reflect.Type, slice bool) VP[*reflect.
 			return VPReflectSlice[VPReflectRegexpNocase]{}
 		}
 		return VPReflectRegexpNocase{}
+	case "secret-src":
+		if sum || ft.Kind() != reflect.String {
+			return nil
+		}
+		if slice {
+			return VPReflectSlice[VPReflectSecretSource]{}
+		}
+		return VPReflectSecretSource{}
+	case "email":
+		if sum || ft.Kind() != reflect.String {
+			return nil
+		}
+		if slice {
+			return VPReflectSlice[VPReflectEmail]{}
+		}
+		return VPReflectEmail{}
+	case "glob":
+		if sum || ft.Kind() != reflect.String {
+			return nil
+		}
+		if slice {
+			return VPReflectSlice[VPReflectGlob]{}
+		}
+		return VPReflectGlob{}
+	case "semver":
+		if sum || ft.Kind() != reflect.String {
+			return nil
+		}
+		if slice {
+			return VPReflectSlice[VPReflectSemver]{}
+		}
+		return VPReflectSemver{}
 	case "", "sum":
 	default:
 		return nil
@@ -255,6 +683,20 @@ func getScalarOrSliceVP(req string, rawFt reflect.Type, slice bool) VP[*reflect.
 			return VPReflectString{}
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if ft == durationType {
+			// named types of time.Duration (e.g. `type Timeout time.Duration`)
+			// are indistinguishable from int64 in reflection, see
+			// DefaultReflectVPFactory.DurationTypes.
+			switch {
+			case slice:
+				return VPReflectSlice[VPReflectDuration]{}
+			case sum:
+				return VPReflectSum[VPReflectDuration]{}
+			default:
+				return VPReflectDuration{}
+			}
+		}
+
 		switch {
 		case slice:
 			return VPReflectSlice[VPReflectInt]{}
@@ -286,6 +728,45 @@ func getScalarOrSliceVP(req string, rawFt reflect.Type, slice bool) VP[*reflect.
 	return nil
 }
 
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// cloneValue returns a copy of v, slices, maps and pointers are copied
+// recursively (elements of slices and maps are copied as is), so that setting
+// through v keeps the copy intact.
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+
+		return reflect.AppendSlice(reflect.MakeSlice(v.Type(), 0, v.Len()), v)
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+
+		ret := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			ret.SetMapIndex(iter.Key(), iter.Value())
+		}
+
+		return ret
+	case reflect.Pointer:
+		if v.IsNil() {
+			break
+		}
+
+		ret := reflect.New(v.Type().Elem())
+		ret.Elem().Set(cloneValue(v.Elem()))
+		return ret
+	}
+
+	ret := reflect.New(v.Type()).Elem()
+	ret.Set(v)
+	return ret
+}
+
 // noptr returns the first non-pointer type from typ.
 func noptr(typ reflect.Type) reflect.Type {
 	for typ.Kind() == reflect.Pointer {
@@ -324,6 +805,16 @@ func NewReflectIndexer(factory ReflectVPFactory, pStruct any) *ReflectIndexer {
 	}
 }
 
+// NewReflectIndexerWithDefaults is NewReflectIndexer but also sets
+// ReflectIndexer.Defaults for flags without `def` option in their tags.
+func NewReflectIndexerWithDefaults(
+	factory ReflectVPFactory, pStruct any, defaults map[string]string,
+) *ReflectIndexer {
+	r := NewReflectIndexer(factory, pStruct)
+	r.Defaults = defaults
+	return r
+}
+
 type ReflectFlagRef struct {
 	Field   int
 	Options string
@@ -340,21 +831,33 @@ type ReflectFlagRef struct {
 //
 // Struct field tag specification
 //
-//	`cli:"<long name>|<shorthand>[,comp=<completion>][,value=<type>][,key=<type>][,def=<default>][,hide][,once][,#<brief usage>]"`
+//	`cli:"<long name>|<shorthand>[,comp=<completion>][,value=<type>][,key=<type>][,def=<default>][,example=<arg>][,metavar=<name>][,hide][,once][,secret][,nonneg][,clear-on=<arg>][,invert][,min=<value>][,max=<value>][,dup=<policy>][,normalize=<kind>][,rule=<rule>][,#<brief usage>]"`
 //
 // Text before the first comma (',') is interpreted as flag name section, it
 // SHOULD contain at most two names (one long name and one shorthand),
-// use pipe ('|') to separate names.
+// use pipe ('|') to separate names. A one-rune name is a shorthand, thus a
+// tag with two one-rune names (e.g. `x|y`) is invalid and causes panic.
 //
 // Text after the first comma and before the sharp ('#') is interpreted as
-// flag options, currently there are six options available:
+// flag options, currently there are seventeen options available:
 //
 //   - comp=<completion>
 //   - value=<type>
 //   - key=<type>
 //   - def=<value>
+//   - example=<arg>
+//   - metavar=<name>
 //   - hide
 //   - once
+//   - secret
+//   - nonneg
+//   - clear-on=<arg>
+//   - invert
+//   - min=<value>
+//   - max=<value>
+//   - dup=<policy>
+//   - normalize=<kind>
+//   - rule=<rule>
 //
 // Option `comp` defines completion values, multiple `comp` option creates
 // multiple CompItems, for example:
@@ -368,23 +871,47 @@ type ReflectFlagRef struct {
 //   - value1
 //   - value2
 //
+// Special values `@files` and `@dirs` request filesystem completion of files
+// and dirs, optionally with a glob pattern after a colon (e.g.
+// `comp=@files:*.yaml`).
+//
+// For map fields, `comp` option can be either `comp=<key>` or
+// `comp=<key>=<value>`, keys are suggested before the `=` and values of the
+// matched key are suggested after it.
+//
 // Option `value` is used to change the method used to decode text arg and
 // can have one of following `<type>` values when using
 // DefaultReflectVPFactory:
 //
 //   - size     (size value, example command-line arg: "1TB", "1g1M")
 //   - dur      (duration value, example command-line arg: "1yr", "1m10s")
+//   - dur-csv  (comma-separated durations, only for slice fields, example command-line arg: "1s,2s,5s")
+//   - endpoint-csv (comma-separated `host:port` pairs, only for slices of structs with `Host` and `Port` fields (e.g. []Endpoint), example command-line arg: "host1:8080,host2:9090")
 //   - sum      (sums numeric values)
 //   - ssum     (sums size values)
 //   - dsum     (sums duration values)
+//   - range    (integer ranges, only for slices of integers, example command-line arg: "0-3,5,7-8", overlapping ranges are rejected unless a custom ReflectVPFactory returns VPReflectIntRange{AllowOverlap: true})
+//   - tristate (on/off/auto for TriState fields, example command-line arg: "on", "auto")
+//   - flagset  (comma-separated keys for map[string]bool fields, example command-line arg: "a,b,-c")
+//   - quantity (Kubernetes style quantity in milli-units for int64 fields, example command-line arg: "100m", "2Gi")
 //   - regexp
 //   - regexp-nocase
+//   - secret-src (read secret from `env:<VAR>`, `file:<path>` or `-` (stdin), value is printed redacted)
+//   - email    (email address, stored without display name, example command-line arg: "a@example.com", "Name <a@example.com>")
+//   - glob     (glob pattern validated by filepath.Match, example command-line arg: "*.go", "[a-c]?.txt")
+//   - semver   (semantic version stored without `v` prefix, example command-line arg: "1.2.3", "v1.2.3-rc.1+build.5")
 //   - time    (decode time string, example command-line arg: "15:00", "21")
 //   - unix-ts (decode time string to seconds since the unix epoch)
 //   - unix-ms (decode time string to milliseconds since the unix epoch)
 //   - unix-us (decode time string to microseconds since the unix epoch)
 //   - unix-ns (decode time string to nanoseconds since the unix epoch)
 //
+// Fields of type time.Duration (including slices and maps of it) are
+// decoded as `dur` without option `value`, so are named types based on it
+// (e.g. `type Timeout time.Duration`) listed in
+// DefaultReflectVPFactory.DurationTypes, other named types require
+// `value=dur`.
+//
 // Option `value`'s meaning varies depending on the field type:
 //
 //   - scalar field: for that scalar field (e.g. `value=dur` for int64)
@@ -405,7 +932,17 @@ type ReflectFlagRef struct {
 // Both option `value` and option `key` can present at most once in the tag value.
 //
 // Option `def` defines a default value for the flag when flag is not set.
-// There can be multiple `def` options.
+// There can be multiple `def` options. A value containing commas can be
+// double-quoted (e.g. `def="a,b"`). It takes precedence over
+// ReflectIndexer.Defaults.
+//
+// Option `example` adds an example flag value shown in help (e.g.
+// `example=10MB/s` for flag `--rate` is shown as `e.g. --rate 10MB/s`).
+// There can be multiple `example` options.
+//
+// Option `metavar` defines the placeholder of the flag value shown in help
+// instead of the value type (e.g. `metavar=FILE` for flag `--output` is shown
+// as `--output FILE`).
 //
 // Option `hide` marks the FlagState with FlagStateHidden. There can be no
 // more than one `hide` option.
@@ -413,10 +950,53 @@ type ReflectFlagRef struct {
 // Option `once` marks the FlagState with FlagStateSetAtMostOnce. There can be
 // no more than one `once` option.
 //
+// Option `secret` marks the FlagState with FlagStateSecret to keep its default
+// value out of help and completion. There can be no more than one `secret`
+// option.
+//
+// Option `nonneg` rejects negative values, it is only valid for scalar
+// numeric fields (e.g. `value=dur` for time.Duration).
+//
+// Option `clear-on` defines a sentinel arg which resets the slice field to
+// empty instead of being appended (e.g. `clear-on=none` makes `--exclude=none`
+// clear all default values), it is only valid for slice fields.
+//
+// Option `invert` makes the presence of the flag set the bool field to false
+// (e.g. `cli:"disable-cache,invert"` for field `EnableCache bool`), an
+// explicit value (e.g. `--disable-cache=true`) is assigned to the field as
+// is. It is only valid for bool fields.
+//
+// Option `min` and `max` define inclusive bounds of scalar numeric values
+// (e.g. `cli:"port,min=1,max=65535"`), bounds are in the same format as the
+// flag value (e.g. `max=1GB` for `value=size`). Out of range values are
+// rejected with ErrValueOutOfRange.
+//
+// Option `dup` defines how a map field handles a key already present in the
+// map, it can be one of `last` (default, overwrite the existing value),
+// `first` (keep the existing value) and `error` (reject with
+// ErrDuplicateMapKey). It is only valid for map fields.
+//
+// Option `normalize` normalizes the arg before parsing, it can be one of
+// `lower`, `upper` and `trim` (trims leading and trailing white spaces).
+// There can be multiple `normalize` options, they are applied in order (e.g.
+// `cli:"format,normalize=trim,normalize=lower"` stores ` JSON` as `json`).
+//
+// Option `rule` declares a Rule of flags, it is not used by the flag itself
+// but collected by ReflectIndexer.FlagRule. Its value is in format
+// `<kind>:<key>|<key>...` where `<kind>` is one of `oneof`, `allof`, `anyof`
+// and `allornone` (e.g. `rule=oneof:json|yaml`). There can be multiple `rule`
+// options, they can also be defined on a blank field (e.g. field `_ struct{}`
+// with tag value `,rule=allof:user|password`).
+//
 // The remaining text after the sharp sign ('#') after the first comma, is
 // interpreted as the brief usage of the flag.
 //
 // NOTE: Unexported fields and fields without a `cli` tag value are ignored.
+//
+// Thread-safety: FindFlag and NthFlag are safe for concurrent use, the lazy
+// indexing they perform is guarded by an internal mutex. Exported fields
+// SHOULD NOT be modified once the ReflectIndexer is shared, and a
+// ReflectIndexer MUST NOT be copied after first use.
 type ReflectIndexer struct {
 	// StructV is the reflect value of the addressable struct.
 	StructV reflect.Value
@@ -436,13 +1016,51 @@ type ReflectIndexer struct {
 	// When < 0: no flag
 	// When > 0: n flags, len(Refs) = TotalFlags
 	TotalFlags int
+
+	// Preindex makes the first call to FindFlag or NthFlag index all fields
+	// at once instead of scanning fields lazily on each cache miss.
+	//
+	// It is useful when the same flags are looked up repeatedly.
+	Preindex bool
+
+	// CheckTypes makes unknown `value=<type>` and `key=<type>` options panic
+	// as soon as the tag is indexed, it requires the Factory to implement
+	// ReflectVPTypeLister and has no effect otherwise.
+	//
+	// Factories embedding DefaultReflectVPFactory to add custom types MUST
+	// override SupportedTypes to list them before enabling it.
+	CheckTypes bool
+
+	// Defaults maps flag names to default values of flags without the `def`
+	// option, the value uses the same format as FlagInfo.DefaultValue.
+	//
+	// When a flag has multiple names in Defaults, the first one in its tag
+	// wins.
+	Defaults map[string]string
+
+	// mu guards lazy indexing of Names, Refs and TotalFlags.
+	mu sync.Mutex
 }
 
 func (r *ReflectIndexer) FindFlag(s string) (Flag, bool) {
-	if r == nil || r.TotalFlags < 0 {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.findFlag(s)
+}
+
+func (r *ReflectIndexer) findFlag(s string) (Flag, bool) {
+	if r.TotalFlags < 0 {
 		return nil, false
 	}
 
+	if r.Preindex && r.TotalFlags == 0 {
+		r.indexAll()
+	}
+
 	if r.Names != nil {
 		i, ok := r.Names[s]
 		if ok {
@@ -495,10 +1113,24 @@ func (r *ReflectIndexer) FindFlag(s string) (Flag, bool) {
 }
 
 func (r *ReflectIndexer) NthFlag(i int) (FlagInfo, bool) {
-	if r == nil || r.TotalFlags < 0 {
+	if r == nil {
 		return FlagInfo{}, false
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.nthFlag(i)
+}
+
+func (r *ReflectIndexer) nthFlag(i int) (FlagInfo, bool) {
+	if r.TotalFlags < 0 {
+		return FlagInfo{}, false
+	}
+
+	if r.Preindex && r.TotalFlags == 0 {
+		r.indexAll()
+	}
+
 	if i < len(r.Refs) {
 		return r.Refs[i].Info, true
 	}
@@ -553,11 +1185,63 @@ func (r *ReflectIndexer) NthFlag(i int) (FlagInfo, bool) {
 	return FlagInfo{}, false
 }
 
+// indexAll creates refs for all remaining fields with `cli` tag and sets
+// r.TotalFlags.
+func (r *ReflectIndexer) indexAll() {
+	typ := r.StructV.Type()
+	totalFlags := 0
+	for fieldIndex, n := 0, typ.NumField(); fieldIndex < n; fieldIndex++ {
+		f := typ.Field(fieldIndex)
+		if !f.IsExported() {
+			continue
+		}
+
+		pos := indexTag(string(f.Tag), "cli")
+		if pos < 0 {
+			continue
+		}
+
+		if totalFlags >= len(r.Refs) { // not checked
+			ref, _ := r.createRefFromTag(fieldIndex, f.Tag[pos:].Get("cli"), totalFlags, "")
+			r.Refs = append(r.Refs, ref)
+		}
+
+		totalFlags++
+	}
+
+	if totalFlags == 0 {
+		r.TotalFlags = -1
+	} else {
+		r.TotalFlags = totalFlags
+	}
+}
+
+// supportsType returns false if r.CheckTypes is true, the Factory implements
+// ReflectVPTypeLister and the typ is not in its supported types.
+func (r *ReflectIndexer) supportsType(typ string) bool {
+	if !r.CheckTypes || len(typ) == 0 {
+		return true
+	}
+
+	lister, ok := r.Factory.(ReflectVPTypeLister)
+	if !ok {
+		return true
+	}
+
+	for _, t := range lister.SupportedTypes() {
+		if t == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *ReflectIndexer) createRefFromTag(
 	fieldIndex int, tag string, flagIndex int, matchName string,
 ) (ref ReflectFlagRef, nameMatch bool) {
-	opt, tag, _ := strings.Cut(tag, ",")
-	for len(opt) > 0 {
+	names, tag, _ := strings.Cut(tag, ",")
+	for opt := names; len(opt) > 0; {
 		var name string
 		name, opt, _ = strings.Cut(opt, "|")
 		if len(name) == 0 {
@@ -570,12 +1254,16 @@ func (r *ReflectIndexer) createRefFromTag(
 		r.Names[name] = flagIndex
 
 		if IsShorthand(name) {
-			if len(ref.Info.Shorthand) == 0 {
-				ref.Info.Shorthand = name
+			if len(ref.Info.Shorthand) != 0 {
+				panic("invalid multiple shorthands `" + ref.Info.Shorthand + "` and `" + name + "`")
 			}
+
+			ref.Info.Shorthand = name
 		} else {
 			if len(ref.Info.Name) == 0 {
 				ref.Info.Name = name
+			} else {
+				ref.Info.Aliases = append(ref.Info.Aliases, name)
 			}
 		}
 
@@ -594,20 +1282,26 @@ func (r *ReflectIndexer) createRefFromTag(
 	tag, _, _ = strings.Cut(tag, "#")
 	for len(tag) != 0 {
 		var opt string
-		opt, tag, _ = strings.Cut(tag, ",")
+		opt, tag = cutTagOption(tag)
 
 		key, value, _ := strings.Cut(opt, "=")
 		switch key {
-		case "comp", "value", "key": // used when creating flag
+		case "value", "key":
+			if !r.supportsType(value) {
+				panic("unsupported type: " + opt)
+			}
+		case "comp", "nonneg", "example", "clear-on", "invert", "min", "max", "dup", "normalize": // used when creating flag
+		case "rule": // used by FlagRule
 		case "def":
+			value = unquoteTagValue(value)
 			if defs.Len() != 0 {
 				defs.WriteString(", ")
-				defs.WriteString(value)
+				defs.WriteString(quoteDefaultEntry(value))
 			} else if len(def) != 0 {
 				defs.WriteByte('[')
-				defs.WriteString(def)
+				defs.WriteString(quoteDefaultEntry(def))
 				defs.WriteString(", ")
-				defs.WriteString(value)
+				defs.WriteString(quoteDefaultEntry(value))
 			} else {
 				def = value
 			}
@@ -623,19 +1317,112 @@ func (r *ReflectIndexer) createRefFromTag(
 			}
 
 			ref.Info.State |= FlagStateSetAtMostOnce
+		case "secret":
+			if ref.Info.State&FlagStateSecret != 0 {
+				panic("invalid duplicate `secret` option")
+			}
+
+			ref.Info.State |= FlagStateSecret
 		}
 	}
 
 	if defs.Len() != 0 {
 		defs.WriteByte(']')
 		ref.Info.DefaultValue = defs.String()
-	} else {
+	} else if len(def) != 0 {
 		ref.Info.DefaultValue = def
+	} else {
+		ref.Info.DefaultValue = r.lookupDefault(names)
 	}
 
 	return
 }
 
+// FlagRule returns Rules defined by `rule` options in `cli` tags of all
+// fields (including blank fields) merged with MergeFlagRules, it is meant to
+// be used as (or merged into) Cmd.FlagRule.
+//
+// It panics if there is invalid `rule` option.
+func (r *ReflectIndexer) FlagRule() Rule {
+	var rules []Rule
+
+	typ := r.StructV.Type()
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		f := typ.Field(i)
+		if !f.IsExported() && f.Name != "_" {
+			continue
+		}
+
+		pos := indexTag(string(f.Tag), "cli")
+		if pos < 0 {
+			continue
+		}
+
+		_, options, _ := strings.Cut(f.Tag[pos:].Get("cli"), ",")
+		options, _, _ = strings.Cut(options, "#")
+		for len(options) != 0 {
+			var opt string
+			opt, options = cutTagOption(options)
+
+			key, value, _ := strings.Cut(opt, "=")
+			if key == "rule" {
+				rules = append(rules, parseTagRule(value))
+			}
+		}
+	}
+
+	return MergeFlagRules(rules...)
+}
+
+// parseTagRule creates a Rule from the value of the `rule` tag option.
+func parseTagRule(value string) Rule {
+	kind, list, _ := strings.Cut(value, ":")
+
+	var keys []string
+	for len(list) != 0 {
+		var key string
+		key, list, _ = strings.Cut(list, "|")
+		if len(key) != 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		panic("invalid `rule` option without flag names: " + value)
+	}
+
+	switch kind {
+	case "oneof":
+		return OneOf(keys...)
+	case "allof":
+		return AllOf(keys...)
+	case "anyof":
+		return AnyOf(keys...)
+	case "allornone":
+		return AllOrNone(keys...)
+	default:
+		panic("invalid `rule` option: " + value)
+	}
+}
+
+// lookupDefault returns the value of the first name in the `|` separated
+// names found in r.Defaults.
+func (r *ReflectIndexer) lookupDefault(names string) string {
+	if len(r.Defaults) == 0 {
+		return ""
+	}
+
+	for len(names) != 0 {
+		var name string
+		name, names, _ = strings.Cut(names, "|")
+		if def, ok := r.Defaults[name]; ok {
+			return def
+		}
+	}
+
+	return ""
+}
+
 // indexTag returns the offset of the key in tag, if there is no such key
 // in tag, return -1.
 func indexTag(tag, key string) int {
@@ -681,15 +1468,24 @@ func (r *ReflectIndexer) getFieldFlag(ref int) Flag {
 	}
 
 	var (
-		comp []string
+		comp     []string
+		examples []string
+		metavar  string
 
 		keyType, valueType string
+		clearOn            string
+		minValue, maxValue string
+		dup                string
+		normalize          []string
+		nonneg             bool
+		hasClearOn         bool
+		invert             bool
 	)
 
 	options, usage, _ := strings.Cut(r.Refs[ref].Options, "#")
 	for len(options) != 0 {
 		var opt string
-		opt, options, _ = strings.Cut(options, ",")
+		opt, options = cutTagOption(options)
 
 		key, value, _ := strings.Cut(opt, "=")
 		switch key {
@@ -697,6 +1493,12 @@ func (r *ReflectIndexer) getFieldFlag(ref int) Flag {
 			if len(value) != 0 {
 				comp = append(comp, value)
 			}
+		case "example":
+			if len(value) != 0 {
+				examples = append(examples, value)
+			}
+		case "metavar":
+			metavar = value
 		case "value":
 			if len(valueType) != 0 {
 				panic("invalid multiple value types: " + opt)
@@ -707,7 +1509,30 @@ func (r *ReflectIndexer) getFieldFlag(ref int) Flag {
 				panic("invalid multiple key types: " + opt)
 			}
 			keyType = value
-		case "def", "hide", "once": // reuse value in FlagInfo
+		case "nonneg":
+			nonneg = true
+		case "clear-on":
+			if hasClearOn {
+				panic("invalid multiple clear-on options: " + opt)
+			}
+			clearOn, hasClearOn = value, true
+		case "invert":
+			invert = true
+		case "min":
+			minValue = value
+		case "max":
+			maxValue = value
+		case "dup":
+			dup = value
+		case "normalize":
+			switch value {
+			case "lower", "upper", "trim":
+			default:
+				panic("invalid `normalize` option: " + value)
+			}
+			normalize = append(normalize, value)
+		case "def", "hide", "once", "secret": // reuse value in FlagInfo
+		case "rule": // used by FlagRule
 		default:
 			// TODO: panic on unknown option?
 		}
@@ -724,13 +1549,115 @@ func (r *ReflectIndexer) getFieldFlag(ref int) Flag {
 		panic("unsupported field type: " + fieldType.String())
 	}
 
+	if nonneg {
+		switch noptr(fieldType).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64:
+		default:
+			panic("invalid `nonneg` option for non-numeric field type: " + fieldType.String())
+		}
+
+		vp = VPReflectNonNeg[VP[*reflect.Value]]{VP: vp}
+	}
+
+	if hasClearOn {
+		if noptr(fieldType).Kind() != reflect.Slice {
+			panic("invalid `clear-on` option for non-slice field type: " + fieldType.String())
+		}
+
+		vp = VPReflectClearOn[VP[*reflect.Value]]{VP: vp, Sentinel: clearOn}
+	}
+
+	if len(dup) != 0 {
+		m, ok := vp.(*VPReflectMap[VP[*reflect.Value], VP[*reflect.Value]])
+		if !ok {
+			panic("invalid `dup` option for non-map field type: " + fieldType.String())
+		}
+
+		switch dup {
+		case "last":
+			m.Dup = MapDupLast
+		case "first":
+			m.Dup = MapDupFirst
+		case "error":
+			m.Dup = MapDupError
+		default:
+			panic("invalid `dup` option: " + dup)
+		}
+	}
+
+	if invert && noptr(fieldType).Kind() != reflect.Bool {
+		panic("invalid `invert` option for non-bool field type: " + fieldType.String())
+	}
+
 	r.Refs[ref].Flag = &FlagReflect{
 		VP:           vp,
 		Value:        r.StructV.Field(fieldIdx),
 		BriefUsage:   usage,
 		DefaultValue: r.Refs[ref].Info.DefaultValue,
 		Comp:         comp,
+		Examples:     examples,
+		Metavar:      metavar,
 		State_:       r.Refs[ref].Info.State,
+		Invert:       invert,
+		Min:          minValue,
+		Max:          maxValue,
+		Normalize:    normalize,
 	}
+
+	if len(minValue) != 0 || len(maxValue) != 0 {
+		switch vp.Type() {
+		case VPTypeInt, VPTypeUint, VPTypeFloat, VPTypeSize, VPTypeDuration:
+		default:
+			panic("invalid `min`/`max` option for non-numeric field type: " + fieldType.String())
+		}
+
+		err = r.Refs[ref].Flag.parseBounds(nil)
+		if err != nil {
+			panic("invalid `min`/`max` option: " + err.Error())
+		}
+	}
+
 	return r.Refs[ref].Flag
 }
+
+// cutTagOption cuts the first option from comma separated options, commas
+// in a double-quoted option value (e.g. `def="a,b"`) are not separators.
+func cutTagOption(options string) (opt, rest string) {
+	eq := strings.IndexByte(options, '=')
+	comma := strings.IndexByte(options, ',')
+	if eq != -1 && (comma == -1 || eq < comma) &&
+		eq+1 < len(options) && options[eq+1] == '"' {
+		if q, err := strconv.QuotedPrefix(options[eq+1:]); err == nil {
+			end := eq + 1 + len(q)
+			rest = options[end:]
+			if len(rest) == 0 || rest[0] == ',' {
+				return options[:end], strings.TrimPrefix(rest, ",")
+			}
+		}
+	}
+
+	opt, rest, _ = strings.Cut(options, ",")
+	return
+}
+
+// unquoteTagValue returns the unquoted value if it is double-quoted.
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' {
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+	}
+
+	return value
+}
+
+// quoteDefaultEntry quotes the default value when it can be mistaken as
+// multiple entries in the `[a, b]` rendering.
+func quoteDefaultEntry(value string) string {
+	if strings.Contains(value, ", ") || strings.HasPrefix(value, `"`) {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
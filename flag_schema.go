@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 The Prime Citizens
+
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WriteJSONSchema writes a JSON Schema (in one line) describing flags as an
+// `object`, with one property per flag not hidden.
+//
+// Property names are flag names (or shorthands if there is no name), types
+// are mapped from Flag.Type():
+//
+//   - int, uint and their sums: `integer`
+//   - float and fsum: `number`
+//   - bool: `boolean`
+//   - slices: `array` of the element type
+//   - maps: `object` with the value type as `additionalProperties`
+//   - others: `string`
+//
+// Descriptions come from Flag.Usage() and defaults from FlagInfo.DefaultValue.
+func WriteJSONSchema(out io.Writer, flags FlagIndexer) (n int, err error) {
+	buf := []byte(`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{`)
+
+	sep := false
+	for i := 0; ; i++ {
+		info, ok := flags.NthFlag(i)
+		if !ok {
+			break
+		}
+
+		_, flag, ok := FindFlag(flags, info.Name, info.Shorthand)
+		if !ok || flag.State().Hidden() {
+			continue
+		}
+
+		name := info.Name
+		if len(name) == 0 {
+			name = info.Shorthand
+		}
+
+		if len(name) == 0 {
+			continue
+		}
+
+		if sep {
+			buf = append(buf, ',')
+		}
+		sep = true
+
+		typ, _ := flag.Type()
+
+		buf = appendJSONString(buf, name)
+		buf = append(buf, ':', '{')
+		buf = appendSchemaType(buf, typ)
+
+		if usage := flag.Usage(); len(usage) != 0 {
+			buf = append(buf, `,"description":`...)
+			buf = appendJSONString(buf, usage)
+		}
+
+		def := info.DefaultValue
+		if len(def) == 0 {
+			if d, ok := flag.(interface{ Default() string }); ok {
+				def = d.Default()
+			}
+		}
+
+		if len(def) != 0 {
+			buf = append(buf, `,"default":`...)
+			buf = appendSchemaDefault(buf, typ, def)
+		}
+
+		buf = append(buf, '}')
+	}
+
+	buf = append(buf, "}}\n"...)
+	return out.Write(buf)
+}
+
+// appendSchemaType appends the `"type"` member (and `"items"` or
+// `"additionalProperties"` if any) of the JSON Schema for typ.
+func appendSchemaType(buf []byte, typ string) []byte {
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		buf = append(buf, `"type":"array","items":{`...)
+		buf = appendSchemaType(buf, typ[2:])
+		return append(buf, '}')
+	case strings.HasPrefix(typ, "map["):
+		_, elem, _ := strings.Cut(typ, "]")
+		buf = append(buf, `"type":"object","additionalProperties":{`...)
+		buf = appendSchemaType(buf, elem)
+		return append(buf, '}')
+	}
+
+	return append(buf, `"type":"`+schemaScalarType(typ)+`"`...)
+}
+
+// schemaScalarType returns the JSON Schema type of the scalar typ.
+func schemaScalarType(typ string) string {
+	switch typ {
+	case "int", "uint", "isum", "usum":
+		return "integer"
+	case "float", "fsum":
+		return "number"
+	case "bool":
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// appendSchemaDefault appends def as a JSON value of typ, def is appended
+// as a string if it cannot be converted.
+func appendSchemaDefault(buf []byte, typ, def string) []byte {
+	if strings.HasPrefix(typ, "[]") {
+		if len(def) < 2 || def[0] != '[' || def[len(def)-1] != ']' {
+			buf = append(buf, '[')
+			buf = appendSchemaDefault(buf, typ[2:], def)
+			return append(buf, ']')
+		}
+
+		buf = append(buf, '[')
+		var ent string
+		for def = def[1 : len(def)-1]; len(def) > 0; {
+			ent, def = cutDefaultEntry(def)
+			buf = appendSchemaDefault(buf, typ[2:], ent)
+			if len(def) > 0 {
+				buf = append(buf, ',')
+			}
+		}
+		return append(buf, ']')
+	}
+
+	switch schemaScalarType(typ) {
+	case "integer":
+		if v, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return strconv.AppendInt(buf, v, 10)
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(def, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return strconv.AppendFloat(buf, f, 'g', -1, 64)
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(def); err == nil {
+			return strconv.AppendBool(buf, v)
+		}
+	}
+
+	return appendJSONString(buf, def)
+}
+
+// appendJSONString appends s encoded as a JSON string to buf.
+func appendJSONString(buf []byte, s string) []byte {
+	b, _ := json.Marshal(s) // never fails for a string
+	return append(buf, b...)
+}
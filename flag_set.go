@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 The Prime Citizens
+
+package cli
+
+import (
+	"time"
+)
+
+// NewFlagSet creates a new FlagSet.
+func NewFlagSet() *FlagSet {
+	return &FlagSet{MapIndexer: NewMapIndexer()}
+}
+
+// FlagSet is a builder of flags bound to variables, it offers an API
+// similar to the standard library `flag` package without reflection.
+//
+// It is a FlagIndexer, thus can be used as Cmd.Flags directly.
+//
+// Current values of bound variables are not default values, use Default
+// after binding to provide a default value shown in help.
+//
+//	var (
+//		verbose bool
+//		output  string
+//	)
+//
+//	flags := NewFlagSet().
+//		BoolVar(&verbose, "verbose output", "verbose", "v").
+//		StringVar(&output, "output file", "output", "o").Default("-")
+type FlagSet struct {
+	*MapIndexer
+
+	last *flagBundle
+}
+
+// Var adds the flag with names.
+//
+// It panics when there is no name or there is flag with the same name.
+func (fs *FlagSet) Var(flag Flag, names ...string) *FlagSet {
+	index := fs.next
+	fs.Add(flag, names...)
+	fs.last = fs.i2f[index]
+	return fs
+}
+
+// Default sets the default value of the last added flag.
+//
+// It panics if there is no flag added.
+func (fs *FlagSet) Default(value string) *FlagSet {
+	if fs.last == nil {
+		panic("invalid call to Default: no flag added")
+	}
+
+	fs.last.info.DefaultValue = value
+	return fs
+}
+
+// BoolVar adds a bool flag bound to p.
+func (fs *FlagSet) BoolVar(p *bool, usage string, names ...string) *FlagSet {
+	return fs.Var(&Bool{BriefUsage: usage, Value: p}, names...)
+}
+
+// StringVar adds a string flag bound to p.
+func (fs *FlagSet) StringVar(p *string, usage string, names ...string) *FlagSet {
+	return fs.Var(&String{BriefUsage: usage, Value: p}, names...)
+}
+
+// IntVar adds an int flag bound to p.
+func (fs *FlagSet) IntVar(p *int, usage string, names ...string) *FlagSet {
+	return fs.Var(&Int{BriefUsage: usage, Value: p}, names...)
+}
+
+// DurationVar adds a duration flag bound to p.
+func (fs *FlagSet) DurationVar(p *time.Duration, usage string, names ...string) *FlagSet {
+	return fs.Var(&Duration{BriefUsage: usage, Value: p}, names...)
+}
+
+// StringSliceVar adds a string slice flag bound to p.
+func (fs *FlagSet) StringSliceVar(p *[]string, usage string, names ...string) *FlagSet {
+	return fs.Var(&StringSlice{BriefUsage: usage, Value: p}, names...)
+}
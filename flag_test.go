@@ -4,7 +4,11 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -771,3 +775,1470 @@ func (tf *TestFlags) Bind(opts *FlagTestOptions) {
 	tf.DurationSum.Value = &opts.DurationSum
 	tf.SizeSum.Value = &opts.SizeSum
 }
+
+func TestParseFlags_NonNeg(t *testing.T) {
+	type Opts struct {
+		Timeout time.Duration `cli:"timeout,value=dur,nonneg"`
+		Size    int64         `cli:"size,value=size,nonneg"`
+		Offset  time.Duration `cli:"offset,value=dur"`
+	}
+
+	for _, test := range []struct {
+		name string
+		args []string
+		bad  error
+	}{
+		{
+			name: "Negative duration is accepted by default",
+			args: []string{"--offset", "-5s"},
+		},
+		{
+			name: "Negative duration is rejected when nonneg",
+			args: []string{"--timeout", "-5s"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "timeout",
+				Value:   "-5s",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "dur", Value: "-5s"},
+			},
+		},
+		{
+			name: "Positive duration is accepted when nonneg",
+			args: []string{"--timeout", "5s"},
+		},
+		{
+			name: "Negative size is rejected when nonneg",
+			args: []string{"--size", "-1KB"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "size",
+				Value:   "-1KB",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "size", Value: "-1KB"},
+			},
+		},
+		{
+			name: "Positive size is accepted when nonneg",
+			args: []string{"--size", "1KB"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := ParseFlags(test.args, NewReflectIndexer(DefaultReflectVPFactory{}, &Opts{}), nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseFlags_ClearOn(t *testing.T) {
+	type Opts struct {
+		Exclude []string `cli:"exclude,clear-on=none"`
+	}
+
+	for _, test := range []struct {
+		name     string
+		init     []string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "Sentinel clears the slice",
+			init:     []string{"x", "y"},
+			args:     []string{"--exclude=none"},
+			expected: []string{},
+		},
+		{
+			name:     "Other values are appended",
+			init:     []string{"x"},
+			args:     []string{"--exclude", "a"},
+			expected: []string{"x", "a"},
+		},
+		{
+			name:     "Values after sentinel are appended",
+			init:     []string{"x"},
+			args:     []string{"--exclude", "none", "--exclude", "a"},
+			expected: []string{"a"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := Opts{Exclude: test.init}
+			flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+			_, _, err := ParseFlags(test.args, flags, nil)
+			assert.NoError(t, err)
+			assert.EqS(t, test.expected, opts.Exclude)
+
+			flag, ok := flags.FindFlag("exclude")
+			assert.True(t, ok)
+			assert.True(t, flag.State()&FlagStateValueChanged != 0)
+		})
+	}
+}
+
+func TestParseFlags_Invert(t *testing.T) {
+	type Opts struct {
+		EnableCache bool `cli:"disable-cache,invert"`
+	}
+
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{"Absence keeps the default", nil, true},
+		{"Presence sets false", []string{"--disable-cache"}, false},
+		{"Explicit value is assigned as is", []string{"--disable-cache=true"}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := Opts{EnableCache: true}
+			flags := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+			_, _, err := ParseFlags(test.args, flags, nil)
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected, opts.EnableCache)
+
+			flag, ok := flags.FindFlag("disable-cache")
+			assert.True(t, ok)
+			implied, ok := flag.ImplyValue()
+			assert.True(t, ok)
+			assert.Eq(t, "false", implied)
+		})
+	}
+
+	var panicked any
+	func() {
+		defer func() { panicked = recover() }()
+		type BadOpts struct {
+			Name string `cli:"name,invert"`
+		}
+		_, _ = NewReflectIndexer(DefaultReflectVPFactory{}, &BadOpts{}).FindFlag("name")
+	}()
+	assert.Eq[any](t, "invalid `invert` option for non-bool field type: string", panicked)
+}
+
+func TestParseFlags_MinMax(t *testing.T) {
+	type Opts struct {
+		Port    int    `cli:"port,min=1,max=65535"`
+		Buffer  uint64 `cli:"buffer,value=size,min=4KB,max=1MB"`
+		Retries int    `cli:"retries,max=10"`
+	}
+
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected Opts
+		bad      error
+	}{
+		{
+			name:     "In range",
+			args:     []string{"--port", "8080", "--buffer", "64KB", "--retries", "-1"},
+			expected: Opts{Port: 8080, Buffer: 64 << 10, Retries: -1},
+		},
+		{
+			name:     "Bounds are inclusive",
+			args:     []string{"--port", "65535", "--buffer", "4KB"},
+			expected: Opts{Port: 65535, Buffer: 4 << 10},
+		},
+		{
+			name: "Int below min",
+			args: []string{"--port", "0"},
+			bad: &ErrFlagValueInvalid{
+				Name: "port", Value: "0", NameAt: 0, ValueAt: 1,
+				Reason: &ErrValueOutOfRange{Name: "port", Min: "1", Max: "65535", Got: "0"},
+			},
+		},
+		{
+			name: "Int above max",
+			args: []string{"--port", "65536"},
+			bad: &ErrFlagValueInvalid{
+				Name: "port", Value: "65536", NameAt: 0, ValueAt: 1,
+				Reason: &ErrValueOutOfRange{Name: "port", Min: "1", Max: "65535", Got: "65536"},
+			},
+		},
+		{
+			name: "Size below min",
+			args: []string{"--buffer", "1KB"},
+			bad: &ErrFlagValueInvalid{
+				Name: "buffer", Value: "1KB", NameAt: 0, ValueAt: 1,
+				Reason: &ErrValueOutOfRange{Name: "buffer", Min: "4KB", Max: "1MB", Got: "1KB"},
+			},
+		},
+		{
+			name: "Size above max",
+			args: []string{"--buffer", "2MB"},
+			bad: &ErrFlagValueInvalid{
+				Name: "buffer", Value: "2MB", NameAt: 0, ValueAt: 1,
+				Reason: &ErrValueOutOfRange{Name: "buffer", Min: "4KB", Max: "1MB", Got: "2MB"},
+			},
+		},
+		{
+			name: "Max only",
+			args: []string{"--retries", "11"},
+			bad: &ErrFlagValueInvalid{
+				Name: "retries", Value: "11", NameAt: 0, ValueAt: 1,
+				Reason: &ErrValueOutOfRange{Name: "retries", Max: "10", Got: "11"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var opts Opts
+			_, _, err := ParseFlags(test.args, NewReflectIndexer(DefaultReflectVPFactory{}, &opts), nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+				assert.Eq(t, Opts{}, opts)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected, opts)
+		})
+	}
+
+	var panicked any
+	func() {
+		defer func() { panicked = recover() }()
+		type BadOpts struct {
+			Port int `cli:"port,min=one"`
+		}
+		_, _ = NewReflectIndexer(DefaultReflectVPFactory{}, &BadOpts{}).FindFlag("port")
+	}()
+	assert.Eq[any](t, "invalid `min`/`max` option: strconv.ParseInt: parsing \"one\": invalid syntax", panicked)
+}
+
+func TestParseFlags_IntRange(t *testing.T) {
+	type Opts struct {
+		CPUs []uint16 `cli:"cpus,value=range"`
+	}
+
+	for _, test := range []struct {
+		name     string
+		vp       VPIntRange[int]
+		args     []string
+		expected []int
+		bad      error
+	}{
+		{
+			name:     "Ranges and single values",
+			args:     []string{"--cpus", "0-3,5,7-8"},
+			expected: []int{0, 1, 2, 3, 5, 7, 8},
+		},
+		{
+			name:     "Single value",
+			args:     []string{"--cpus", "5"},
+			expected: []int{5},
+		},
+		{
+			name:     "Multiple flags append",
+			args:     []string{"--cpus", "1-2", "--cpus", "2"},
+			expected: []int{1, 2, 2},
+		},
+		{
+			name: "Reversed range",
+			args: []string{"--cpus", "3-1"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "cpus",
+				Value:   "3-1",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "range", Value: "3-1"},
+			},
+		},
+		{
+			name: "Overlapped ranges",
+			args: []string{"--cpus", "0-3,2"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "cpus",
+				Value:   "0-3,2",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "range", Value: "0-3,2"},
+			},
+		},
+		{
+			name:     "Overlapped ranges allowed",
+			vp:       VPIntRange[int]{AllowOverlap: true},
+			args:     []string{"--cpus", "0-1,1"},
+			expected: []int{0, 1, 1},
+		},
+		{
+			name:     "Span within cap",
+			vp:       VPIntRange[int]{MaxSpan: 4},
+			args:     []string{"--cpus", "0-1,2-3"},
+			expected: []int{0, 1, 2, 3},
+		},
+		{
+			name: "Span exceeds cap",
+			vp:   VPIntRange[int]{MaxSpan: 4},
+			args: []string{"--cpus", "0-4"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "cpus",
+				Value:   "0-4",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "range", Value: "0-4"},
+			},
+		},
+		{
+			name: "Total span exceeds cap",
+			vp:   VPIntRange[int]{MaxSpan: 4},
+			args: []string{"--cpus", "0-1,3-5"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "cpus",
+				Value:   "0-1,3-5",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "range", Value: "0-1,3-5"},
+			},
+		},
+		{
+			name: "Default span cap",
+			vp:   VPIntRange[int]{AllowOverlap: true},
+			args: []string{"--cpus", "0-9223372036854775807"},
+			bad: &ErrFlagValueInvalid{
+				Name:    "cpus",
+				Value:   "0-9223372036854775807",
+				NameAt:  0,
+				ValueAt: 1,
+				Reason:  &ErrInvalidValue{Type: "range", Value: "0-9223372036854775807"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var cpus []int
+			flags := NewMapIndexer().Add(&FlagBase[[]int, VPIntRange[int]]{
+				Value: &cpus,
+				VP:    test.vp,
+			}, "cpus")
+			_, _, err := ParseFlags(test.args, flags, nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqS(t, test.expected, cpus)
+		})
+
+		if test.vp != (VPIntRange[int]{}) {
+			continue
+		}
+
+		t.Run("Reflect "+test.name, func(t *testing.T) {
+			var opts Opts
+			_, _, err := ParseFlags(test.args, NewReflectIndexer(DefaultReflectVPFactory{}, &opts), nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Eq(t, len(test.expected), len(opts.CPUs))
+			for i := range test.expected {
+				assert.Eq(t, uint16(test.expected[i]), opts.CPUs[i])
+			}
+		})
+	}
+}
+
+func TestParseFlags_IntRangePointers(t *testing.T) {
+	type Opts struct {
+		CPUs *[]int `cli:"cpus,value=range"`
+	}
+
+	var opts Opts
+	flag, ok := NewReflectIndexer(DefaultReflectVPFactory{}, &opts).FindFlag("cpus")
+	assert.True(t, ok)
+	assert.False(t, flag.HasValue())
+
+	assert.NoError(t, flag.Decode(nil, "cpus", "0-2,5", true))
+	assert.True(t, flag.HasValue())
+	assert.EqS(t, []int{0, 1, 2, 5}, *opts.CPUs)
+
+	var buf strings.Builder
+	_, err := flag.PrintValue(&buf)
+	assert.NoError(t, err)
+	assert.Eq(t, "0-2,5", buf.String())
+
+	_, err = DefaultReflectVPFactory{}.GetVPReflectFor(reflect.TypeOf([]*int{}), "", "range")
+	assert.Error(t, err)
+}
+
+func TestVPIntRange_PrintValue(t *testing.T) {
+	var buf strings.Builder
+	_, err := VPIntRange[int]{}.PrintValue(&buf, &[]int{0, 1, 2, 3, 5, 7, 8})
+	assert.NoError(t, err)
+	assert.Eq(t, "0-3,5,7-8", buf.String())
+}
+
+func TestParseFlags_BoolNegation(t *testing.T) {
+	var (
+		verbose = true
+		output  string
+	)
+
+	flags := NewMapIndexer().
+		Add(&Bool{Value: &verbose}, "verbose", "v").
+		Add(&String{Value: &output}, "output")
+
+	_, _, err := ParseFlags([]string{"--no-verbose"}, flags, nil)
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "no-verbose", At: 0}, err)
+	assert.True(t, verbose)
+
+	opts := &ParseOptions{BoolNegation: true}
+	_, _, err = ParseFlags([]string{"--no-verbose"}, flags, opts)
+	assert.NoError(t, err)
+	assert.False(t, verbose)
+
+	_, _, err = ParseFlags([]string{"--no-output"}, flags, opts)
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "no-output", At: 0}, err)
+}
+
+func TestParseOneFlag(t *testing.T) {
+	var (
+		foo     StringV
+		a, b, c BoolV
+		o       IntV
+	)
+
+	flags := NewMapIndexer().
+		Add(&foo, "foo").
+		Add(&a, "a").
+		Add(&b, "b").
+		Add(&c, "c").
+		Add(&o, "o")
+
+	name, set, err := ParseOneFlag("--foo=bar", flags, nil)
+	assert.NoError(t, err)
+	assert.True(t, set)
+	assert.Eq(t, "foo", name)
+	assert.Eq(t, "bar", foo.Value)
+
+	name, set, err = ParseOneFlag("-abc", flags, nil)
+	assert.NoError(t, err)
+	assert.True(t, set)
+	assert.Eq(t, "c", name)
+	assert.True(t, a.Value && b.Value && c.Value)
+
+	name, set, err = ParseOneFlag("-o=1", flags, nil)
+	assert.NoError(t, err)
+	assert.True(t, set)
+	assert.Eq(t, "o", name)
+	assert.Eq(t, 1, o.Value)
+
+	name, set, err = ParseOneFlag("--unknown=1", flags, nil)
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "unknown"}, err)
+	assert.False(t, set)
+	assert.Eq(t, "unknown", name)
+
+	name, set, err = ParseOneFlag("--foo", flags, nil)
+	assert.ErrorIs(t, &ErrFlagValueMissing{Name: "foo"}, err)
+	assert.False(t, set)
+	assert.Eq(t, "foo", name)
+
+	for _, token := range []string{"", "-", "--", "foo"} {
+		_, set, err = ParseOneFlag(token, flags, nil)
+		assert.ErrorIs(t, &ErrInvalidValue{Type: "flag", Value: token}, err)
+		assert.False(t, set)
+	}
+
+	for _, token := range []string{"--=1", "-=1"} {
+		_, set, err = ParseOneFlag(token, flags, nil)
+		assert.ErrorIs(t, &ErrMalformedFlag{Arg: token}, err)
+		assert.False(t, set)
+	}
+}
+
+func TestParseFlags_MalformedFlag(t *testing.T) {
+	var v BoolV
+	flags := NewMapIndexer().Add(&v, "v")
+
+	for _, arg := range []string{"-=x", "--=x", "-="} {
+		_, _, err := ParseFlags([]string{"-v", arg}, flags, nil)
+		assert.ErrorIs(t, &ErrMalformedFlag{Arg: arg, At: 1}, err)
+	}
+
+	posArgs, _, err := ParseFlags([]string{"-", "-v"}, flags, nil)
+	assert.NoError(t, err)
+	assert.EqS(t, []string{"-"}, posArgs)
+	assert.True(t, v.Value)
+
+	// `=` is part of the shorthand cluster in posix strict mode.
+	_, _, err = ParseFlags([]string{"-=x"}, flags, &ParseOptions{PosixStrict: true})
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "="}, err)
+}
+
+func TestParseOptions_Trace(t *testing.T) {
+	var (
+		verbose bool
+		output  string
+	)
+
+	flags := NewMapIndexer().
+		Add(&Bool{Value: &verbose}, "verbose", "v").
+		Add(&String{Value: &output}, "out", "o")
+
+	type event struct {
+		Kind ParseEventKind
+		At   int
+		Arg  string
+	}
+
+	var (
+		events []event
+		errs   []error
+	)
+	opts := &ParseOptions{
+		HandleParseError: func(opts *ParseOptions, args []string, i int, err error) error {
+			return nil // continue parsing
+		},
+		Trace: func(ev ParseEvent) {
+			events = append(events, event{ev.Kind, ev.At, ev.Arg})
+			if ev.Err != nil {
+				errs = append(errs, ev.Err)
+			}
+		},
+	}
+
+	posArgs, dashArgs, err := ParseFlags([]string{
+		"-v", "--out", "file", "pos", "-o=x", "--bad", "--", "rest",
+	}, flags, opts)
+	assert.NoError(t, err)
+	assert.EqS(t, []string{"pos"}, posArgs)
+	assert.EqS(t, []string{"rest"}, dashArgs)
+	assert.EqS(t, []event{
+		{ParseEventShortFlags, 0, "-v"},
+		{ParseEventLongFlag, 1, "--out"},
+		{ParseEventFlagValue, 2, "file"},
+		{ParseEventPosArg, 3, "pos"},
+		{ParseEventShortFlags, 4, "-o=x"},
+		{ParseEventError, 5, "--bad"},
+		{ParseEventDash, 6, "--"},
+	}, events)
+	assert.Eq(t, 1, len(errs))
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "bad", At: 5}, errs[0])
+
+	events = events[:0]
+	_, _, err = ParseFlags([]string{"pos", "--help"}, flags, opts)
+	assert.NoError(t, err)
+	assert.EqS(t, []event{
+		{ParseEventPosArg, 0, "pos"},
+		{ParseEventHelpArg, 1, "--help"},
+	}, events)
+	assert.Eq(t, "long-flag", ParseEventLongFlag.String())
+}
+
+func TestParseFlags_RequireEqForLongValues(t *testing.T) {
+	var (
+		verbose bool
+		output  string
+	)
+
+	flags := NewMapIndexer().
+		Add(&Bool{Value: &verbose}, "verbose", "v").
+		Add(&String{Value: &output}, "out", "o")
+
+	opts := &ParseOptions{RequireEqForLongValues: true}
+	posArgs, _, err := ParseFlags([]string{"--verbose", "--out", "file"}, flags, opts)
+	assert.ErrorIs(t, &ErrFlagValueRequiresEq{Name: "out", At: 1}, err)
+	assert.Eq(t, 0, len(posArgs))
+	assert.Eq(t, "", output)
+
+	posArgs, _, err = ParseFlags([]string{"--verbose", "--out=file", "pos"}, flags, opts)
+	assert.NoError(t, err)
+	assert.True(t, verbose)
+	assert.Eq(t, "file", output)
+	assert.EqS(t, []string{"pos"}, posArgs)
+
+	// shorthands are not affected
+	_, _, err = ParseFlags([]string{"-o", "other"}, flags, opts)
+	assert.NoError(t, err)
+	assert.Eq(t, "other", output)
+}
+
+func TestParseFlags_CollectUnknown(t *testing.T) {
+	var (
+		verbose bool
+		output  string
+		unknown []string
+	)
+
+	flags := NewMapIndexer().
+		Add(&Bool{Value: &verbose}, "verbose", "v").
+		Add(&String{Value: &output}, "output")
+
+	args := []string{
+		"--plugin-opt", "x",
+		"-v",
+		"--output", "out",
+		"--plugin-flag",
+		"--other=1",
+		"pos",
+		"-z", "val",
+		"--last",
+	}
+
+	_, _, err := ParseFlags(args, flags, nil)
+	assert.ErrorIs(t, &ErrFlagUndefined{Name: "plugin-opt", At: 0}, err)
+
+	posArgs, _, err := ParseFlags(args, flags, &ParseOptions{CollectUnknown: &unknown})
+	assert.NoError(t, err)
+	assert.True(t, verbose)
+	assert.Eq(t, "out", output)
+	assert.EqS(t, []string{"pos"}, posArgs)
+	assert.EqS(t, []string{
+		"--plugin-opt", "x",
+		"--plugin-flag",
+		"--other=1",
+		"-z", "val",
+		"--last",
+	}, unknown)
+}
+
+func TestParseFlags_AssignChar(t *testing.T) {
+	type Opts struct {
+		Out string            `cli:"out|o"`
+		Env map[string]string `cli:"env"`
+	}
+
+	for _, test := range []struct {
+		name     string
+		popts    ParseOptions
+		args     []string
+		expected Opts
+		bad      error
+	}{
+		{
+			name:     "Long flag",
+			popts:    ParseOptions{AssignChar: ':'},
+			args:     []string{"--out:file"},
+			expected: Opts{Out: "file"},
+		},
+		{
+			name:     "Shorthand",
+			popts:    ParseOptions{AssignChar: ':'},
+			args:     []string{"-o:file"},
+			expected: Opts{Out: "file"},
+		},
+		{
+			name:  "Default char no longer splits",
+			popts: ParseOptions{AssignChar: ':'},
+			args:  []string{"--out=file"},
+			bad:   &ErrFlagUndefined{Name: "out=file", At: 0},
+		},
+		{
+			name:     "Map entry uses = by default",
+			popts:    ParseOptions{AssignChar: ':'},
+			args:     []string{"--env:a=b:c"},
+			expected: Opts{Env: map[string]string{"a": "b:c"}},
+		},
+		{
+			name:     "Map entry uses AssignChar",
+			popts:    ParseOptions{AssignChar: ':', MapAssignChar: true},
+			args:     []string{"--env:a:b=c"},
+			expected: Opts{Env: map[string]string{"a": "b=c"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var opts Opts
+			_, _, err := ParseFlags(test.args, NewReflectIndexer(DefaultReflectVPFactory{}, &opts), &test.popts)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected.Out, opts.Out)
+			assert.Eq(t, len(test.expected.Env), len(opts.Env))
+			for k, v := range test.expected.Env {
+				assert.Eq(t, v, opts.Env[k])
+			}
+		})
+	}
+}
+
+func TestParseFlags_MapDup(t *testing.T) {
+	type Opts struct {
+		Last  map[string]int `cli:"last,dup=last"`
+		First map[string]int `cli:"first,dup=first"`
+		Error map[string]int `cli:"error,dup=error"`
+	}
+
+	for _, test := range []struct {
+		dup      string
+		policy   MapDupPolicy
+		expected int
+		bad      error
+	}{
+		{"last", MapDupLast, 2, nil},
+		{"first", MapDupFirst, 1, nil},
+		{"error", MapDupError, 1, &ErrDuplicateMapKey{Key: "a"}},
+	} {
+		t.Run(test.dup, func(t *testing.T) {
+			check := func(t *testing.T, err error, value map[string]int) {
+				if test.bad != nil {
+					assert.ErrorIs(t, test.bad, err)
+				} else {
+					assert.NoError(t, err)
+				}
+				assert.Eq(t, test.expected, value["a"])
+			}
+
+			t.Run("Reflect", func(t *testing.T) {
+				var opts Opts
+				_, _, err := ParseFlags(
+					[]string{"--" + test.dup, "a=1", "--" + test.dup, "a=2"},
+					NewReflectIndexer(DefaultReflectVPFactory{}, &opts), nil,
+				)
+				check(t, err, map[string]map[string]int{
+					"last": opts.Last, "first": opts.First, "error": opts.Error,
+				}[test.dup])
+			})
+
+			t.Run("Generic", func(t *testing.T) {
+				var value map[string]int
+				flag := &MapStringInt{Value: &value}
+				flag.VP.Dup = test.policy
+				_, _, err := ParseFlags(
+					[]string{"--m", "a=1", "--m", "a=2"},
+					NewMapIndexer().Add(flag, "m"), nil,
+				)
+				check(t, err, value)
+			})
+		})
+	}
+
+	var panicked any
+	func() {
+		defer func() { panicked = recover() }()
+		type BadOpts struct {
+			Name string `cli:"name,dup=error"`
+		}
+		_, _ = NewReflectIndexer(DefaultReflectVPFactory{}, &BadOpts{}).FindFlag("name")
+	}()
+	assert.Eq[any](t, "invalid `dup` option for non-map field type: string", panicked)
+}
+
+func TestParseFlags_TriState(t *testing.T) {
+	type Opts struct {
+		Color TriState `cli:"color,value=tristate"`
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected TriState
+		bad      error
+	}{
+		{[]string{"--color"}, TriStateOn, nil},
+		{[]string{"--color", "on"}, TriStateOn, nil},
+		{[]string{"--color=true"}, TriStateOn, nil},
+		{[]string{"--color=yes"}, TriStateOn, nil},
+		{[]string{"--color=1"}, TriStateOn, nil},
+		{[]string{"--color", "off"}, TriStateOff, nil},
+		{[]string{"--color=false"}, TriStateOff, nil},
+		{[]string{"--color=no"}, TriStateOff, nil},
+		{[]string{"--color=0"}, TriStateOff, nil},
+		{[]string{"--color", "auto"}, TriStateAuto, nil},
+		{[]string{"--color=maybe"}, TriStateAuto, &ErrFlagValueInvalid{
+			Name:    "color",
+			Value:   "maybe",
+			NameAt:  0,
+			ValueAt: 0,
+		}},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			color := TriStateFlagV{Value: TriStateOff}
+			_, _, err := ParseFlags(test.args, NewMapIndexer().Add(&color, "color"), nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Eq(t, test.expected, color.Value)
+			}
+
+			opts := Opts{Color: TriStateOff}
+			_, _, err = ParseFlags(test.args, NewReflectIndexer(DefaultReflectVPFactory{}, &opts), nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Eq(t, test.expected, opts.Color)
+			}
+		})
+	}
+
+	err := VPTriState[TriState]{}.ParseValue(nil, "maybe", new(TriState), true)
+	assert.ErrorIs(t, &ErrInvalidValue{Type: "tristate", Value: "maybe"}, err)
+}
+
+func TestParseFlags_Quantity(t *testing.T) {
+	type Opts struct {
+		CPU int64 `cli:"cpu,value=quantity"`
+	}
+
+	for _, test := range []struct {
+		arg      string
+		expected int64
+		bad      bool
+	}{
+		{"100m", 100, false},
+		{"2", 2000, false},
+		{"2Gi", 2 << 30 * 1000, false},
+		{"500M", 500 * 1000 * 1000 * 1000, false},
+		{"1.5k", 1500 * 1000, false},
+		{"1e3", 1000 * 1000, false},
+		{"0.1m", 1, false},
+		{"-1", -1000, false},
+		{"2Xi", 0, true},
+		{"1.2.3", 0, true},
+		{"Gi", 0, true},
+		{"1E", 0, true},
+		{"1Ei", 0, true},
+		{"1E3", 1000 * 1000, false},
+		{"8Pi", 8 << 50 * 1000, false},
+	} {
+		t.Run(test.arg, func(t *testing.T) {
+			var (
+				value int64
+				flag  = &Quantity{Value: &value}
+			)
+			_, _, err := ParseFlags([]string{"--cpu", test.arg}, NewMapIndexer().Add(flag, "cpu"), nil)
+			if test.bad {
+				assert.ErrorIs(t, &ErrFlagValueInvalid{
+					Name:    "cpu",
+					Value:   test.arg,
+					NameAt:  0,
+					ValueAt: 1,
+					Reason:  &ErrInvalidValue{Type: "quantity", Value: test.arg},
+				}, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected, value)
+
+			var opts Opts
+			_, _, err = ParseFlags([]string{"--cpu", test.arg}, NewReflectIndexer(DefaultReflectVPFactory{}, &opts), nil)
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected, opts.CPU)
+		})
+	}
+}
+
+func TestVPQuantity_PrintValue(t *testing.T) {
+	for _, test := range []struct {
+		value    int64
+		expected string
+	}{
+		{0, "0"},
+		{100, "100m"},
+		{2000, "2"},
+		{1500 * 1000, "1500"},
+		{500 * 1000 * 1000 * 1000, "500M"},
+		{2 << 30 * 1000, "2Gi"},
+		{-2 << 10 * 1000, "-2Ki"},
+		{9e15 * 1000, "9P"},
+	} {
+		var sb strings.Builder
+		_, err := VPQuantity[int64]{}.PrintValue(&sb, &test.value)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, sb.String())
+	}
+}
+
+func TestVPMap_PrintValue(t *testing.T) {
+	m := map[string]int{"c": 3, "a": 1, "d": 4, "b": 2, "e": 5}
+	rm := reflect.ValueOf(&m).Elem()
+
+	for i := 0; i < 2; i++ {
+		var sb strings.Builder
+		_, err := VPMap[string, int, VPString[string], VPInt[int]]{}.PrintValue(&sb, &m)
+		assert.NoError(t, err)
+		assert.Eq(t, "[a=1, b=2, c=3, d=4, e=5]", sb.String())
+
+		sb.Reset()
+		_, err = VPReflectMap[VPReflectString, VPReflectInt]{}.PrintValue(&sb, &rm)
+		assert.NoError(t, err)
+		assert.Eq(t, "[a=1, b=2, c=3, d=4, e=5]", sb.String())
+	}
+}
+
+func TestParseFlags_StringSetBool(t *testing.T) {
+	type Opts struct {
+		Features map[string]bool `cli:"features,value=flagset"`
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected map[string]bool
+		printed  string
+		bad      bool
+	}{
+		{
+			args:     []string{"--features", "a,b,-c"},
+			expected: map[string]bool{"a": true, "b": true, "c": false},
+			printed:  "a,b",
+		},
+		{
+			args:     []string{"--features", "a,b", "--features=-a,c"},
+			expected: map[string]bool{"a": false, "b": true, "c": true},
+			printed:  "b,c",
+		},
+		{
+			args: []string{"--features", "a,,b"},
+			bad:  true,
+		},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var features StringSetBoolV
+			_, _, err := ParseFlags(test.args, NewMapIndexer().Add(&features, "features"), nil)
+			if test.bad {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Eq(t, len(test.expected), len(features.Value))
+			for k, v := range test.expected {
+				assert.Eq(t, v, features.Value[k])
+			}
+
+			var sb strings.Builder
+			_, err = features.PrintValue(&sb)
+			assert.NoError(t, err)
+			assert.Eq(t, test.printed, sb.String())
+
+			var opts Opts
+			_, _, err = ParseFlags(test.args, NewReflectIndexer(DefaultReflectVPFactory{}, &opts), nil)
+			assert.NoError(t, err)
+			assert.Eq(t, len(test.expected), len(opts.Features))
+			for k, v := range test.expected {
+				assert.Eq(t, v, opts.Features[k])
+			}
+		})
+	}
+}
+
+func TestParseFlags_PosixStrict(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		args     []string
+		posArgs  []string
+		dashArgs []string
+		output   string
+		verbose  bool
+	}{
+		{
+			name:    "Long flag is an operand",
+			args:    []string{"--foo", "-v"},
+			posArgs: []string{"--foo", "-v"},
+		},
+		{
+			name:    "Stop at the first operand",
+			args:    []string{"-v", "file", "-o", "out"},
+			posArgs: []string{"file", "-o", "out"},
+			verbose: true,
+		},
+		{
+			name:     "Dash after operand",
+			args:     []string{"file", "-v", "--", "-o"},
+			posArgs:  []string{"file", "-v"},
+			dashArgs: []string{"-o"},
+		},
+		{
+			name:    "Attached value keeps equal sign",
+			args:    []string{"-vo=out"},
+			output:  "=out",
+			verbose: true,
+		},
+		{
+			name:   "Separate value",
+			args:   []string{"-o", "out"},
+			output: "out",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				output  StringV
+				verbose BoolV
+			)
+
+			flags := NewMapIndexer().
+				Add(&output, "output", "o").
+				Add(&verbose, "verbose", "v")
+
+			posArgs, dashArgs, err := ParseFlags(test.args, flags, &ParseOptions{
+				PosixStrict: true,
+			})
+			assert.NoError(t, err)
+			assert.EqS(t, test.posArgs, posArgs)
+			assert.EqS(t, test.dashArgs, dashArgs)
+			assert.Eq(t, test.output, output.Value)
+			assert.Eq(t, test.verbose, verbose.Value)
+		})
+	}
+}
+
+func TestParseFlags_BoolClusterWithValue(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		args    []string
+		a, b, c bool
+		bad     error
+	}{
+		{
+			name: "Implied values",
+			args: []string{"-abc"},
+			a:    true, b: true, c: true,
+		},
+		{
+			name: "Explicit value only applies to the last",
+			args: []string{"-abc=false"},
+			a:    true, b: true, c: false,
+		},
+		{
+			name: "Explicit true for the last",
+			args: []string{"-abc=true"},
+			a:    true, b: true, c: true,
+		},
+		{
+			name: "Non-implicit flag in the middle",
+			args: []string{"-asc=false"},
+			a:    true,
+			bad: &ErrShorthandOfExplicitFlagInMiddle{
+				Shorthand:        "s",
+				ShorthandCluster: "asc",
+				Value:            "false",
+			},
+		},
+		{
+			name: "Invalid explicit value for the last",
+			args: []string{"-ab=1c"},
+			a:    true,
+			bad: &ErrFlagValueInvalid{
+				Name:    "b",
+				Value:   "1c",
+				NameAt:  0,
+				ValueAt: 0,
+				Reason:  &ErrInvalidValue{Type: "bool", Value: "1c"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				a, b, c BoolV
+				str     StringV
+			)
+			flags := NewMapIndexer().
+				Add(&a, "a").
+				Add(&b, "b").
+				Add(&c, "c").
+				Add(&str, "s")
+
+			_, _, err := ParseFlags(test.args, flags, nil)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Eq(t, test.a, a.Value)
+			assert.Eq(t, test.b, b.Value)
+			assert.Eq(t, test.c, c.Value)
+		})
+	}
+}
+
+func TestParseFlags_TimeLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data not available:", err)
+	}
+
+	var (
+		at   TimeV
+		unix UnixSecV
+	)
+
+	flags := NewMapIndexer().
+		Add(&at, "at").
+		Add(&unix, "unix")
+
+	opts := &ParseOptions{
+		StartTime:    time.Date(2023, time.August, 1, 12, 0, 0, 0, time.UTC),
+		TimeLocation: loc,
+	}
+
+	_, _, err = ParseFlags([]string{"--at", "15:04", "--unix", "15:04"}, flags, opts)
+	assert.NoError(t, err)
+
+	expected := time.Date(2023, time.August, 1, 15, 4, 0, 0, loc)
+	assert.Eq(t, loc, at.Value.Location())
+	assert.True(t, expected.Equal(at.Value))
+	assert.Eq(t, expected.Unix(), unix.Value)
+}
+
+func TestWriteJSONSchema(t *testing.T) {
+	var opts struct {
+		Count  int            `cli:"count|n,def=3,#number of items"`
+		Force  bool           `cli:"force,#skip confirmation"`
+		Tags   []string       `cli:"tag,def=a,def=b"`
+		Limits map[string]int `cli:"limit"`
+		Secret string         `cli:"secret,hide"`
+	}
+
+	var sb strings.Builder
+	_, err := WriteJSONSchema(&sb, NewReflectIndexer(DefaultReflectVPFactory{}, &opts))
+	assert.NoError(t, err)
+	assert.Eq(t, ""+
+		`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{`+
+		`"count":{"type":"integer","description":"number of items","default":3},`+
+		`"force":{"type":"boolean","description":"skip confirmation"},`+
+		`"tag":{"type":"array","items":{"type":"string"},"default":["a","b"]},`+
+		`"limit":{"type":"object","additionalProperties":{"type":"integer"}}`+
+		"}}\n", sb.String())
+}
+
+func TestVPSecretSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x")
+	assert.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+	var opts struct {
+		Token string `cli:"token,value=secret-src"`
+	}
+
+	popts := &ParseOptions{
+		Stdin: strings.NewReader("from-stdin\r\n"),
+		LookupEnv: func(key string) (string, bool) {
+			if key == "TOKEN" {
+				return "from-env", true
+			}
+			return "", false
+		},
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	for _, test := range []struct {
+		arg      string
+		expected string
+	}{
+		{"env:TOKEN", "from-env"},
+		{"file:" + path, "from-file"},
+		{"-", "from-stdin"},
+	} {
+		_, _, err := ParseFlags([]string{"--token", test.arg}, indexer, popts)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, opts.Token)
+
+		flag, ok := indexer.FindFlag("token")
+		assert.True(t, ok)
+
+		var sb strings.Builder
+		_, err = flag.PrintValue(&sb)
+		assert.NoError(t, err)
+		assert.Eq(t, "<redacted>", sb.String())
+	}
+
+	for _, arg := range []string{"plain-secret", "env:MISSING", "env:"} {
+		_, _, err := ParseFlags([]string{"--token=" + arg}, indexer, popts)
+		assert.Error(t, err)
+	}
+
+	var token string
+	err := VPSecretSource[string]{}.ParseValue(popts, "env:MISSING", &token, true)
+	assert.ErrorIs(t, &ErrInvalidValue{Type: "secret source", Value: "env:MISSING"}, err)
+}
+
+func TestVPEmail(t *testing.T) {
+	var opts struct {
+		From string   `cli:"from,value=email"`
+		To   []string `cli:"to,value=email"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	for _, test := range []struct {
+		arg      string
+		expected string
+	}{
+		{"a@example.com", "a@example.com"},
+		{"Jane Doe <jane@example.com>", "jane@example.com"},
+	} {
+		_, _, err := ParseFlags([]string{"--from", test.arg}, indexer, nil)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, opts.From)
+
+		flag, ok := indexer.FindFlag("from")
+		assert.True(t, ok)
+
+		var sb strings.Builder
+		_, err = flag.PrintValue(&sb)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, sb.String())
+	}
+
+	_, _, err := ParseFlags([]string{"--to", "a@x.com", "--to", "B <b@y.com>"}, indexer, nil)
+	assert.NoError(t, err)
+	assert.EqS(t, []string{"a@x.com", "b@y.com"}, opts.To)
+
+	_, _, err = ParseFlags([]string{"--from", "not-an-email"}, indexer, nil)
+	assert.Error(t, err)
+
+	var addr string
+	err = VPEmail[string]{}.ParseValue(nil, "not-an-email", &addr, true)
+	assert.ErrorIs(t, &ErrInvalidValue{Type: "email", Value: "not-an-email"}, err)
+	assert.Eq(t, "", addr)
+}
+
+// policyVPFactory handles `value=policy` with VPReflectBoolWords.
+type policyVPFactory struct{ DefaultReflectVPFactory }
+
+func (f policyVPFactory) GetVPReflectFor(fieldType reflect.Type, keyType, valueType string) (VP[*reflect.Value], error) {
+	if valueType == "policy" {
+		return VPReflectBoolWords{True: []string{"allow"}, False: []string{"deny"}}, nil
+	}
+
+	return f.DefaultReflectVPFactory.GetVPReflectFor(fieldType, keyType, valueType)
+}
+
+func TestVPBoolWords(t *testing.T) {
+	vp := VPBoolWords[bool]{True: []string{"allow"}, False: []string{"deny"}}
+	for _, test := range []struct {
+		arg      string
+		expected bool
+	}{
+		{"allow", true},
+		{"deny", false},
+		{"true", true},
+		{"false", false},
+	} {
+		value := !test.expected
+		assert.NoError(t, vp.ParseValue(nil, test.arg, &value, true))
+		assert.Eq(t, test.expected, value)
+	}
+
+	for _, arg := range []string{"yes", "enabled", "Allow", ""} {
+		value := false
+		assert.ErrorIs(t, &ErrInvalidValue{Type: "bool", Value: arg}, vp.ParseValue(nil, arg, &value, true))
+	}
+
+	var opts struct {
+		Network bool `cli:"network,value=policy"`
+	}
+
+	indexer := NewReflectIndexer(policyVPFactory{}, &opts)
+	for _, test := range []struct {
+		args     []string
+		expected bool
+		printed  string
+	}{
+		{[]string{"--network=allow"}, true, "allow"},
+		{[]string{"--network=deny"}, false, "deny"},
+		{[]string{"--network"}, true, "allow"},
+	} {
+		_, _, err := ParseFlags(test.args, indexer, nil)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, opts.Network)
+
+		flag, ok := indexer.FindFlag("network")
+		assert.True(t, ok)
+
+		var sb strings.Builder
+		_, err = flag.PrintValue(&sb)
+		assert.NoError(t, err)
+		assert.Eq(t, test.printed, sb.String())
+	}
+
+	_, _, err := ParseFlags([]string{"--network=on"}, indexer, nil)
+	assert.Error(t, err)
+}
+
+func TestVPGlob(t *testing.T) {
+	var opts struct {
+		Include string   `cli:"include,value=glob"`
+		Exclude []string `cli:"exclude,value=glob"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+
+	_, _, err := ParseFlags([]string{"--include", "*.go"}, indexer, nil)
+	assert.NoError(t, err)
+	assert.Eq(t, "*.go", opts.Include)
+
+	_, _, err = ParseFlags([]string{"--exclude", "*_test.go", "--exclude", "[a-c]?.txt"}, indexer, nil)
+	assert.NoError(t, err)
+	assert.EqS(t, []string{"*_test.go", "[a-c]?.txt"}, opts.Exclude)
+
+	_, _, err = ParseFlags([]string{"--include", "[abc"}, indexer, nil)
+	assert.Error(t, err)
+	assert.Eq(t, "*.go", opts.Include)
+
+	_, _, err = ParseFlags([]string{"--exclude", "a\\"}, indexer, nil)
+	assert.Error(t, err)
+
+	var pattern string
+	err = VPGlob[string]{}.ParseValue(nil, "[abc", &pattern, true)
+	assert.ErrorIs(t, &ErrInvalidValue{Type: "glob", Value: "[abc"}, err)
+	assert.Eq(t, "", pattern)
+}
+
+func TestVPSemver(t *testing.T) {
+	var opts struct {
+		Version  string   `cli:"version,value=semver"`
+		Versions []string `cli:"versions,value=semver"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	for _, test := range []struct {
+		arg      string
+		expected string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"1.2.3-rc.1+build.5", "1.2.3-rc.1+build.5"},
+		{"v0.10.0-alpha-1", "0.10.0-alpha-1"},
+	} {
+		_, _, err := ParseFlags([]string{"--version", test.arg}, indexer, nil)
+		assert.NoError(t, err)
+		assert.Eq(t, test.expected, opts.Version)
+	}
+
+	_, _, err := ParseFlags([]string{"--versions", "1.0.0", "--versions", "v2.0.0"}, indexer, nil)
+	assert.NoError(t, err)
+	assert.EqS(t, []string{"1.0.0", "2.0.0"}, opts.Versions)
+
+	for _, arg := range []string{"1.2", "1.2.3.4", "01.2.3", "1.2.3-", "1.2.3-01", "1.2.3+", "1.2.3-a..b", "1.2.x"} {
+		var v string
+		err = VPSemver[string]{}.ParseValue(nil, arg, &v, true)
+		assert.ErrorIs(t, &ErrInvalidValue{Type: "semver", Value: arg}, err)
+		assert.Eq(t, "", v)
+	}
+
+	v, ok := ParseSemver("1.2.3-rc.1+build.5")
+	assert.True(t, ok)
+	assert.Eq(t, Semver{Major: 1, Minor: 2, Patch: 3, Pre: "rc.1", Build: "build.5"}, v)
+
+	// precedence example from semver.org
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "2.0.0", "2.1.0", "2.1.1",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, ok := ParseSemver(ordered[i-1])
+		assert.True(t, ok)
+		b, ok := ParseSemver(ordered[i])
+		assert.True(t, ok)
+		assert.Eq(t, -1, a.Compare(b))
+		assert.Eq(t, 1, b.Compare(a))
+	}
+
+	a, _ := ParseSemver("1.0.0+a")
+	b, _ := ParseSemver("1.0.0+b")
+	assert.Eq(t, 0, a.Compare(b))
+}
+
+func TestValueModeOf(t *testing.T) {
+	var opts struct {
+		Verbose bool     `cli:"verbose"`
+		Quiet   bool     `cli:"quiet,invert"`
+		Jobs    int      `cli:"jobs"`
+		Level   int      `cli:"level,value=sum"`
+		Color   TriState `cli:"color,value=tristate"`
+		Tags    []string `cli:"tag"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	for _, test := range []struct {
+		name     string
+		expected FlagValueMode
+	}{
+		{"verbose", FlagValueOptional},
+		{"quiet", FlagValueOptional},
+		{"jobs", FlagValueRequired},
+		{"level", FlagValueOptional},
+		{"color", FlagValueOptional},
+		{"tag", FlagValueRequired},
+	} {
+		flag, ok := indexer.FindFlag(test.name)
+		assert.True(t, ok)
+		assert.Eq(t, test.expected.String(), ValueModeOf(flag).String())
+	}
+
+	var (
+		verbose bool
+		jobs    int
+		level   int
+	)
+	assert.Eq(t, FlagValueOptional, ValueModeOf(&Bool{Value: &verbose}))
+	assert.Eq(t, FlagValueRequired, ValueModeOf(&Int{Value: &jobs}))
+	assert.Eq(t, FlagValueOptional, ValueModeOf(&IntSum{Value: &level}))
+}
+
+func TestVPEndpointCSV(t *testing.T) {
+	var opts struct {
+		Endpoints []Endpoint `cli:"endpoints,value=endpoint-csv"`
+		Peers     []struct {
+			Host string
+			Port uint16
+		} `cli:"peers,value=endpoint-csv"`
+	}
+
+	indexer := NewReflectIndexer(DefaultReflectVPFactory{}, &opts)
+	_, _, err := ParseFlags([]string{
+		"--endpoints", "host1:8080,host2:9090", "--endpoints=[::1]:443",
+		"--peers", "10.0.0.1:7000",
+	}, indexer, nil)
+	assert.NoError(t, err)
+	assert.EqS(t, []Endpoint{
+		{Host: "host1", Port: 8080},
+		{Host: "host2", Port: 9090},
+		{Host: "::1", Port: 443},
+	}, opts.Endpoints)
+	assert.Eq(t, 1, len(opts.Peers))
+	assert.Eq(t, "10.0.0.1", opts.Peers[0].Host)
+	assert.Eq(t, uint16(7000), opts.Peers[0].Port)
+
+	flag, ok := indexer.FindFlag("endpoints")
+	assert.True(t, ok)
+
+	var sb strings.Builder
+	_, err = flag.PrintValue(&sb)
+	assert.NoError(t, err)
+	assert.Eq(t, "host1:8080,host2:9090,[::1]:443", sb.String())
+
+	for _, test := range []struct {
+		arg     string
+		invalid string
+	}{
+		{"host1:8080,host2", "host2"}, // missing port
+		{"host1:http", "host1:http"},  // invalid port
+		{"host1:8080,host2:70000", "host2:70000"},
+		{":8080", ":8080"}, // missing host
+		{"host1:8080,", ""},
+	} {
+		var endpoints []Endpoint
+		err = VPEndpointCSV[Endpoint]{}.ParseValue(nil, test.arg, &endpoints, true)
+		assert.ErrorIs(t, &ErrInvalidValue{Type: "endpoint", Value: test.invalid}, err)
+		assert.Eq(t, 0, len(endpoints))
+	}
+
+	_, _, err = ParseFlags([]string{"--endpoints", "host3:1,host4"}, indexer, nil)
+	assert.Error(t, err)
+	assert.Eq(t, 3, len(opts.Endpoints))
+
+	var ptrOpts struct {
+		Endpoints *[]Endpoint `cli:"endpoints,value=endpoint-csv"`
+	}
+
+	flag, ok = NewReflectIndexer(DefaultReflectVPFactory{}, &ptrOpts).FindFlag("endpoints")
+	assert.True(t, ok)
+	assert.False(t, flag.HasValue())
+
+	assert.NoError(t, flag.Decode(nil, "endpoints", "host1:8080", true))
+	assert.True(t, flag.HasValue())
+	assert.EqS(t, []Endpoint{{Host: "host1", Port: 8080}}, *ptrOpts.Endpoints)
+
+	sb.Reset()
+	_, err = flag.PrintValue(&sb)
+	assert.NoError(t, err)
+	assert.Eq(t, "host1:8080", sb.String())
+}
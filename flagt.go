@@ -61,6 +61,30 @@ type (
 	RegexpNocaseV = FlagBaseV[regexp.Regexp, VPRegexpNocase[regexp.Regexp]]
 )
 
+// predefined flag types for on/off/auto values from command line.
+type (
+	TriStateFlag  = FlagBase[TriState, VPTriState[TriState]]
+	TriStateFlagV = FlagBaseV[TriState, VPTriState[TriState]]
+)
+
+// predefined flag types for Kubernetes style quantities from command line.
+type (
+	Quantity  = FlagBase[int64, VPQuantity[int64]]
+	QuantityV = FlagBaseV[int64, VPQuantity[int64]]
+)
+
+// predefined flag types for comma-separated key sets from command line.
+type (
+	StringSetBool  = FlagBase[map[string]bool, VPStringSetBool[string]]
+	StringSetBoolV = FlagBaseV[map[string]bool, VPStringSetBool[string]]
+)
+
+// predefined flag types for integer ranges from command line.
+type (
+	IntRange  = FlagBase[[]int, VPIntRange[int]]
+	IntRangeV = FlagBaseV[[]int, VPIntRange[int]]
+)
+
 // predefined flag types for slice values from command line.
 type (
 	StringSlice       = FlagBase[[]string, VPSlice[string, VPString[string]]]
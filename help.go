@@ -22,11 +22,108 @@ type HelperTerminal interface {
 	) (int, error)
 }
 
-// HandleHelpRequest calls HandleArgErrorAsHelpRequest with nil error.
+// HelpVerbosity is the verbosity level of a help request.
+type HelpVerbosity uint8
+
+const (
+	HelpVerbosityNone  HelpVerbosity = iota // not a help request.
+	HelpVerbosityBrief                      // brief help requested (e.g. `-h`).
+	HelpVerbosityFull                       // full help requested (e.g. `--help`, `-h -h`).
+)
+
+// HelpVerbosityOf returns the verbosity level of the help request initiated
+// by args[helpArgAt], it is meant to be called in a HelpHandleFunc.
+//
+// Help args (see ParseOptions.IsHelpArg) from args[helpArgAt] up to the
+// first dash are counted, a short one (e.g. `-h`) counts as brief, and a
+// long one (e.g. `--help` and `help`) as full, repeated short ones are full.
+func HelpVerbosityOf(opts *ParseOptions, args []string, helpArgAt int) HelpVerbosity {
+	if helpArgAt < 0 || helpArgAt >= len(args) {
+		return HelpVerbosityNone
+	}
+
+	level := HelpVerbosityNone
+	for _, arg := range args[helpArgAt:] {
+		if arg == "--" {
+			break
+		}
+
+		if !opts.IsHelpArg(arg) {
+			continue
+		}
+
+		if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' {
+			level++
+		} else {
+			level = HelpVerbosityFull
+		}
+
+		if level >= HelpVerbosityFull {
+			return HelpVerbosityFull
+		}
+	}
+
+	return level
+}
+
+// HelpContext describes a help request, it is meant to be created by
+// NewHelpContext in a HelpHandleFunc.
+type HelpContext struct {
+	// Route is the route passed to the HelpHandleFunc.
+	Route Route
+
+	// Target is the Cmd the help is requested for (the target of Route).
+	Target *Cmd
+
+	// Flags are flags accessible from the Target, including LocalFlags of
+	// the Target and Flags inherited from its ancestors.
+	Flags FlagIndexer
+
+	// Subcmd is true if the help is requested for a subcommand named after
+	// the help arg (e.g. `--help sub`), instead of the command before the
+	// help arg (e.g. `sub --help`).
+	Subcmd bool
+}
+
+// NewHelpContext creates a HelpContext from arguments of a HelpHandleFunc.
+func NewHelpContext(route Route, args []string, helpArgAt int) HelpContext {
+	return HelpContext{
+		Route:  route,
+		Target: route.Target(),
+		Flags:  &route,
+		Subcmd: helpArgAt >= 0 && countSubcmdsAfter(route, args[helpArgAt+1:]) != 0,
+	}
+}
+
+// countSubcmdsAfter returns the count of Cmds at the end of the route
+// resolved from leading args.
+func countSubcmdsAfter(route Route, args []string) int {
+	for n := min(len(route)-1, len(args)); n > 0; n-- {
+		matched := true
+		for i, parent := 0, len(route)-1-n; i < n && matched; i, parent = i+1, parent+1 {
+			matched = route[parent].findChild(args[i]) == route[parent+1]
+		}
+
+		if matched {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// HandleHelpRequest is HandleArgErrorAsHelpRequest with nil error, but only
+// writes brief usage text (without `Examples:` and `See Also:` sections) when
+// the HelpVerbosityOf the request is HelpVerbosityBrief (e.g. `-h`).
 func HandleHelpRequest(
 	opts *CmdOptions, route Route, args []string, helpArgAt int,
 ) error {
-	return HandleArgErrorAsHelpRequest(opts, route, args, helpArgAt, nil)
+	var popts *ParseOptions
+	if opts != nil {
+		popts = opts.ParseOptions
+	}
+
+	return handleHelp(opts, route, nil, HelpVerbosityOf(popts, args, helpArgAt))
 }
 
 // HandleArgErrorAsHelpRequest prints the error and usage text of the target
@@ -35,6 +132,12 @@ func HandleHelpRequest(
 func HandleArgErrorAsHelpRequest(
 	opts *CmdOptions, route Route, args []string, badArgAt int, cmdErr error,
 ) error {
+	return handleHelp(opts, route, cmdErr, HelpVerbosityFull)
+}
+
+// handleHelp implements HandleArgErrorAsHelpRequest, the usage text is brief
+// when verbosity is HelpVerbosityBrief.
+func handleHelp(opts *CmdOptions, route Route, cmdErr error, verbosity HelpVerbosity) error {
 	c := route.Target()
 	if c == nil {
 		return cmdErr
@@ -82,6 +185,11 @@ func HandleArgErrorAsHelpRequest(
 		}
 
 		_, err = printlnTargetCmdFlags(out, route, "\n\nFlags:\n", LinePrefix+"  ")
+		if err != nil || verbosity == HelpVerbosityBrief {
+			return cmdErr
+		}
+
+		_, err = printlnCmdSections(out, c, LinePrefix)
 		return cmdErr
 	}
 }
@@ -98,6 +98,12 @@ func (m *CmdHelp) HelplnCmdTerminal(out io.Writer, route Route, linePrefix strin
 		return
 	}
 
+	x, err = printlnCmdSections(out, route.Target(), linePrefix)
+	n += x
+	if err != nil {
+		return
+	}
+
 	x, err = write(out, m.Changelog, "\n\n", "\nChanges:\n\n")
 	n += x
 	return
@@ -146,6 +152,44 @@ func printSubcmds(out io.Writer, children []*Cmd, before, linePrefix string) (n
 	return
 }
 
+// printlnCmdSections writes the optional `Examples:` and `See Also:`
+// sections of the Cmd.
+func printlnCmdSections(out io.Writer, c *Cmd, linePrefix string) (n int, err error) {
+	x, err := printlnSection(out, "\nExamples:\n", c.Examples, linePrefix)
+	n += x
+	if err != nil {
+		return
+	}
+
+	x, err = printlnSection(out, "\nSee Also:\n", c.SeeAlso, linePrefix)
+	n += x
+	return
+}
+
+// printlnSection writes title and then one indented line for each entry of
+// lines, it writes nothing if there is no line.
+func printlnSection(out io.Writer, title string, lines []string, linePrefix string) (n int, err error) {
+	if len(lines) == 0 {
+		return
+	}
+
+	n, err = wstr(out, title)
+	if err != nil {
+		return
+	}
+
+	var x int
+	for _, line := range lines {
+		x, err = write(out, line, "\n", linePrefix, "  ")
+		n += x
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 // printlnTargetCmdFlags writes cli usage text of all flags accessible to this Cmd.
 func printlnTargetCmdFlags(
 	out io.Writer, route Route, before, linePrefix string,
@@ -154,19 +198,19 @@ func printlnTargetCmdFlags(
 		// cursorFlagEnd is the cursor position where flag description aligns.
 		cursorFlagEnd int
 		proute        = noescape(&route)
+		flags         = proute.AllFlags()
 
 		hasShorthand bool
 	)
 
-	for i := 0; ; i++ {
-		info, ok := proute.NthFlag(i)
-		if !ok {
-			break
-		}
-
+	for _, info := range flags {
 		x := utf8.RuneCountInString(info.Name)
 		if x > 1 {
 			x += 2 // `--`
+
+			for _, alias := range info.Aliases {
+				x += 4 + utf8.RuneCountInString(alias) // `, --alias`
+			}
 		} else { // either empty or invalid long name
 			x = 0
 		}
@@ -190,8 +234,7 @@ func printlnTargetCmdFlags(
 			continue
 		}
 
-		typ, ok := flag.Type()
-		if ok && len(typ) != 0 {
+		if typ := flagValueName(flag); len(typ) != 0 {
 			x += utf8.RuneCountInString(typ) + 1 // space between flag name and type
 		}
 
@@ -210,12 +253,7 @@ func printlnTargetCmdFlags(
 			return
 		}
 
-		for i := 0; ; i++ {
-			info, ok := proute.NthFlag(i)
-			if !ok {
-				break
-			}
-
+		for _, info := range flags {
 			_, x, err = printlnValidFlag(out, route, linePrefix, cursorFlagEnd, info, hasShorthand)
 			n += x
 			if err != nil {
@@ -117,6 +117,33 @@ func (h *FlagHelp) HelplnFlagTerminal(
 	return
 }
 
+// A FlagExampler provides usage examples of a flag shown in help.
+type FlagExampler interface {
+	// FlagExamples returns example values (without the flag name).
+	FlagExamples() []string
+}
+
+// A FlagMetavarer provides the placeholder of the flag value shown in help
+// (e.g. `FILE` in `--output FILE`) instead of the value type.
+type FlagMetavarer interface {
+	// FlagMetavar returns the placeholder, return an empty string to use
+	// the value type.
+	FlagMetavar() string
+}
+
+// flagValueName returns the placeholder of the flag value shown in help, it
+// is the FlagMetavar if any, otherwise the flag type.
+func flagValueName(flag Flag) string {
+	if m, ok := flag.(FlagMetavarer); ok {
+		if metavar := m.FlagMetavar(); len(metavar) != 0 {
+			return metavar
+		}
+	}
+
+	typ, _ := flag.Type()
+	return typ
+}
+
 // printlnValidFlag
 func printlnValidFlag(
 	out io.Writer, route Route, linePrefix string,
@@ -149,6 +176,51 @@ func printlnValidFlag(
 	x, err := wstr(out, "\n")
 	n += x
 	cursor = 0
+	if err != nil {
+		return
+	}
+
+	x, err = printlnFlagExamples(out, flag, linePrefix, indent, info)
+	n += x
+	return
+}
+
+// printlnFlagExamples writes examples of the flag (if it is a FlagExampler)
+// one per line aligned with the flag description.
+func printlnFlagExamples(
+	out io.Writer, flag Flag, linePrefix string, indent int, info FlagInfo,
+) (n int, err error) {
+	fe, ok := flag.(FlagExampler)
+	if !ok {
+		return
+	}
+
+	name := "--" + info.Name
+	if len(info.Name) == 0 || IsShorthand(info.Name) {
+		name = "-" + info.Shorthand
+	}
+
+	var x int
+	for _, example := range fe.FlagExamples() {
+		x, err = wstr(out, linePrefix)
+		n += x
+		if err != nil {
+			return
+		}
+
+		x, err = writeSpaces(out, indent)
+		n += x
+		if err != nil {
+			return
+		}
+
+		x, err = wstr(out, "e.g. "+name+" "+example+"\n")
+		n += x
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
 
@@ -216,6 +288,15 @@ func printFlagBasicInfo(
 		}
 
 		cursor += utf8.RuneCountInString(info.Name)
+
+		for _, alias := range info.Aliases {
+			x, err = write(out, alias, "", ", --")
+			n += x
+			cursor += x
+			if err != nil {
+				return
+			}
+		}
 	} else if !hasShort {
 		// defensive check, should have been filtered out
 		return
@@ -227,8 +308,7 @@ func printFlagBasicInfo(
 		return
 	}
 
-	typ, ok := flag.Type()
-	if ok && cursor > 0 && len(typ) != 0 {
+	if typ := flagValueName(flag); cursor > 0 && len(typ) != 0 {
 		x, err = write(out, typ, "", " ")
 		n += x
 		if err != nil {
@@ -259,14 +339,21 @@ func printFlagBasicInfo(
 		}
 	}
 
-	if len(info.DefaultValue) != 0 {
-		x, err = write(out, info.DefaultValue, ")", " (default: ")
+	state := flag.State()
+	if len(info.DefaultValue) != 0 || state.Secret() && !state.ValueChanged() && flag.HasValue() {
+		def := info.DefaultValue
+		if state.Secret() {
+			// never show the secret
+			def = redacted
+		}
+
+		x, err = write(out, def, ")", " (default: ")
 		n += x
 		cursor += x // approx
 		if err != nil {
 			return
 		}
-	} else if !flag.State().ValueChanged() && flag.HasValue() {
+	} else if !state.ValueChanged() && flag.HasValue() {
 		// default value implied by flag state
 		x, err = wstr(out, " (default: ")
 		n += x
@@ -108,3 +108,387 @@ func TestHelper(t *testing.T) {
 	assert.Eq(t, expected, sb.String())
 	assert.Error(t, err)
 }
+
+func TestHelper_FlagExamples(t *testing.T) {
+	var opts struct {
+		Rate string `cli:"rate|r,example=10MB/s,example=1GB/m,#limit the transfer rate"`
+	}
+
+	root := &Cmd{
+		Pattern: "test",
+		Flags:   NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+	}
+
+	flag, ok := root.Flags.FindFlag("rate")
+	assert.True(t, ok)
+	assert.EqS(t, []string{"10MB/s", "1GB/m"}, flag.(FlagExampler).FlagExamples())
+
+	var sb strings.Builder
+	err := HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, ""+
+		"test\n"+
+		"\n"+
+		"Flags:\n"+
+		"  -r --rate str  limit the transfer rate\n"+
+		"                 e.g. --rate 10MB/s\n"+
+		"                 e.g. --rate 1GB/m\n", sb.String())
+}
+
+func TestHelper_FlagMetavar(t *testing.T) {
+	var opts struct {
+		Output string `cli:"output|o,metavar=FILE,#write to the file"`
+		Jobs   int    `cli:"jobs|j,#number of jobs"`
+	}
+
+	root := &Cmd{
+		Pattern: "test",
+		Flags:   NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+	}
+
+	flag, ok := root.Flags.FindFlag("output")
+	assert.True(t, ok)
+	assert.Eq(t, "FILE", flag.(FlagMetavarer).FlagMetavar())
+
+	var sb strings.Builder
+	err := HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, ""+
+		"test\n"+
+		"\n"+
+		"Flags:\n"+
+		"  -o --output FILE  write to the file\n"+
+		"  -j --jobs int     number of jobs\n", sb.String())
+}
+
+func TestHelper_FlagAliases(t *testing.T) {
+	var opts struct {
+		Color string `cli:"color|colour|c,#when to use colors"`
+		Quiet bool   `cli:"quiet|q,#suppress output"`
+	}
+
+	root := &Cmd{
+		Pattern: "test",
+		Flags:   NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+	}
+
+	color, ok := root.Flags.FindFlag("color")
+	assert.True(t, ok)
+	colour, ok := root.Flags.FindFlag("colour")
+	assert.True(t, ok)
+	assert.Eq(t, color, colour)
+
+	info, ok := root.Flags.(FlagIter).NthFlag(0)
+	assert.True(t, ok)
+	assert.Eq(t, "color", info.Name)
+	assert.Eq(t, "c", info.Shorthand)
+	assert.EqS(t, []string{"colour"}, info.Aliases)
+
+	var sb strings.Builder
+	err := HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, ""+
+		"test\n"+
+		"\n"+
+		"Flags:\n"+
+		"  -c --color, --colour str  when to use colors\n"+
+		"  -q --quiet bool           suppress output\n", sb.String())
+}
+
+func TestHelper_SecretDefault(t *testing.T) {
+	var opts struct {
+		Token string `cli:"token,secret,def=hunter2,#api token"`
+		Level string `cli:"level,def=info,#log level"`
+	}
+
+	root := &Cmd{
+		Pattern: "test",
+		Flags:   NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+		LocalFlags: NewMapIndexer().
+			Add(&StringV{Value: "s3cr3t", State_: FlagStateSecret, BriefUsage: "signing key"}, "key"),
+	}
+
+	var sb strings.Builder
+	err := HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, ""+
+		"test\n"+
+		"\n"+
+		"Flags:\n"+
+		"  --key str    signing key (default: <redacted>)\n"+
+		"  --token str  api token (default: <redacted>)\n"+
+		"  --level str  log level (default: info)\n", sb.String())
+
+	for _, test := range []struct {
+		name     string
+		def      string
+		expected []string
+	}{
+		{"token", "hunter2", nil},
+		{"key", "", nil},
+		{"level", "info", []string{"info"}},
+	} {
+		_, flag, ok := FindFlag(&Route{root}, test.name)
+		assert.True(t, ok)
+
+		var tsk CompTask
+		tsk.Init(root, nil, 2, "./test", "--"+test.name, "")
+		tsk.AddFlagValues(false, flag, test.def, true)
+
+		var actual []string
+		for _, item := range tsk.result {
+			actual = append(actual, item.Value)
+		}
+		assert.EqS(t, test.expected, actual)
+	}
+}
+
+func TestHelper_CmdSections(t *testing.T) {
+	var opts struct {
+		Force bool `cli:"force|f,#overwrite existing files"`
+	}
+
+	root := &Cmd{
+		Pattern:    "test",
+		BriefUsage: "copy files",
+		Flags:      NewReflectIndexer(DefaultReflectVPFactory{}, &opts),
+		Examples: []string{
+			"test -f a.txt b.txt",
+			"test a.txt dir/",
+		},
+		SeeAlso: []string{"test-sync"},
+	}
+
+	const expected = "" +
+		"test\n" +
+		"\n" +
+		"copy files\n" +
+		"\n" +
+		"Flags:\n" +
+		"  -f --force bool  overwrite existing files\n" +
+		"\n" +
+		"Examples:\n" +
+		"  test -f a.txt b.txt\n" +
+		"  test a.txt dir/\n" +
+		"\n" +
+		"See Also:\n" +
+		"  test-sync\n"
+
+	var sb strings.Builder
+	err := HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, expected, sb.String())
+
+	root.Extra = &CmdHelp{}
+	sb.Reset()
+	err = HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, expected, sb.String())
+
+	root.Examples, root.SeeAlso = nil, nil
+	sb.Reset()
+	err = HandleHelpRequest(&CmdOptions{Stderr: &sb}, Route{root}, nil, -1)
+	assert.NoError(t, err)
+	assert.Eq(t, ""+
+		"test\n"+
+		"\n"+
+		"copy files\n"+
+		"\n"+
+		"Flags:\n"+
+		"  -f --force bool  overwrite existing files\n", sb.String())
+}
+
+func TestNewHelpContext(t *testing.T) {
+	var ctx HelpContext
+
+	sub := &Cmd{
+		Pattern:    "sub",
+		LocalFlags: NewMapIndexer().Add(&BoolV{}, "force"),
+		Children:   []*Cmd{{Pattern: "leaf"}},
+	}
+	root := &Cmd{
+		Pattern:  "test",
+		Flags:    NewMapIndexer().Add(&BoolV{}, "verbose"),
+		Children: []*Cmd{sub},
+	}
+	opts := &CmdOptions{
+		HandleHelpRequest: func(opts *CmdOptions, route Route, args []string, helpArgAt int) error {
+			ctx = NewHelpContext(route, args, helpArgAt)
+			return nil
+		},
+	}
+
+	for _, test := range []struct {
+		args   []string
+		target string
+		subcmd bool
+	}{
+		{[]string{"--help"}, "test", false},
+		{[]string{"sub", "--help"}, "sub", false},
+		{[]string{"--help", "sub"}, "sub", true},
+		{[]string{"sub", "--help", "leaf"}, "leaf", true},
+		{[]string{"--help", "sub", "leaf"}, "leaf", true},
+		{[]string{"--help", "unknown"}, "test", false},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			ctx = HelpContext{}
+			err := root.Exec(opts, test.args...)
+			assert.ErrorIs(t, ErrHelpHandled{}, err)
+			assert.Eq(t, test.target, ctx.Target.Name())
+			assert.Eq(t, test.subcmd, ctx.Subcmd)
+
+			_, ok := ctx.Flags.FindFlag("verbose")
+			assert.True(t, ok)
+			_, ok = ctx.Flags.FindFlag("force")
+			assert.Eq(t, test.target == "sub", ok)
+		})
+	}
+
+	ctx = NewHelpContext(Route{root}, nil, -1)
+	assert.Eq(t, root, ctx.Target)
+	assert.False(t, ctx.Subcmd)
+}
+
+func TestHelpVerbosityOf(t *testing.T) {
+	var sb strings.Builder
+	root := &Cmd{
+		Pattern:    "test",
+		BriefUsage: "brief usage",
+		Children:   []*Cmd{{Pattern: "foo"}},
+	}
+	opts := &CmdOptions{
+		HandleHelpRequest: func(opts *CmdOptions, route Route, args []string, helpArgAt int) error {
+			switch HelpVerbosityOf(opts.ParseOptions, args, helpArgAt) {
+			case HelpVerbosityBrief:
+				_, _ = sb.WriteString("brief")
+			case HelpVerbosityFull:
+				_, _ = sb.WriteString("full")
+			}
+			return nil
+		},
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-h"}, "brief"},
+		{[]string{"--help"}, "full"},
+		{[]string{"help"}, "full"},
+		{[]string{"-h", "-h"}, "full"},
+		{[]string{"-h", "--", "-h"}, "brief"},
+		{[]string{"foo", "-h"}, "brief"},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			sb.Reset()
+			err := root.Exec(opts, test.args...)
+			assert.ErrorIs(t, ErrHelpHandled{}, err)
+			assert.Eq(t, test.expected, sb.String())
+		})
+	}
+
+	assert.Eq(t, HelpVerbosityNone, HelpVerbosityOf(nil, []string{"-h"}, -1))
+	assert.Eq(t, HelpVerbosityFull, HelpVerbosityOf(&ParseOptions{HelpArgs: []string{"-?"}}, []string{"-?", "-?"}, 0))
+}
+
+func TestHandleHelpRequest_Verbosity(t *testing.T) {
+	root := &Cmd{
+		Pattern:    "test",
+		BriefUsage: "brief usage",
+		Examples:   []string{"test --help"},
+	}
+
+	const brief = "test\n\nbrief usage\n"
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-h"}, brief},
+		{[]string{"--help"}, brief + "\nExamples:\n  test --help\n"},
+		{[]string{"-h", "-h"}, brief + "\nExamples:\n  test --help\n"},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var sb strings.Builder
+			err := root.Exec(&CmdOptions{
+				Stderr:            &sb,
+				HandleHelpRequest: HandleHelpRequest,
+			}, test.args...)
+			assert.ErrorIs(t, ErrHelpHandled{}, err)
+			assert.Eq(t, test.expected, sb.String())
+		})
+	}
+}
+
+func TestParseOptions_HelpArgSpecs(t *testing.T) {
+	popts := &ParseOptions{
+		HelpArgSpecs: []HelpArgSpec{
+			{Arg: "help", Scope: HelpArgScopeCommand},
+			{Arg: "-h", Scope: HelpArgScopeFlag},
+			{Arg: "--help"},
+		},
+	}
+
+	assert.True(t, popts.IsHelpArg("help"))
+	assert.True(t, popts.IsHelpArgAt("help", HelpArgScopeCommand))
+	assert.False(t, popts.IsHelpArgAt("help", HelpArgScopeFlag|HelpArgScopePosArg))
+	assert.True(t, popts.IsHelpArgAt("-h", HelpArgScopeFlag))
+	assert.False(t, popts.IsHelpArgAt("-h", HelpArgScopeCommand))
+	assert.True(t, popts.IsHelpArgAt("--help", HelpArgScopePosArg))
+	assert.False(t, (&ParseOptions{HelpArgSpecs: []HelpArgSpec{}}).IsHelpArg("--help"))
+
+	var (
+		helpArgAt int
+		posArgs   []string
+	)
+	root := &Cmd{
+		Pattern: "test",
+		Children: []*Cmd{{
+			Pattern: "foo",
+			Run: func(opts *CmdOptions, route Route, args, dashArgs []string) error {
+				posArgs = args
+				return nil
+			},
+		}},
+	}
+	opts := &CmdOptions{
+		ParseOptions: popts,
+		HandleHelpRequest: func(opts *CmdOptions, route Route, args []string, at int) error {
+			helpArgAt = at
+			return nil
+		},
+	}
+
+	for _, test := range []struct {
+		args      []string
+		helpArgAt int
+		posArgs   []string
+	}{
+		{[]string{"help"}, 0, nil},
+		{[]string{"foo", "help"}, 1, nil},
+		{[]string{"foo", "-h"}, 1, nil},
+		{[]string{"foo", "x", "-h"}, 2, nil},
+		{[]string{"foo", "x", "help"}, -1, []string{"x", "help"}},
+		{[]string{"foo", "-", "-h"}, 2, nil},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			helpArgAt, posArgs = -1, nil
+			err := root.Exec(opts, test.args...)
+			if test.helpArgAt >= 0 {
+				assert.ErrorIs(t, ErrHelpHandled{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Eq(t, test.helpArgAt, helpArgAt)
+			assert.EqS(t, test.posArgs, posArgs)
+		})
+	}
+
+	t.Run("OutOfScope", func(t *testing.T) {
+		popts.HelpArgSpecs = []HelpArgSpec{{Arg: "-h", Scope: HelpArgScopeCommand}}
+		defer func() { popts.HelpArgSpecs = nil }()
+
+		err := root.Exec(opts, "foo", "-h")
+		assert.ErrorIs(t, &ErrFlagUndefined{Name: "h", At: 1}, err)
+	})
+}
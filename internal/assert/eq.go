@@ -109,6 +109,19 @@ func Eq[T comparable](t testing.TB, expected, actual T, skips ...int) bool {
 	return true
 }
 
+func DeepEq[T any](t testing.TB, expected, actual T) bool {
+	if !reflect.DeepEqual(expected, actual) {
+		_, file, line, ok := runtime.Caller(1)
+		if ok {
+			t.Errorf("%s:%d\nwant %+v\ngot  %+v", file, line, expected, actual)
+		} else {
+			t.Errorf("want %+v\ngot  %+v", expected, actual)
+		}
+		return false
+	}
+	return true
+}
+
 func diff[E any, A any](expected E, actual A) string {
 	wantLines := strings.SplitAfter(fmt.Sprint(expected), "\n")
 	actualLines := strings.SplitAfter(fmt.Sprint(actual), "\n")
@@ -3,7 +3,10 @@
 
 package cli
 
-import "io"
+import (
+	"io"
+	"strings"
+)
 
 type ViolationCode uint32
 
@@ -89,75 +92,23 @@ func (RuleAny) NthEx(Inspector, int) (Violation, bool)          { return Violati
 func (RuleAny) WriteFlagRule(io.Writer, ...string) (int, error) { return 0, nil }
 
 // MultiRule
+//
+// A MultiRule reached again while being evaluated (i.e. it is one of its
+// own Rules, directly or not) is treated as RuleAny to stop the recursion.
 type MultiRule struct {
 	Rules []Rule
 }
 
-func (r *MultiRule) Requires(key string) bool {
-	for _, rule := range r.Rules {
-		if rule.Requires(key) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (r *MultiRule) Contains(key string) bool {
-	for _, rule := range r.Rules {
-		if rule.Contains(key) {
-			return true
-		}
-	}
-
-	return false
-}
+func (r *MultiRule) Requires(key string) bool { return requiresRule(r, key, nil) }
+func (r *MultiRule) Contains(key string) bool { return containsRule(r, key, nil) }
 
 // NthEx implements [Rule].
 func (r *MultiRule) NthEx(f Inspector, i int) (Violation, bool) {
-	for _, rule := range r.Rules {
-		for j := 0; ; j++ {
-			v, ok := rule.NthEx(f, j)
-			if !ok {
-				break
-			}
-
-			if i == 0 {
-				return v, true
-			}
-
-			i--
-		}
-	}
-
-	return Violation{}, false
+	return nthViolation(r, f, i, nil)
 }
 
 func (r *MultiRule) WriteFlagRule(out io.Writer, keys ...string) (n int, err error) {
-	var x int
-	for _, rule := range r.Rules {
-		if len(keys) != 0 && !RuleContainsAny(rule, keys...) {
-			x = 0
-			continue
-		}
-
-		if x > 0 {
-			// last rule wrote at least one tag
-			x, err = wstr(out, " & ")
-			n += x
-			if err != nil {
-				return
-			}
-		}
-
-		x, err = rule.WriteFlagRule(out, keys...)
-		n += x
-		if err != nil {
-			return
-		}
-	}
-
-	return
+	return writeRule(out, r, keys, nil)
 }
 
 // AllOf creates a *RuleAllOf from provided keys.
@@ -434,65 +385,237 @@ type RuleDepends[X, Y, Z Rule] struct {
 	Else Z
 }
 
+func (r *RuleDepends[X, Y, Z]) branches() (ifX, thenY, elseZ Rule) {
+	return r.If, r.Then, r.Else
+}
+
 func (r *RuleDepends[X, Y, Z]) Requires(key string) bool {
-	return r.Then.Requires(key) && r.Else.Requires(key)
+	return requiresRule(r, key, nil)
 }
 
 func (r *RuleDepends[X, Y, Z]) Contains(key string) bool {
-	return r.If.Contains(key) || r.Then.Contains(key) || r.Else.Contains(key)
+	return containsRule(r, key, nil)
 }
 
 // NthEx implements [Rule].
 func (r *RuleDepends[X, Y, Z]) NthEx(f Inspector, i int) (p Violation, ok bool) {
-	if i < 0 || r == nil {
+	if r == nil {
 		return p, false
 	}
 
-	useThen := true
-	// only need to check whether there is violation, so passing `0` is fine.
-	_, ok = r.If.NthEx(f, 0)
-	if ok {
-		useThen = false
-	}
+	return nthViolation(r, f, i, nil)
+}
 
-	if useThen {
-		for k := 0; ; k++ {
-			p, ok = r.Then.NthEx(f, k)
-			if !ok {
-				break
+func (r *RuleDepends[X, Y, Z]) WriteFlagRule(out io.Writer, keys ...string) (n int, err error) {
+	return writeRule(out, r, keys, nil)
+}
+
+// ruleBrancher is implemented by RuleDepends of all type parameters.
+type ruleBrancher interface {
+	Rule
+	branches() (ifX, thenY, elseZ Rule)
+}
+
+// requiresRule is rule.Requires(key) but treats MultiRules and RuleDepends
+// in visiting as RuleAny.
+//
+// The visiting stack is passed down instead of being kept in rules, so that
+// a rule can be evaluated concurrently.
+func requiresRule(rule Rule, key string, visiting []Rule) bool {
+	switch r := rule.(type) {
+	case *MultiRule:
+		if r == nil || sliceContainsRule(visiting, r) {
+			return false
+		}
+
+		visiting = append(visiting, r)
+		for _, sub := range r.Rules {
+			if requiresRule(sub, key, visiting) {
+				return true
 			}
+		}
+
+		return false
+	case ruleBrancher:
+		if sliceContainsRule(visiting, r) {
+			return false
+		}
 
-			if i == 0 {
-				return p, true
+		visiting = append(visiting, r)
+		_, thenY, elseZ := r.branches()
+		return requiresRule(thenY, key, visiting) && requiresRule(elseZ, key, visiting)
+	default:
+		return rule.Requires(key)
+	}
+}
+
+// containsRule is rule.Contains(key) but treats MultiRules and RuleDepends
+// in visiting as RuleAny.
+func containsRule(rule Rule, key string, visiting []Rule) bool {
+	switch r := rule.(type) {
+	case *MultiRule:
+		if r == nil || sliceContainsRule(visiting, r) {
+			return false
+		}
+
+		visiting = append(visiting, r)
+		for _, sub := range r.Rules {
+			if containsRule(sub, key, visiting) {
+				return true
 			}
+		}
 
-			i--
+		return false
+	case ruleBrancher:
+		if sliceContainsRule(visiting, r) {
+			return false
 		}
-	} else {
-		for k := 0; ; k++ {
-			p, ok = r.Else.NthEx(f, k)
-			if !ok {
-				break
+
+		visiting = append(visiting, r)
+		ifX, thenY, elseZ := r.branches()
+		return containsRule(ifX, key, visiting) ||
+			containsRule(thenY, key, visiting) ||
+			containsRule(elseZ, key, visiting)
+	default:
+		return rule.Contains(key)
+	}
+}
+
+// containsAnyRule is RuleContainsAny but treats MultiRules and RuleDepends
+// in visiting as RuleAny.
+func containsAnyRule(rule Rule, keys []string, visiting []Rule) bool {
+	for _, key := range keys {
+		if containsRule(rule, key, visiting) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nthViolation is rule.NthEx(f, i) but treats MultiRules and RuleDepends in
+// visiting as RuleAny.
+func nthViolation(rule Rule, f Inspector, i int, visiting []Rule) (Violation, bool) {
+	switch r := rule.(type) {
+	case *MultiRule:
+		if i < 0 || r == nil || sliceContainsRule(visiting, r) {
+			return Violation{}, false
+		}
+
+		visiting = append(visiting, r)
+		for _, sub := range r.Rules {
+			for j := 0; ; j++ {
+				v, ok := nthViolation(sub, f, j, visiting)
+				if !ok {
+					break
+				}
+
+				if i == 0 {
+					return v, true
+				}
+
+				i--
 			}
+		}
+
+		return Violation{}, false
+	case ruleBrancher:
+		if i < 0 || sliceContainsRule(visiting, r) {
+			return Violation{}, false
+		}
+
+		visiting = append(visiting, r)
+		ifX, thenY, elseZ := r.branches()
+
+		// only need to check whether there is violation, so passing `0` is fine.
+		if _, ok := nthViolation(ifX, f, 0, visiting); ok {
+			return nthViolation(elseZ, f, i, visiting)
+		}
+
+		return nthViolation(thenY, f, i, visiting)
+	default:
+		return rule.NthEx(f, i)
+	}
+}
+
+// writeRule is rule.WriteFlagRule(out, keys...) but treats MultiRules and
+// RuleDepends in visiting as RuleAny.
+func writeRule(out io.Writer, rule Rule, keys []string, visiting []Rule) (n int, err error) {
+	switch r := rule.(type) {
+	case *MultiRule:
+		if r == nil || sliceContainsRule(visiting, r) {
+			return
+		}
+
+		visiting = append(visiting, r)
+		return writeMultiRule(out, r.Rules, keys, visiting)
+	case ruleBrancher:
+		if sliceContainsRule(visiting, r) {
+			return
+		}
+
+		visiting = append(visiting, r)
+		ifX, thenY, elseZ := r.branches()
+		return writeRuleDepends(out, ifX, thenY, elseZ, keys, visiting)
+	default:
+		return rule.WriteFlagRule(out, keys...)
+	}
+}
+
+// writeMultiRule writes rules joined by ` & `.
+func writeMultiRule(out io.Writer, rules []Rule, keys []string, visiting []Rule) (n int, err error) {
+	var (
+		x     int
+		buf   strings.Builder
+		wrote bool
+	)
+
+	for _, rule := range rules {
+		if len(keys) != 0 && !containsAnyRule(rule, keys, visiting) {
+			continue
+		}
 
-			if i == 0 {
-				return p, true
+		// buffer the rule to only write the separator between non-empty
+		// rules.
+		buf.Reset()
+		_, err = writeRule(&buf, rule, keys, visiting)
+		if err != nil {
+			return
+		}
+
+		if buf.Len() == 0 {
+			continue
+		}
+
+		if wrote {
+			x, err = wstr(out, " & ")
+			n += x
+			if err != nil {
+				return
 			}
+		}
 
-			i--
+		x, err = wstr(out, buf.String())
+		n += x
+		if err != nil {
+			return
 		}
+
+		wrote = true
 	}
 
-	return Violation{}, false
+	return
 }
 
-func (r *RuleDepends[X, Y, Z]) WriteFlagRule(out io.Writer, keys ...string) (n int, err error) {
+// writeRuleDepends writes branches of a RuleDepends in the form of
+// `(if X; then Y; else Z)`.
+func writeRuleDepends(out io.Writer, ifX, thenY, elseZ Rule, keys []string, visiting []Rule) (n int, err error) {
 	n, err = wstr(out, "(if ")
 	if err != nil {
 		return
 	}
 
-	x, err := r.If.WriteFlagRule(out, keys...)
+	x, err := writeRule(out, ifX, keys, visiting)
 	n += x
 	if err != nil {
 		return
@@ -512,7 +635,7 @@ func (r *RuleDepends[X, Y, Z]) WriteFlagRule(out io.Writer, keys ...string) (n i
 		return
 	}
 
-	x, err = r.Then.WriteFlagRule(out, keys...)
+	x, err = writeRule(out, thenY, keys, visiting)
 	n += x
 	if err != nil {
 		return
@@ -532,7 +655,7 @@ func (r *RuleDepends[X, Y, Z]) WriteFlagRule(out io.Writer, keys ...string) (n i
 		return
 	}
 
-	x, err = r.Else.WriteFlagRule(out)
+	x, err = writeRule(out, elseZ, nil, visiting)
 	n += x
 	if err != nil {
 		return
@@ -549,6 +672,119 @@ func (r *RuleDepends[X, Y, Z]) WriteFlagRule(out io.Writer, keys ...string) (n i
 	return
 }
 
+// ValidateRule checks rule and all rules nested in it, it returns an
+// *ErrContradictoryRule for the first RuleDepends having a branch that can
+// never be satisfied when it is used:
+//
+//   - `Then` requires more than one key of a RuleOneOf in `If`.
+//   - `Else` requires all keys of a RuleAllOf `If`, or any key of a
+//     RuleAnyOf `If`.
+func ValidateRule(rule Rule) error {
+	return validateRule(rule, nil)
+}
+
+func validateRule(rule Rule, visiting []Rule) error {
+	switch r := rule.(type) {
+	case *MultiRule:
+		if r == nil || sliceContainsRule(visiting, r) {
+			return nil
+		}
+
+		visiting = append(visiting, r)
+		for _, sub := range r.Rules {
+			if err := validateRule(sub, visiting); err != nil {
+				return err
+			}
+		}
+	case ruleBrancher:
+		if sliceContainsRule(visiting, r) {
+			return nil
+		}
+
+		ifX, thenY, elseZ := r.branches()
+		if err := checkRuleBranches(ifX, thenY, elseZ); err != nil {
+			return err
+		}
+
+		visiting = append(visiting, r)
+		for _, sub := range [...]Rule{ifX, thenY, elseZ} {
+			if err := validateRule(sub, visiting); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRuleBranches checks whether thenY or elseZ contradicts ifX.
+func checkRuleBranches(ifX, thenY, elseZ Rule) error {
+	conds := []Rule{ifX}
+	if m, ok := ifX.(*MultiRule); ok && m != nil {
+		conds = m.Rules
+	}
+
+	for _, cond := range conds {
+		oneOf, ok := cond.(*RuleOneOf)
+		if !ok || oneOf == nil {
+			continue
+		}
+
+		keys := requiredKeys(thenY, oneOf.Keys)
+		if len(keys) > 1 {
+			return &ErrContradictoryRule{Branch: "then", If: cond, Keys: keys}
+		}
+	}
+
+	switch cond := ifX.(type) {
+	case *RuleAllOf:
+		if cond == nil {
+			break
+		}
+
+		keys := requiredKeys(elseZ, cond.Keys)
+		if len(keys) != 0 && len(keys) == len(requiredKeys(cond, cond.Keys)) {
+			return &ErrContradictoryRule{Branch: "else", If: cond, Keys: keys}
+		}
+	case *RuleAnyOf:
+		if cond == nil {
+			break
+		}
+
+		keys := requiredKeys(elseZ, cond.Keys)
+		if len(keys) != 0 {
+			return &ErrContradictoryRule{Branch: "else", If: cond, Keys: keys}
+		}
+	}
+
+	return nil
+}
+
+// requiredKeys returns non-empty keys required by rule.
+func requiredKeys(rule Rule, keys []string) (ret []string) {
+	if rule == nil {
+		return nil
+	}
+
+	for _, key := range keys {
+		if len(key) != 0 && rule.Requires(key) {
+			ret = append(ret, key)
+		}
+	}
+
+	return
+}
+
+func sliceContainsRule(rules []Rule, rule Rule) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
 func sliceContains(ss []string, keys ...string) bool {
 	for _, x := range ss {
 		for _, y := range keys {
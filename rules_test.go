@@ -5,6 +5,7 @@ package cli
 
 import (
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/primecitizens/cli/internal/assert"
@@ -49,6 +50,30 @@ func TestRule_WriteFlagRule(t *testing.T) {
 	}
 }
 
+func TestMultiRule_WriteFlagRule_Filtered(t *testing.T) {
+	rule := MergeFlagRules(AllOf("foo", "bar"), AnyOf("woo"), OneOf("foo", "zoo"))
+
+	for _, test := range []struct {
+		keys     []string
+		expected string
+	}{
+		{nil, "allof[--foo, --bar] & anyof[--woo] & oneof[--foo, --zoo]"},
+		{[]string{"woo"}, "anyof[--woo]"},
+		{[]string{"foo"}, "allof[--foo, --bar] & oneof[--foo, --zoo]"},
+		{[]string{"zoo"}, "oneof[--foo, --zoo]"},
+		{[]string{"bar", "woo", "zoo"}, "allof[--foo, --bar] & anyof[--woo] & oneof[--foo, --zoo]"},
+		{[]string{"none"}, ""},
+	} {
+		t.Run(strings.Join(test.keys, ","), func(t *testing.T) {
+			var sb strings.Builder
+			n, err := rule.WriteFlagRule(&sb, test.keys...)
+			assert.NoError(t, err)
+			assert.Eq(t, test.expected, sb.String())
+			assert.Eq(t, len(test.expected), n)
+		})
+	}
+}
+
 func TestRule_Requires(t *testing.T) {
 	for _, test := range []struct {
 		contains bool
@@ -241,3 +266,83 @@ func nV(t *testing.T, rule Rule, reason ViolationCode, n int) {
 		t.Errorf("expecting %d violations, got %d", n, i)
 	}
 }
+
+func TestValidateRule(t *testing.T) {
+	self := &MultiRule{}
+	self.Rules = []Rule{AllOf("a"), self}
+
+	for _, test := range []struct {
+		rule Rule
+		err  error
+	}{
+		{AllOf("a", "b"), nil},
+		{DependOn(OneOf("a", "b"), AllOf("a", "c"), RuleAny{}), nil},
+		{DependOn(AllOf("a", "b"), RuleAny{}, AllOf("a")), nil},
+		{DependOn(OneOf("a", "b"), AllOf("a", "b"), RuleAny{}),
+			&ErrContradictoryRule{Branch: "then", If: OneOf("a", "b"), Keys: []string{"a", "b"}}},
+		{DependOn(MergeFlagRules(AnyOf("c"), OneOf("a", "b")), AllOf("b", "a"), RuleAny{}),
+			&ErrContradictoryRule{Branch: "then", If: OneOf("a", "b"), Keys: []string{"a", "b"}}},
+		{DependOn(AllOf("a", "b"), RuleAny{}, AllOf("a", "b", "c")),
+			&ErrContradictoryRule{Branch: "else", If: AllOf("a", "b"), Keys: []string{"a", "b"}}},
+		{DependOn(AnyOf("a", "b"), RuleAny{}, AllOf("b")),
+			&ErrContradictoryRule{Branch: "else", If: AnyOf("a", "b"), Keys: []string{"b"}}},
+		{MergeFlagRules(AllOf("c"), DependOn(AnyOf("a"), RuleAny{}, AnyOf("a"))),
+			&ErrContradictoryRule{Branch: "else", If: AnyOf("a"), Keys: []string{"a"}}},
+		{self, nil},
+	} {
+		t.Run("", func(t *testing.T) {
+			assert.DeepEq(t, test.err, ValidateRule(test.rule))
+		})
+	}
+}
+
+func TestMultiRule_SelfReferential(t *testing.T) {
+	self := &MultiRule{}
+	self.Rules = []Rule{AllOf("a"), DependOn(RuleAny{}, self, RuleAny{})}
+
+	var sb strings.Builder
+	_, err := self.WriteFlagRule(&sb)
+	assert.NoError(t, err)
+	assert.Eq(t, "allof[-a] & (if nop; then nop; else nop)", sb.String())
+
+	assert.True(t, self.Contains("a"))
+	assert.False(t, self.Contains("b"))
+	assert.True(t, self.Requires("a"))
+
+	v, ok := self.NthEx(testInspector{}, 0)
+	assert.True(t, ok)
+	assert.Eq(t, Violation{Key: "a", Reason: ViolationCodeEmptyAllOf}, v)
+
+	_, ok = self.NthEx(testInspector{}, 1)
+	assert.False(t, ok)
+}
+
+func TestMultiRule_Concurrent(t *testing.T) {
+	self := &MultiRule{}
+	self.Rules = []Rule{AllOf("a"), DependOn(RuleAny{}, self, RuleAny{})}
+
+	var (
+		wg sync.WaitGroup
+		ok [16]bool
+	)
+	for i := range ok {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				v, found := self.NthEx(testInspector{}, 0)
+				if !found || v.Key != "a" || !self.Contains("a") || !self.Requires("a") {
+					return
+				}
+			}
+
+			ok[i] = true
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range ok {
+		assert.True(t, ok[i])
+	}
+}
@@ -69,6 +69,10 @@ __start_999() {
         cmd+=("${fsargs[@]}")
         "${cmd[@]}"
         ;;
+      '#'*)
+        __999_debug "show message: ${line:1}"
+        printf '\n%s' "${line:1}" >&2
+        ;;
       *)
         __999_debug "add completion: $line"
         COMPREPLY+=("$line")
@@ -41,6 +41,10 @@ _999() {
       done < <(echo "$line")
     elif [[ -n "$line" ]]; then
       case "$line" in
+      ::*)
+        __999_debug "call _message ${line:2}"
+        _message -r "${line:2}"
+        ;;
       :*)
         __999_debug "call _arguments ${line:1} ${extra_flags[*]}"
         ret=1
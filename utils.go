@@ -6,6 +6,7 @@ package cli
 import (
 	"io"
 	"math"
+	"math/bits"
 	"strconv"
 	"strings"
 	"time"
@@ -50,7 +51,7 @@ func replaceFuncW(
 	)
 }
 
-// replaceFuncWEx is like replaceFuncWEx, but takes an extra argument.
+// replaceFuncWEx is like replaceFuncW, but takes an extra argument.
 func replaceFuncWEx[Arg any](
 	w io.Writer,
 	text string,
@@ -59,9 +60,9 @@ func replaceFuncWEx[Arg any](
 	writeReplace func(w io.Writer, matched string, arg Arg) (int, error),
 ) (n int, err error) {
 	var (
-		x, lastMatchedIdx int
-		lastMismatchedIdx = len(text)
-		wasMatched        = true
+		x, lastMatchedIdx, lastMismatchedIdx int
+
+		wasMatched = true
 	)
 
 	for i, c := range text {
@@ -98,7 +99,9 @@ func replaceFuncWEx[Arg any](
 	}
 
 	if wasMatched {
-		x, err = writeReplace(w, text[lastMismatchedIdx:], arg)
+		if lastMismatchedIdx < len(text) {
+			x, err = writeReplace(w, text[lastMismatchedIdx:], arg)
+		}
 	} else {
 		x, err = wstr(w, text[lastMatchedIdx:])
 	}
@@ -149,6 +152,9 @@ func parseNum(str string, assumeFloat bool) (neg bool, uv uint64, isFloat bool,
 	return neg, math.Float64bits(math.NaN()), true, strconv.ErrSyntax
 }
 
+// durationUnits are unit suffixes accepted by parseDuration.
+var durationUnits = [...]string{"ns", "us", "ms", "s", "m", "h", "d", "w", "mt", "y"}
+
 func parseDuration(s string, base time.Time) (neg bool, dur uint64, err error) {
 	var (
 		n      = len(s)
@@ -254,6 +260,16 @@ func parseDuration(s string, base time.Time) (neg bool, dur uint64, err error) {
 			}
 
 			isFloat = true
+			continue
+		case '-': // -5s
+			if i != start || i+1 == n {
+				return false, 0, &ErrInvalidValue{
+					Type:    "numeric",
+					Partial: true,
+					Value:   s,
+				}
+			}
+
 			continue
 		default:
 			if c < '0' || c > '9' {
@@ -409,6 +425,14 @@ func parseSize(s string) (neg bool, sz uint64, err error) {
 		c = s[i]
 
 		switch c {
+		case '-': // -1k
+			if i != start || i+1 == len(s) {
+				return false, 0, &ErrInvalidValue{
+					Type:    "numeric",
+					Partial: true,
+					Value:   s,
+				}
+			}
 		default:
 			if c < '0' || c > '9' {
 				return false, 0, &ErrInvalidValue{
@@ -545,8 +569,17 @@ const (
 	sizeStaticLev = 64
 )
 
+// SimilarityThreshold returns the maximum Levenshtein distance (exclusive)
+// for a name to be considered similar to the known one when suggesting
+// fuzzy matches in completion.
+//
+// Defaults to min(3, len(known)).
+var SimilarityThreshold = func(known string) int {
+	return min(3, len(known))
+}
+
 // isSimilar returns true if the Levenshtein distance between known and
-// toCompare is less than min(3, len(known)).
+// toCompare is less than SimilarityThreshold(known).
 //
 // The result can only be true when min(len(known), min(toCompare)) < 64.
 func isSimilar(known, toCompare string, nocase bool) bool {
@@ -558,12 +591,12 @@ func isSimilar(known, toCompare string, nocase bool) bool {
 	case len(known) < sizeStaticLev:
 		// optimize for more inner loop.
 		if len(toCompare) < len(known) {
-			return max63Lev(toCompare, known, nocase) < min(3, len(known))
+			return max63Lev(toCompare, known, nocase) < SimilarityThreshold(known)
 		} else {
-			return max63Lev(known, toCompare, nocase) < min(3, len(known))
+			return max63Lev(known, toCompare, nocase) < SimilarityThreshold(known)
 		}
 	case len(toCompare) < sizeStaticLev:
-		return max63Lev(known, toCompare, nocase) < min(3, len(known))
+		return max63Lev(known, toCompare, nocase) < SimilarityThreshold(known)
 	default:
 		return false
 	}
@@ -603,7 +636,7 @@ func max63Lev(y, max63 string, nocase bool) int {
 			runeY, dY = utf8.DecodeRuneInString(y[offY:])
 		}
 
-		mat[0][0] = row
+		mat[1][0] = row
 		for col, offX = 1, 0; offX < len(max63); col, offX = col+1, offX+dX {
 			if runeX = rune(max63[offX]); runeX < utf8.RuneSelf {
 				dX = 1
@@ -626,6 +659,17 @@ func max63Lev(y, max63 string, nocase bool) int {
 	return mat[0][col-1]
 }
 
+// hasAnyPrefix returns true if any of strs has the prefix.
+func hasAnyPrefix(strs []string, prefix string) bool {
+	for _, s := range strs {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -633,3 +677,239 @@ func min(a, b int) int {
 
 	return b
 }
+
+// endsWithDigit returns true if the last byte of s is a decimal digit.
+func endsWithDigit(s string) bool {
+	return len(s) != 0 && s[len(s)-1] >= '0' && s[len(s)-1] <= '9'
+}
+
+// displayWidth returns the count of terminal columns s occupies, assuming
+// East Asian wide runes take two columns (see runeWidth).
+func displayWidth(s string) (n int) {
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+
+	return
+}
+
+// runeWidth returns 2 for East Asian wide and fullwidth runes (including
+// common emoji), 1 for others.
+//
+// It only checks common ranges instead of the full Unicode table.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x1100:
+		return 1
+	case r <= 0x115f, // Hangul Jamo
+		0x2e80 <= r && r <= 0x303e,   // CJK Radicals ... CJK Symbols and Punctuation
+		0x3041 <= r && r <= 0x33ff,   // Hiragana ... CJK Compatibility
+		0x3400 <= r && r <= 0x4dbf,   // CJK Unified Ideographs Extension A
+		0x4e00 <= r && r <= 0x9fff,   // CJK Unified Ideographs
+		0xa000 <= r && r <= 0xa4cf,   // Yi Syllables, Yi Radicals
+		0xac00 <= r && r <= 0xd7a3,   // Hangul Syllables
+		0xf900 <= r && r <= 0xfaff,   // CJK Compatibility Ideographs
+		0xfe30 <= r && r <= 0xfe4f,   // CJK Compatibility Forms
+		0xff00 <= r && r <= 0xff60,   // Fullwidth Forms
+		0xffe0 <= r && r <= 0xffe6,   // Fullwidth Signs
+		0x1f300 <= r && r <= 0x1f6ff, // Misc Symbols and Pictographs ... Transport and Map Symbols
+		0x1f900 <= r && r <= 0x1f9ff, // Supplemental Symbols and Pictographs
+		0x20000 <= r && r <= 0x3fffd: // CJK Unified Ideographs Extension B ...
+		return 2
+	default:
+		return 1
+	}
+}
+
+// truncateWidth returns the longest prefix of s not exceeding max columns.
+func truncateWidth(s string, max int) string {
+	var n int
+	for i, r := range s {
+		n += runeWidth(r)
+		if n > max {
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+// parseQuantity parses a Kubernetes style quantity (e.g. "100m", "2Gi",
+// "1.5k", "1e3") to milli-units, non-integer milli-units are rounded up.
+//
+// Suffixes:
+//   - binary: Ki, Mi, Gi, Ti, Pi
+//   - decimal: m, k, M, G, T, P
+//   - decimal exponent: e<n>, E<n>
+//
+// Suffixes E and Ei are not supported as one exa in milli-units overflows
+// int64.
+func parseQuantity(s string) (milli int64, err error) {
+	bad := &ErrInvalidValue{
+		Type:  "quantity",
+		Value: s,
+	}
+
+	var (
+		neg   bool
+		num   = s
+		mant  uint64
+		scale = 3 // to milli-units
+		pow2  uint
+		ok    bool
+	)
+
+	if len(num) != 0 && (num[0] == '-' || num[0] == '+') {
+		neg = num[0] == '-'
+		num = num[1:]
+	}
+
+	// split number and suffix
+	i := 0
+	for ; i < len(num) && (num[i] >= '0' && num[i] <= '9' || num[i] == '.'); i++ {
+	}
+	num, suffix := num[:i], num[i:]
+
+	intPart, fracPart, _ := strings.Cut(num, ".")
+	if len(intPart)+len(fracPart) == 0 || strings.IndexByte(fracPart, '.') != -1 {
+		return 0, bad
+	}
+
+	switch suffix {
+	case "":
+	case "m":
+		scale -= 3
+	case "k":
+		scale += 3
+	case "M":
+		scale += 6
+	case "G":
+		scale += 9
+	case "T":
+		scale += 12
+	case "P":
+		scale += 15
+	case "Ki":
+		pow2 = 10
+	case "Mi":
+		pow2 = 20
+	case "Gi":
+		pow2 = 30
+	case "Ti":
+		pow2 = 40
+	case "Pi":
+		pow2 = 50
+	default:
+		if suffix[0] != 'e' && suffix[0] != 'E' {
+			return 0, bad
+		}
+
+		exp, err := strconv.ParseInt(suffix[1:], 10, 8)
+		if err != nil {
+			return 0, bad
+		}
+
+		scale += int(exp)
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	for _, c := range intPart + fracPart {
+		mant, ok = mulAdd64(mant, 10, uint64(c-'0'))
+		if !ok {
+			return 0, bad
+		}
+	}
+	scale -= len(fracPart)
+
+	if mant != 0 {
+		if pow2 != 0 {
+			if bits.Len64(mant)+int(pow2) > 64 {
+				return 0, bad
+			}
+			mant <<= pow2
+		}
+
+		for ; scale > 0; scale-- {
+			mant, ok = mulAdd64(mant, 10, 0)
+			if !ok {
+				return 0, bad
+			}
+		}
+
+		for ; scale < 0; scale++ {
+			mant = mant/10 + min64(mant%10, 1) // round up
+		}
+	}
+
+	if neg {
+		if mant > 1<<63 {
+			return 0, bad
+		}
+		return -int64(mant), nil
+	}
+
+	if mant > math.MaxInt64 {
+		return 0, bad
+	}
+	return int64(mant), nil
+}
+
+// appendQuantity appends the canonical text of milli-units to buf, it uses
+// the largest decimal suffix dividing the value, then the largest binary
+// suffix, otherwise the `m` suffix.
+func appendQuantity(buf []byte, milli int64) []byte {
+	v := uint64(milli)
+	if milli < 0 {
+		buf = append(buf, '-')
+		v = -v
+	}
+
+	if v%1000 != 0 {
+		buf = strconv.AppendUint(buf, v, 10)
+		return append(buf, 'm')
+	}
+
+	v /= 1000
+	if v == 0 {
+		return append(buf, '0')
+	}
+
+	const decimal = "kMGTP"
+	i := -1
+	for ; i+1 < len(decimal) && v%1000 == 0; i++ {
+		v /= 1000
+	}
+	if i >= 0 {
+		buf = strconv.AppendUint(buf, v, 10)
+		return append(buf, decimal[i])
+	}
+
+	const binary = "KMGTP"
+	for ; i+1 < len(binary) && v%1024 == 0; i++ {
+		v /= 1024
+	}
+
+	buf = strconv.AppendUint(buf, v, 10)
+	if i >= 0 {
+		buf = append(buf, binary[i], 'i')
+	}
+	return buf
+}
+
+// mulAdd64 returns a*b+c, ok is false on overflow.
+func mulAdd64(a, b, c uint64) (_ uint64, ok bool) {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return 0, false
+	}
+
+	sum, carry := bits.Add64(lo, c, 0)
+	return sum, carry == 0
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
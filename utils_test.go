@@ -14,6 +14,11 @@ import (
 )
 
 func TestReplaceFuncW(t *testing.T) {
+	isDollar := func(r rune) bool { return r == '$' }
+	escape := func(w io.Writer, s string) (int, error) {
+		return wstr(w, strings.Repeat("\\", len(s))+s)
+	}
+
 	for _, test := range []struct {
 		text     string
 		match    func(rune) bool
@@ -26,6 +31,12 @@ func TestReplaceFuncW(t *testing.T) {
 		{"foobar", unicode.IsLetter,
 			func(w io.Writer, s string) (int, error) { return wstr(w, "[letters]") },
 			"[letters]"},
+		{"$HOME", isDollar, escape, "\\$HOME"},
+		{"$HOME/x", isDollar, escape, "\\$HOME/x"},
+		{"$$a$", isDollar, escape, "\\\\$$a\\$"},
+		{"", isDollar,
+			func(w io.Writer, s string) (int, error) { return wstr(w, "[unexpected]") },
+			""},
 	} {
 		var sb strings.Builder
 		_, err := replaceFuncW(&sb,
@@ -85,6 +96,8 @@ func TestParseDuration(t *testing.T) {
 		{false, "1.1y", 0},
 		{false, "1.1M", 0},
 		{false, "1.1.1", 0},
+		{false, "-", 0},
+		{false, "1-s", 0},
 	} {
 		t.Run(test.dur, func(t *testing.T) {
 			neg, ret, err := parseDuration(test.dur, base)
@@ -97,6 +110,26 @@ func TestParseDuration(t *testing.T) {
 			}
 		})
 	}
+
+	// negative values and negative parts.
+	for _, test := range []struct {
+		dur      string
+		neg      bool
+		expected uint64
+	}{
+		{"-5s", true, 5 * SECOND},
+		{"-1.5m", true, 90 * SECOND},
+		{"1h-5m", false, 55 * MINUTE},
+		{"-1h5m", true, 55 * MINUTE},
+		{"5m-1h", true, 55 * MINUTE},
+	} {
+		t.Run(test.dur, func(t *testing.T) {
+			neg, ret, err := parseDuration(test.dur, base)
+			assert.NoError(t, err)
+			assert.Eq(t, test.neg, neg)
+			assert.Eq(t, test.expected, ret)
+		})
+	}
 }
 
 func TestParseTime(t *testing.T) {
@@ -176,6 +209,8 @@ func TestParseSize(t *testing.T) {
 		{false, "pp", 0},
 		{false, "p2p", 0},
 		{false, "1.1.1", 0},
+		{false, "-", 0},
+		{false, "1-k", 0},
 	} {
 		t.Run(test.sz, func(t *testing.T) {
 			neg, ret, err := parseSize(test.sz)
@@ -189,4 +224,69 @@ func TestParseSize(t *testing.T) {
 		})
 
 	}
+
+	// negative values and negative parts.
+	for _, test := range []struct {
+		sz       string
+		neg      bool
+		expected uint64
+	}{
+		{"-1k", true, KB},
+		{"-1.5m", true, MB + 512*KB},
+		{"1k-1b", false, KB - B},
+		{"1k-2k", true, KB},
+	} {
+		t.Run(test.sz, func(t *testing.T) {
+			neg, ret, err := parseSize(test.sz)
+			assert.NoError(t, err)
+			assert.Eq(t, test.neg, neg)
+			assert.Eq(t, test.expected, ret)
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected []string
+		bad      error
+	}{
+		{"", nil, nil},
+		{"  # comment", nil, nil},
+		{"foo  --bar=1\tx", []string{"foo", "--bar=1", "x"}, nil},
+		{`a 'b c' "d \"e\" \n" f\ g`, []string{"a", "b c", `d "e" \n`, "f g"}, nil},
+		{`a''b "" x#y # z`, []string{"ab", "", "x#y"}, nil},
+		{`a 'b`, nil, &ErrUnterminatedQuote{Quote: '\'', At: 2}},
+		{`a "b\"`, nil, &ErrUnterminatedQuote{Quote: '"', At: 2}},
+	} {
+		t.Run(test.line, func(t *testing.T) {
+			args, err := SplitArgs(test.line)
+			if test.bad != nil {
+				assert.ErrorIs(t, test.bad, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqS(t, test.expected, args)
+		})
+	}
+}
+
+func TestMax63Lev(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"build", "build", 0},
+		{"build", "buld", 1},
+		{"build", "biuld", 2},
+		{"abc", "abd", 1},
+		{"kitten", "sitting", 3},
+		{"Build", "build", 0},
+	} {
+		t.Run(test.a+"-"+test.b, func(t *testing.T) {
+			assert.Eq(t, test.expected, max63Lev(test.a, test.b, true))
+			assert.Eq(t, test.expected, max63Lev(test.b, test.a, true))
+		})
+	}
 }
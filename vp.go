@@ -27,6 +27,16 @@ type VP[T any] interface {
 	PrintValue(out io.Writer, value T) (int, error)
 }
 
+// VPChoices is an optional interface for VPs only accepting a fixed set of
+// args, FlagReflect suggests these args in completion when there is no
+// `comp` option.
+//
+// NOTE: The method is not named Choices as VPEnum has a field of that name.
+type VPChoices interface {
+	// ValueChoices returns all args accepted.
+	ValueChoices() []string
+}
+
 // VPType represents the type a VP is handling.
 //
 // It is limited to one of following types:
@@ -68,6 +78,8 @@ const (
 	VPTypeTimestampUnixNano
 	VPTypeRegexp
 	VPTypeRegexpNocase
+	VPTypeTriState
+	VPTypeQuantity
 
 	VPTypeScalarMAX
 
@@ -113,6 +125,10 @@ func (t VPType) String() string {
 			return "time"
 		case VPTypeRegexp, VPTypeRegexpNocase:
 			return "regexp"
+		case VPTypeTriState:
+			return "tristate"
+		case VPTypeQuantity:
+			return "quantity"
 		}
 	case VPTypeVariantSlice:
 		switch t & VPTypeElemScalarMASK {
@@ -134,6 +150,10 @@ func (t VPType) String() string {
 			return "[]time"
 		case VPTypeRegexp, VPTypeRegexpNocase:
 			return "[]regexp"
+		case VPTypeTriState:
+			return "[]tristate"
+		case VPTypeQuantity:
+			return "[]quantity"
 		}
 	case VPTypeVariantSum:
 		switch t & VPTypeElemScalarMASK {
@@ -6,7 +6,12 @@ package cli
 import (
 	"io"
 	"math"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -37,6 +42,357 @@ func (VPString[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool)
 	return nil
 }
 
+// VPEnum for types compatible with string, but only accepts args listed in
+// Choices.
+type VPEnum[T ~string] struct{ Choices []string }
+
+func (VPEnum[T]) Type() VPType                                { return VPTypeString }
+func (VPEnum[T]) HasValue(v *T) bool                          { return v != nil && len(*v) != 0 }
+func (VPEnum[T]) PrintValue(out io.Writer, v *T) (int, error) { return wstr(out, string(*v)) }
+
+// ValueChoices implements [VPChoices].
+func (vp VPEnum[T]) ValueChoices() []string { return vp.Choices }
+
+func (vp VPEnum[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	for _, c := range vp.Choices {
+		if c != arg {
+			continue
+		}
+
+		if set {
+			*out = T(arg)
+		}
+		return nil
+	}
+
+	return &ErrInvalidValue{
+		Type:  "enum",
+		Value: arg,
+	}
+}
+
+// VPSecretSource for types compatible with string, it resolves secret values
+// from sources named by args to keep secrets out of argv:
+//
+//   - `env:<VAR>`: value of the environment variable VAR.
+//   - `file:<path>`: content of the file.
+//   - `-`: content read from stdin.
+//
+// One trailing newline of the content is trimmed. Environment variables and
+// stdin are accessed via ParseOptions.LookupEnv and ParseOptions.Stdin.
+//
+// Sources are only resolved when setting the value, and PrintValue writes
+// `<redacted>` instead of the secret.
+type VPSecretSource[T ~string] struct{}
+
+func (VPSecretSource[T]) Type() VPType       { return VPTypeString }
+func (VPSecretSource[T]) HasValue(v *T) bool { return v != nil && len(*v) != 0 }
+
+func (VPSecretSource[T]) PrintValue(out io.Writer, v *T) (int, error) {
+	if v == nil || len(*v) == 0 {
+		return 0, nil
+	}
+
+	return wstr(out, redacted)
+}
+
+func (VPSecretSource[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
+	var (
+		kind, src, _ = strings.Cut(arg, ":")
+		data         []byte
+	)
+
+	switch {
+	case arg == "-":
+		if set {
+			data, err = io.ReadAll(opts.stdin())
+		}
+	case kind == "env" && len(src) != 0:
+		if set {
+			value, ok := opts.lookupEnv(src)
+			if !ok {
+				return &ErrInvalidValue{
+					Type:  "secret source",
+					Value: arg,
+				}
+			}
+
+			data = []byte(value)
+		}
+	case kind == "file" && len(src) != 0:
+		if set {
+			data, err = os.ReadFile(src)
+		}
+	default:
+		return &ErrInvalidValue{
+			Type:  "secret source",
+			Value: arg,
+		}
+	}
+
+	if err != nil || !set {
+		return
+	}
+
+	secret := strings.TrimSuffix(string(data), "\n")
+	*out = T(strings.TrimSuffix(secret, "\r"))
+	return nil
+}
+
+// VPEmail for email addresses, the value is parsed by mail.ParseAddress
+// and stored without display name (e.g. `Jane <jane@example.com>` is
+// stored as `jane@example.com`).
+type VPEmail[T ~string] struct{}
+
+func (VPEmail[T]) Type() VPType                                { return VPTypeString }
+func (VPEmail[T]) HasValue(v *T) bool                          { return v != nil && len(*v) != 0 }
+func (VPEmail[T]) PrintValue(out io.Writer, v *T) (int, error) { return wstr(out, string(*v)) }
+
+func (VPEmail[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	addr, err := mail.ParseAddress(arg)
+	if err != nil {
+		return &ErrInvalidValue{
+			Type:  "email",
+			Value: arg,
+		}
+	}
+
+	if set {
+		*out = T(addr.Address)
+	}
+
+	return nil
+}
+
+// VPGlob for glob patterns, the pattern is validated by filepath.Match and
+// stored as is.
+type VPGlob[T ~string] struct{}
+
+func (VPGlob[T]) Type() VPType                                { return VPTypeString }
+func (VPGlob[T]) HasValue(v *T) bool                          { return v != nil && len(*v) != 0 }
+func (VPGlob[T]) PrintValue(out io.Writer, v *T) (int, error) { return wstr(out, string(*v)) }
+
+func (VPGlob[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	// filepath.Match only reports ErrBadPattern after reaching the bad part
+	// of the pattern, match against an empty name to check the whole pattern.
+	_, err := filepath.Match(arg, "")
+	if err != nil {
+		return &ErrInvalidValue{
+			Type:  "glob",
+			Value: arg,
+		}
+	}
+
+	if set {
+		*out = T(arg)
+	}
+
+	return nil
+}
+
+// Semver is a semantic version in format
+// `MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]` (see https://semver.org).
+type Semver struct {
+	Major, Minor, Patch uint64
+
+	// Pre is the pre-release part without the leading `-` (e.g. `rc.1`).
+	Pre string
+
+	// Build is the build metadata without the leading `+` (e.g. `build.5`).
+	Build string
+}
+
+// ParseSemver parses s as a Semver, an optional `v` prefix is allowed
+// (e.g. `v1.2.3`).
+func ParseSemver(s string) (v Semver, ok bool) {
+	s = strings.TrimPrefix(s, "v")
+
+	s, v.Build, ok = strings.Cut(s, "+")
+	if ok && !validSemverIdents(v.Build, false) {
+		return Semver{}, false
+	}
+
+	s, v.Pre, ok = strings.Cut(s, "-")
+	if ok && !validSemverIdents(v.Pre, true) {
+		return Semver{}, false
+	}
+
+	var major, minor, patch string
+	major, s, _ = strings.Cut(s, ".")
+	minor, patch, _ = strings.Cut(s, ".")
+	for _, x := range [3]struct {
+		str string
+		out *uint64
+	}{{major, &v.Major}, {minor, &v.Minor}, {patch, &v.Patch}} {
+		if !isSemverNumber(x.str) {
+			return Semver{}, false
+		}
+
+		n, err := strconv.ParseUint(x.str, 10, 64)
+		if err != nil {
+			return Semver{}, false
+		}
+		*x.out = n
+	}
+
+	return v, true
+}
+
+// String returns v in format `MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]`.
+func (v Semver) String() string {
+	buf := strconv.AppendUint(nil, v.Major, 10)
+	buf = append(buf, '.')
+	buf = strconv.AppendUint(buf, v.Minor, 10)
+	buf = append(buf, '.')
+	buf = strconv.AppendUint(buf, v.Patch, 10)
+	if len(v.Pre) != 0 {
+		buf = append(buf, '-')
+		buf = append(buf, v.Pre...)
+	}
+	if len(v.Build) != 0 {
+		buf = append(buf, '+')
+		buf = append(buf, v.Build...)
+	}
+
+	return string(buf)
+}
+
+// Compare returns -1, 0 or +1 when v has lower, the same or higher
+// precedence than o, build metadata is ignored.
+func (v Semver) Compare(o Semver) int {
+	for _, x := range [3][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		switch {
+		case x[0] < x[1]:
+			return -1
+		case x[0] > x[1]:
+			return 1
+		}
+	}
+
+	// a version without pre-release has higher precedence.
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+
+	a, b := v.Pre, o.Pre
+	for len(a) != 0 && len(b) != 0 {
+		var x, y string
+		x, a, _ = strings.Cut(a, ".")
+		y, b, _ = strings.Cut(b, ".")
+		if c := compareSemverIdent(x, y); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(a) != 0:
+		return 1
+	case len(b) != 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// compareSemverIdent compares pre-release identifiers, numeric identifiers
+// have lower precedence than alphanumeric ones.
+func compareSemverIdent(x, y string) int {
+	xnum, ynum := isSemverNumber(x), isSemverNumber(y)
+	switch {
+	case xnum && ynum:
+		if len(x) != len(y) { // no leading zeros
+			if len(x) < len(y) {
+				return -1
+			}
+			return 1
+		}
+	case xnum:
+		return -1
+	case ynum:
+		return 1
+	}
+
+	return strings.Compare(x, y)
+}
+
+// isSemverNumber returns true if s is a non-empty decimal number without
+// leading zeros.
+func isSemverNumber(s string) bool {
+	if len(s) == 0 || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validSemverIdents returns true if s is dot separated non-empty
+// identifiers of [0-9A-Za-z-], when pre is true, numeric identifiers
+// MUST NOT have leading zeros.
+func validSemverIdents(s string, pre bool) bool {
+	for {
+		var ident string
+		ident, s, _ = strings.Cut(s, ".")
+		if len(ident) == 0 {
+			return false
+		}
+
+		digits := true
+		for i := 0; i < len(ident); i++ {
+			switch c := ident[i]; {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				digits = false
+			default:
+				return false
+			}
+		}
+
+		if pre && digits && !isSemverNumber(ident) {
+			return false
+		}
+
+		if len(s) == 0 {
+			return true
+		}
+	}
+}
+
+// VPSemver for semantic versions, the value is validated by ParseSemver
+// and stored in normalized form (Semver.String), use ParseSemver to get
+// the components of the stored value.
+type VPSemver[T ~string] struct{}
+
+func (VPSemver[T]) Type() VPType                                { return VPTypeString }
+func (VPSemver[T]) HasValue(v *T) bool                          { return v != nil && len(*v) != 0 }
+func (VPSemver[T]) PrintValue(out io.Writer, v *T) (int, error) { return wstr(out, string(*v)) }
+
+func (VPSemver[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	v, ok := ParseSemver(arg)
+	if !ok {
+		return &ErrInvalidValue{
+			Type:  "semver",
+			Value: arg,
+		}
+	}
+
+	if set {
+		*out = T(v.String())
+	}
+
+	return nil
+}
+
 // VPInt for types compatible with int{, 8, 16, 32, 64}.
 //
 // It uses strconv.ParseInt to parse args.
@@ -199,6 +555,156 @@ func (VPBool[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) er
 	return nil
 }
 
+// VPBoolWords for types compatible with bool, using custom words (e.g.
+// "allow" and "deny") in place of those accepted by VPBool.
+//
+// Args in True are considered true, args in False are considered false,
+// "true" and "false" are always accepted so that implied values (e.g.
+// `--flag` and inverted flags) keep working.
+//
+// All other values are invalid.
+type VPBoolWords[T ~bool] struct {
+	True  []string
+	False []string
+}
+
+func (VPBoolWords[T]) Type() VPType       { return VPTypeBool }
+func (VPBoolWords[T]) HasValue(v *T) bool { return v != nil && bool(*v) }
+
+// PrintValue writes the first word in True or False, it writes "true" or
+// "false" if there is no such word.
+func (vp VPBoolWords[T]) PrintValue(out io.Writer, v *T) (int, error) {
+	switch {
+	case bool(*v) && len(vp.True) != 0:
+		return wstr(out, vp.True[0])
+	case !bool(*v) && len(vp.False) != 0:
+		return wstr(out, vp.False[0])
+	}
+
+	return VPBool[T]{}.PrintValue(out, v)
+}
+
+// ValueChoices implements [VPChoices].
+func (vp VPBoolWords[T]) ValueChoices() []string {
+	return append(append([]string(nil), vp.True...), vp.False...)
+}
+
+func (vp VPBoolWords[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	switch {
+	case arg == "true" || sliceContains(vp.True, arg):
+		if set {
+			*out = true
+		}
+	case arg == "false" || sliceContains(vp.False, arg):
+		if set {
+			*out = false
+		}
+	default:
+		return &ErrInvalidValue{
+			Type:  "bool",
+			Value: arg,
+		}
+	}
+
+	return nil
+}
+
+// TriState is the value type of VPTriState.
+type TriState uint8
+
+const (
+	TriStateAuto TriState = iota
+	TriStateOn
+	TriStateOff
+)
+
+// String returns one of "auto", "on" and "off".
+func (s TriState) String() string {
+	switch s {
+	case TriStateOn:
+		return "on"
+	case TriStateOff:
+		return "off"
+	default:
+		return "auto"
+	}
+}
+
+// VPTriState for types compatible with TriState.
+//
+// These args are considered on: "on", "true", "yes", "1"
+// These args are considered off: "off", "false", "no", "0"
+// The arg "auto" is considered auto.
+//
+// All other values are invalid.
+type VPTriState[T ~uint8] struct{}
+
+func (VPTriState[T]) Type() VPType       { return VPTypeTriState }
+func (VPTriState[T]) HasValue(v *T) bool { return v != nil && TriState(*v) != TriStateAuto }
+
+func (VPTriState[T]) PrintValue(out io.Writer, v *T) (int, error) {
+	return wstr(out, TriState(*v).String())
+}
+
+// ValueChoices implements [VPChoices].
+func (VPTriState[T]) ValueChoices() []string { return []string{"on", "off", "auto"} }
+
+func (VPTriState[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	var v TriState
+	switch arg {
+	case "on", "true", "yes", "1":
+		v = TriStateOn
+	case "off", "false", "no", "0":
+		v = TriStateOff
+	case "auto":
+		v = TriStateAuto
+	default:
+		return &ErrInvalidValue{
+			Type:  "tristate",
+			Value: arg,
+		}
+	}
+
+	if set {
+		*out = T(v)
+	}
+
+	return nil
+}
+
+// VPQuantity for Kubernetes style quantities (e.g. "100m", "2Gi", "500M"),
+// the value is stored in milli-units (e.g. "2" is stored as 2000).
+//
+// Supported suffixes are binary (Ki, Mi, Gi, Ti, Pi), decimal (m, k, M, G,
+// T, P) and decimal exponent (e.g. "1e3"), non-integer milli-units are
+// rounded up. Suffixes E and Ei are rejected as one exa in milli-units
+// overflows int64.
+//
+// When printing, it uses the largest decimal suffix dividing the value, then
+// the largest binary suffix, otherwise the `m` suffix.
+type VPQuantity[T ~int64] struct{}
+
+func (VPQuantity[T]) Type() VPType       { return VPTypeQuantity }
+func (VPQuantity[T]) HasValue(v *T) bool { return v != nil && *v != 0 }
+
+func (VPQuantity[T]) PrintValue(out io.Writer, v *T) (int, error) {
+	var buf [32]byte
+	return out.Write(appendQuantity(buf[:0], int64(*v)))
+}
+
+func (VPQuantity[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) error {
+	v, err := parseQuantity(arg)
+	if err != nil {
+		return err
+	}
+
+	if set {
+		*out = T(v)
+	}
+
+	return nil
+}
+
 // VPRegexp for types compatible regexp.Regexp.
 //
 // When parsing, it compiles the arg as a regular expression using
@@ -424,12 +930,7 @@ func (VPDuration[T]) PrintValue(out io.Writer, v *T) (int, error) {
 }
 
 func (VPDuration[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
-	var t time.Time
-	if opts == nil || opts.StartTime.IsZero() {
-		t = time.Now()
-	} else {
-		t = opts.StartTime
-	}
+	t := opts.baseTime()
 
 	neg, x, err := parseDuration(arg, t)
 	if err != nil {
@@ -468,12 +969,7 @@ func (VPUnixSec[T]) PrintValue(out io.Writer, value *T) (int, error) {
 }
 
 func (VPUnixSec[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
-	var t time.Time
-	if opts == nil || opts.StartTime.IsZero() {
-		t = time.Now()
-	} else {
-		t = opts.StartTime
-	}
+	t := opts.baseTime()
 
 	t, err = parseTime(arg, t)
 	if err != nil {
@@ -498,12 +994,7 @@ func (VPUnixMilli[T]) PrintValue(out io.Writer, value *T) (int, error) {
 }
 
 func (VPUnixMilli[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
-	var t time.Time
-	if opts != nil || opts.StartTime.IsZero() {
-		t = opts.StartTime
-	} else {
-		t = time.Now()
-	}
+	t := opts.baseTime()
 
 	t, err = parseTime(arg, t)
 	if err != nil {
@@ -528,12 +1019,7 @@ func (VPUnixMicro[T]) PrintValue(out io.Writer, value *T) (int, error) {
 }
 
 func (VPUnixMicro[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
-	var t time.Time
-	if opts != nil || opts.StartTime.IsZero() {
-		t = opts.StartTime
-	} else {
-		t = time.Now()
-	}
+	t := opts.baseTime()
 
 	t, err = parseTime(arg, t)
 	if err != nil {
@@ -558,12 +1044,7 @@ func (VPUnixNano[T]) PrintValue(out io.Writer, value *T) (int, error) {
 }
 
 func (VPUnixNano[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
-	var t time.Time
-	if opts != nil || opts.StartTime.IsZero() {
-		t = opts.StartTime
-	} else {
-		t = time.Now()
-	}
+	t := opts.baseTime()
 
 	t, err = parseTime(arg, t)
 	if err != nil {
@@ -586,7 +1067,7 @@ func (VPUnixNano[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool
 //   - 15
 //
 // To parse value, it uses opts.StartTime or time.Now() to fill missing
-// date parts.
+// date parts, in opts.TimeLocation if set.
 type VPTime[T time.Time] struct{}
 
 func (VPTime[T]) Type() VPType       { return VPTypeTime }
@@ -597,12 +1078,7 @@ func (VPTime[T]) PrintValue(out io.Writer, v *T) (int, error) {
 }
 
 func (VPTime[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (err error) {
-	var t time.Time
-	if opts == nil || opts.StartTime.IsZero() {
-		t = time.Now()
-	} else {
-		t = opts.StartTime
-	}
+	t := opts.baseTime()
 
 	t, err = parseTime(arg, t)
 	if err != nil {
@@ -615,12 +1091,29 @@ func (VPTime[T]) ParseValue(opts *ParseOptions, arg string, out *T, set bool) (e
 	return
 }
 
+// MapDupPolicy defines how to handle a map flag value with a key already
+// present in the map.
+type MapDupPolicy uint8
+
+const (
+	// MapDupLast merges the value into the existing one (overwrites scalar
+	// values), this is the default.
+	MapDupLast MapDupPolicy = iota
+	// MapDupFirst keeps the existing value and ignores the new one.
+	MapDupFirst
+	// MapDupError rejects the value with ErrDuplicateMapKey.
+	MapDupError
+)
+
 // VPMap wraps other VPs for parsing map[K]E types.
 //
 // It parses args as "key=value" pairs.
 type VPMap[K comparable, E any, KP VP[*K], EP VP[*E]] struct {
 	Key   KP
 	Value EP
+
+	// Dup is the policy for keys already present in the map.
+	Dup MapDupPolicy
 }
 
 func (m VPMap[K, E, KP, EP]) Type() VPType {
@@ -642,55 +1135,68 @@ func (m VPMap[K, E, KP, EP]) Type() VPType {
 
 func (m VPMap[K, E, KP, EP]) HasValue(v *map[K]E) bool { return v != nil && len(*v) != 0 }
 
+// PrintValue prints entries sorted by their printed keys.
 func (m VPMap[K, E, KP, EP]) PrintValue(out io.Writer, value *map[K]E) (n int, err error) {
 	var (
-		x int
-
-		afterFirst bool
+		sb      strings.Builder
+		entries = make([]printedMapEntry, 0, len(*value))
 	)
 
-	n, err = wstr(out, "[")
-	if err != nil {
-		return
-	}
-
 	for k, v := range *value {
-		if afterFirst {
-			x, err = wstr(out, ", ")
-			n += x
-			if err != nil {
-				return
-			}
-		}
-
-		x, err = m.Key.PrintValue(out, noescape(&k))
-		n += x
+		sb.Reset()
+		_, err = m.Key.PrintValue(&sb, noescape(&k))
 		if err != nil {
 			return
 		}
 
-		x, err = wstr(out, "=")
-		n += x
+		keyLen := sb.Len()
+		sb.WriteString("=")
+		_, err = m.Value.PrintValue(&sb, noescape(&v))
 		if err != nil {
 			return
 		}
 
-		x, err = m.Value.PrintValue(out, noescape(&v))
-		n += x
-		if err != nil {
-			return
+		entries = append(entries, printedMapEntry{text: sb.String(), keyLen: keyLen})
+	}
+
+	return writeMapEntries(out, entries)
+}
+
+// printedMapEntry is the `key=value` text of a map entry.
+type printedMapEntry struct {
+	text   string
+	keyLen int
+}
+
+func (e *printedMapEntry) key() string { return e.text[:e.keyLen] }
+
+// writeMapEntries writes entries as `[k1=v1, k2=v2]`, sorted by keys to
+// produce stable output.
+func writeMapEntries(out io.Writer, entries []printedMapEntry) (int, error) {
+	sort.Slice(entries, func(i, j int) bool {
+		if ki, kj := entries[i].key(), entries[j].key(); ki != kj {
+			return ki < kj
 		}
 
-		afterFirst = true
+		return entries[i].text < entries[j].text
+	})
+
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := range entries {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(entries[i].text)
 	}
+	sb.WriteString("]")
 
-	x, err = wstr(out, "]")
-	n += x
-	return
+	return wstr(out, sb.String())
 }
 
 func (m VPMap[K, E, KP, EP]) ParseValue(opts *ParseOptions, arg string, out *map[K]E, set bool) (err error) {
-	strKey, strVal, ok := strings.Cut(arg, "=")
+	strKey, strVal, ok := strings.Cut(arg, opts.mapAssignSep())
 	if !ok {
 		return &ErrInvalidValue{
 			Type:  "map",
@@ -709,9 +1215,17 @@ func (m VPMap[K, E, KP, EP]) ParseValue(opts *ParseOptions, arg string, out *map
 	}
 
 	if set {
-		m := *out
-		if m != nil {
-			val = m[key]
+		if mv := *out; mv != nil {
+			var exists bool
+			val, exists = mv[key]
+			if exists {
+				switch m.Dup {
+				case MapDupFirst:
+					return m.Value.ParseValue(opts, strVal, noescape(&val), false)
+				case MapDupError:
+					return &ErrDuplicateMapKey{Key: strKey}
+				}
+			}
 		}
 	}
 
@@ -731,6 +1245,70 @@ func (m VPMap[K, E, KP, EP]) ParseValue(opts *ParseOptions, arg string, out *map
 	return
 }
 
+// VPStringSetBool for map[K]bool types, it parses comma-separated keys and
+// sets each key true, keys with `-` prefix are set false.
+//
+// For example, arg "a,b,-c" sets {a: true, b: true, c: false}, values parsed
+// from multiple args are merged.
+//
+// PrintValue lists keys set true in sorted order.
+type VPStringSetBool[K ~string] struct{}
+
+func (VPStringSetBool[K]) Type() VPType {
+	return VPTypeVariantMap | VPTypeString<<VPTypeKeyScalarShift | VPTypeBool
+}
+
+func (VPStringSetBool[K]) HasValue(v *map[K]bool) bool { return v != nil && len(*v) != 0 }
+
+func (VPStringSetBool[K]) PrintValue(out io.Writer, v *map[K]bool) (int, error) {
+	keys := make([]string, 0, len(*v))
+	for k, on := range *v {
+		if on {
+			keys = append(keys, string(k))
+		}
+	}
+
+	sort.Strings(keys)
+	return wstr(out, strings.Join(keys, ","))
+}
+
+func (VPStringSetBool[K]) ParseValue(opts *ParseOptions, arg string, out *map[K]bool, set bool) error {
+	for rest := arg; ; {
+		var key string
+		key, rest, _ = strings.Cut(rest, ",")
+		if len(key) == 0 || key == "-" {
+			return &ErrInvalidValue{
+				Type:  "flagset",
+				Value: arg,
+			}
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+	}
+
+	if !set {
+		return nil
+	}
+
+	if *out == nil {
+		*out = make(map[K]bool)
+	}
+
+	for rest := arg; len(rest) != 0; {
+		var key string
+		key, rest, _ = strings.Cut(rest, ",")
+		if key[0] == '-' {
+			(*out)[K(key[1:])] = false
+		} else {
+			(*out)[K(key)] = true
+		}
+	}
+
+	return nil
+}
+
 // VPSlice wraps other VP for parsing []T types.
 //
 // It appends value parsed by the inner VP to []T.
@@ -797,6 +1375,231 @@ func (s VPSlice[E, EP]) ParseValue(opts *ParseOptions, arg string, out *[]E, set
 	return s.Elem.ParseValue(opts, arg, noescape(&tmp), set)
 }
 
+// VPIntRange for []T types, it parses comma-separated items where each item
+// is either a single non-negative integer or an inclusive range `lo-hi`, and
+// appends expanded integers to []T.
+//
+// For example, arg "0-3,5,7-8" appends [0, 1, 2, 3, 5, 7, 8].
+//
+// Reversed ranges (e.g. "3-1") are always rejected, overlapping items in
+// the same arg (e.g. "0-3,2") are rejected unless AllowOverlap is true.
+//
+// An arg expanding to more than MaxSpan integers is rejected.
+type VPIntRange[T integer] struct {
+	AllowOverlap bool
+
+	// MaxSpan is the max count of integers one arg can expand to.
+	//
+	// Defaults to 0 (use DefaultIntRangeMaxSpan).
+	MaxSpan int
+}
+
+// DefaultIntRangeMaxSpan is the default VPIntRange.MaxSpan.
+const DefaultIntRangeMaxSpan = 1 << 16
+
+func (VPIntRange[T]) Type() VPType         { return VPTypeInt | VPTypeVariantSlice }
+func (VPIntRange[T]) HasValue(v *[]T) bool { return v != nil && len(*v) != 0 }
+
+// PrintValue prints values in the same format accepted by ParseValue.
+func (VPIntRange[T]) PrintValue(out io.Writer, v *[]T) (n int, err error) {
+	var (
+		buf   []byte
+		slice = *v
+	)
+
+	for i := 0; i < len(slice); {
+		j := i + 1
+		for j < len(slice) && slice[j] == slice[j-1]+1 {
+			j++
+		}
+
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+
+		buf = appendInt(buf, slice[i])
+		if j-i > 1 {
+			buf = append(buf, '-')
+			buf = appendInt(buf, slice[j-1])
+		}
+
+		i = j
+	}
+
+	return out.Write(buf)
+}
+
+func (vp VPIntRange[T]) ParseValue(opts *ParseOptions, arg string, out *[]T, set bool) (err error) {
+	var (
+		ranges [][2]T
+		item   string
+
+		// count of integers expanded so far
+		total   uint64
+		maxSpan = uint64(DefaultIntRangeMaxSpan)
+	)
+
+	if vp.MaxSpan > 0 {
+		maxSpan = uint64(vp.MaxSpan)
+	}
+
+	for rest := arg; ; {
+		item, rest, _ = strings.Cut(rest, ",")
+
+		var lo, hi T
+		loStr, hiStr, isRange := strings.Cut(item, "-")
+		lo, err = parseRangeBound[T](loStr)
+		if err != nil {
+			return
+		}
+
+		hi = lo
+		if isRange {
+			hi, err = parseRangeBound[T](hiStr)
+			if err != nil {
+				return
+			}
+		}
+
+		if hi < lo {
+			return &ErrInvalidValue{Type: "range", Value: arg}
+		}
+
+		// hi-lo is non-negative, check it first to avoid overflow.
+		span := uint64(hi - lo)
+		if span >= maxSpan || total+span+1 > maxSpan {
+			return &ErrInvalidValue{Type: "range", Value: arg}
+		}
+		total += span + 1
+
+		if !vp.AllowOverlap {
+			for _, r := range ranges {
+				if lo <= r[1] && r[0] <= hi {
+					return &ErrInvalidValue{Type: "range", Value: arg}
+				}
+			}
+		}
+
+		ranges = append(ranges, [2]T{lo, hi})
+		if len(rest) == 0 {
+			break
+		}
+	}
+
+	if !set {
+		return nil
+	}
+
+	for _, r := range ranges {
+		for x := r[0]; ; x++ {
+			*out = append(*out, x)
+			if x == r[1] {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseRangeBound[T integer](s string) (ret T, err error) {
+	x, err := strconv.ParseUint(s, 10, int(unsafe.Sizeof(ret))*8)
+	if err != nil {
+		return
+	}
+
+	ret = T(x)
+	if ret < 0 || uint64(ret) != x {
+		return ret, strconv.ErrRange
+	}
+
+	return
+}
+
+func appendInt[T integer](buf []byte, v T) []byte {
+	if v < 0 {
+		return strconv.AppendInt(buf, int64(v), 10)
+	}
+
+	return strconv.AppendUint(buf, uint64(v), 10)
+}
+
+// Endpoint is a network endpoint in format `host:port`.
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+// String returns e in format `host:port` (`[host]:port` for IPv6 hosts).
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// ParseEndpoint parses s in format `host:port`, the host MUST NOT be empty
+// and the port MUST be a number in range [1, 65535].
+func ParseEndpoint(s string) (e Endpoint, ok bool) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil || len(host) == 0 {
+		return
+	}
+
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || n == 0 {
+		return
+	}
+
+	return Endpoint{Host: host, Port: int(n)}, true
+}
+
+// VPEndpointCSV for []Endpoint, it parses one arg of comma-separated
+// endpoints (e.g. "host1:8080,host2:9090") and appends them to []Endpoint.
+//
+// Every element is parsed by ParseEndpoint, nothing is appended if any of
+// them is invalid, the returned ErrInvalidValue contains the invalid one.
+type VPEndpointCSV[T Endpoint] struct{}
+
+func (VPEndpointCSV[T]) Type() VPType         { return VPTypeString | VPTypeVariantSlice }
+func (VPEndpointCSV[T]) HasValue(v *[]T) bool { return v != nil && len(*v) != 0 }
+
+// PrintValue prints values in the same format accepted by ParseValue.
+func (VPEndpointCSV[T]) PrintValue(out io.Writer, v *[]T) (int, error) {
+	var buf []byte
+	for i, e := range *v {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+
+		buf = append(buf, Endpoint(e).String()...)
+	}
+
+	return out.Write(buf)
+}
+
+func (VPEndpointCSV[T]) ParseValue(opts *ParseOptions, arg string, out *[]T, set bool) error {
+	var elem string
+	for rest, more := arg, true; more; {
+		elem, rest, more = strings.Cut(rest, ",")
+		if _, ok := ParseEndpoint(elem); !ok {
+			return &ErrInvalidValue{
+				Type:  "endpoint",
+				Value: elem,
+			}
+		}
+	}
+
+	if !set {
+		return nil
+	}
+
+	for rest, more := arg, true; more; {
+		elem, rest, more = strings.Cut(rest, ",")
+		e, _ := ParseEndpoint(elem)
+		*out = append(*out, T(e))
+	}
+
+	return nil
+}
+
 // VPPointer wraps other VP for parsing *T types.
 type VPPointer[T any, P VP[*T]] struct{ Elem P }
 
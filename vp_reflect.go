@@ -44,6 +44,43 @@ func (VPReflectBool) ParseValue(opts *ParseOptions, arg string, value *reflect.V
 	return
 }
 
+// VPReflectBoolWords is the reflect version of VPBoolWords.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectBoolWords struct {
+	True  []string
+	False []string
+}
+
+func (VPReflectBoolWords) Type() VPType                   { return VPTypeBool }
+func (VPReflectBoolWords) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (vp VPReflectBoolWords) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := v.Bool()
+	return VPBoolWords[bool](vp).PrintValue(out, noescape(&tmp))
+}
+
+// ValueChoices implements [VPChoices].
+func (vp VPReflectBoolWords) ValueChoices() []string { return VPBoolWords[bool](vp).ValueChoices() }
+
+func (vp VPReflectBoolWords) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp bool
+	err = VPBoolWords[bool](vp).ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetBool(tmp)
+	return
+}
+
 // VPReflectString is the reflect version of VPString.
 //
 // It accepts arbitrary depth of pointers.
@@ -75,6 +112,130 @@ func (VPReflectString) ParseValue(opts *ParseOptions, arg string, value *reflect
 	return
 }
 
+// VPReflectSecretSource is the reflect version of VPSecretSource.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectSecretSource struct{}
+
+func (VPReflectSecretSource) Type() VPType                   { return VPTypeString }
+func (VPReflectSecretSource) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectSecretSource) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := v.String()
+	return VPSecretSource[string]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectSecretSource) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp string
+	err = VPSecretSource[string]{}.ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetString(tmp)
+	return
+}
+
+// VPReflectEmail is the reflect version of VPEmail.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectEmail struct{}
+
+func (VPReflectEmail) Type() VPType                   { return VPTypeString }
+func (VPReflectEmail) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectEmail) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := v.String()
+	return VPEmail[string]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectEmail) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp string
+	err = VPEmail[string]{}.ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetString(tmp)
+	return
+}
+
+// VPReflectGlob is the reflect version of VPGlob.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectGlob struct{}
+
+func (VPReflectGlob) Type() VPType                   { return VPTypeString }
+func (VPReflectGlob) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectGlob) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := v.String()
+	return VPGlob[string]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectGlob) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp string
+	err = VPGlob[string]{}.ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetString(tmp)
+	return
+}
+
+// VPReflectSemver is the reflect version of VPSemver.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectSemver struct{}
+
+func (VPReflectSemver) Type() VPType                   { return VPTypeString }
+func (VPReflectSemver) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectSemver) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := v.String()
+	return VPSemver[string]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectSemver) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp string
+	err = VPSemver[string]{}.ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetString(tmp)
+	return
+}
+
 // VPReflectInt is the reflect version of VPInt.
 //
 // It accepts arbitrary depth of pointers.
@@ -180,6 +341,96 @@ func (VPReflectFloat) ParseValue(opts *ParseOptions, arg string, value *reflect.
 	return
 }
 
+// VPReflectQuantity is the reflect version of VPQuantity.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectQuantity struct{}
+
+func (VPReflectQuantity) Type() VPType                   { return VPTypeQuantity }
+func (VPReflectQuantity) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectQuantity) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := v.Int()
+	return VPQuantity[int64]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectQuantity) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp int64
+	err = VPQuantity[int64]{}.ParseValue(opts, arg, noescape(&tmp), true)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetInt(tmp)
+	return
+}
+
+// VPReflectEnum is the reflect version of VPEnum.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectEnum struct{ Choices []string }
+
+func (VPReflectEnum) Type() VPType                   { return VPTypeString }
+func (VPReflectEnum) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectEnum) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	return VPReflectString{}.PrintValue(out, value)
+}
+
+// ValueChoices implements [VPChoices].
+func (vp VPReflectEnum) ValueChoices() []string { return vp.Choices }
+
+func (vp VPReflectEnum) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp string
+	err = VPEnum[string]{Choices: vp.Choices}.ParseValue(opts, arg, noescape(&tmp), false)
+	if err != nil {
+		return
+	}
+
+	return VPReflectString{}.ParseValue(opts, arg, value, set)
+}
+
+// VPReflectTriState is the reflect version of VPTriState.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectTriState struct{}
+
+func (VPReflectTriState) Type() VPType                   { return VPTypeTriState }
+func (VPReflectTriState) HasValue(v *reflect.Value) bool { return reflectHasValue(v) }
+
+func (VPReflectTriState) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := TriState(v.Uint())
+	return VPTriState[TriState]{}.PrintValue(out, noescape(&tmp))
+}
+
+// ValueChoices implements [VPChoices].
+func (VPReflectTriState) ValueChoices() []string { return VPTriState[TriState]{}.ValueChoices() }
+
+func (VPReflectTriState) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp TriState
+	err = VPTriState[TriState]{}.ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	v := *value
+	_, v = prepareRValue(v.Type(), value, set)
+	v.SetUint(uint64(tmp))
+	return
+}
+
 // VPReflectSize is the reflect version of VPSize.
 //
 // It accepts arbitrary depth of pointers.
@@ -606,6 +857,197 @@ func (vp VPReflectSum[P]) ParseValue(opts *ParseOptions, arg string, value *refl
 	return
 }
 
+// VPReflectNonNeg wraps other VP to reject negative numeric values.
+//
+// It only works with scalar values.
+type VPReflectNonNeg[P VP[*reflect.Value]] struct{ VP P }
+
+func (vp VPReflectNonNeg[P]) Type() VPType                   { return vp.VP.Type() }
+func (vp VPReflectNonNeg[P]) HasValue(v *reflect.Value) bool { return vp.VP.HasValue(v) }
+
+func (vp VPReflectNonNeg[P]) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	return vp.VP.PrintValue(out, value)
+}
+
+func (vp VPReflectNonNeg[P]) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	if value == nil || !value.IsValid() {
+		return vp.VP.ParseValue(opts, arg, value, set)
+	}
+
+	// parse into a temporary value to check the sign without touching the
+	// actual value.
+	tmp := reflect.New(noptr(value.Type())).Elem()
+	err = vp.VP.ParseValue(opts, arg, noescape(&tmp), true)
+	if err != nil {
+		return
+	}
+
+	var neg bool
+	switch tmp.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		neg = tmp.Int() < 0
+	case reflect.Float32, reflect.Float64:
+		neg = tmp.Float() < 0
+	}
+
+	if neg {
+		return &ErrInvalidValue{
+			Type:  vp.VP.Type().String(),
+			Value: arg,
+		}
+	}
+
+	if !set {
+		return nil
+	}
+
+	return vp.VP.ParseValue(opts, arg, value, true)
+}
+
+// VPReflectClearOn wraps other VP to reset slice values to empty when the
+// arg equals to the Sentinel, other args are passed to the wrapped VP.
+//
+// It only works with slice values.
+type VPReflectClearOn[P VP[*reflect.Value]] struct {
+	VP       P
+	Sentinel string
+}
+
+func (vp VPReflectClearOn[P]) Type() VPType                   { return vp.VP.Type() }
+func (vp VPReflectClearOn[P]) HasValue(v *reflect.Value) bool { return vp.VP.HasValue(v) }
+
+func (vp VPReflectClearOn[P]) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	return vp.VP.PrintValue(out, value)
+}
+
+func (vp VPReflectClearOn[P]) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) error {
+	if arg != vp.Sentinel || value == nil || !value.IsValid() {
+		return vp.VP.ParseValue(opts, arg, value, set)
+	}
+
+	if !set {
+		return nil
+	}
+
+	typ, val := prepareRValue(value.Type(), value, true)
+	if typ.Kind() != reflect.Slice {
+		return vp.VP.ParseValue(opts, arg, value, set)
+	}
+
+	val.Set(reflect.MakeSlice(typ, 0, 2))
+	return nil
+}
+
+// VPReflectIntRange is the reflect version of VPIntRange.
+//
+// It only works with slices of integers (not pointers to integers), and
+// accepts arbitrary depth of pointers to the slice.
+//
+// AllowOverlap can only be set programmatically, the `value=range` tag
+// option of DefaultReflectVPFactory always rejects overlapping ranges.
+type VPReflectIntRange struct{ AllowOverlap bool }
+
+func (VPReflectIntRange) Type() VPType { return VPTypeInt | VPTypeVariantSlice }
+func (VPReflectIntRange) HasValue(v *reflect.Value) bool {
+	base, ok := reflectBaseValue(v)
+	return ok && base.Len() != 0
+}
+
+func (VPReflectIntRange) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := make([]int64, v.Len())
+	for i := range tmp {
+		switch elem := v.Index(i); elem.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			tmp[i] = elem.Int()
+		default:
+			tmp[i] = int64(elem.Uint())
+		}
+	}
+
+	return VPIntRange[int64]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (vp VPReflectIntRange) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp []int64
+	err = VPIntRange[int64]{AllowOverlap: vp.AllowOverlap}.ParseValue(opts, arg, noescape(&tmp), true)
+	if err != nil || !set {
+		return
+	}
+
+	typ, v := prepareRValue(value.Type(), value, set)
+	elem := reflect.New(typ.Elem()).Elem()
+	for _, x := range tmp {
+		switch elem.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if elem.OverflowInt(x) {
+				return strconv.ErrRange
+			}
+			elem.SetInt(x)
+		default:
+			if elem.OverflowUint(uint64(x)) {
+				return strconv.ErrRange
+			}
+			elem.SetUint(uint64(x))
+		}
+
+		v.Set(reflect.Append(v, elem))
+	}
+
+	return nil
+}
+
+// VPReflectStringSetBool is the reflect version of VPStringSetBool.
+//
+// It only works with map[K]bool where K is of kind string, and accepts
+// arbitrary depth of pointers.
+type VPReflectStringSetBool struct{}
+
+func (VPReflectStringSetBool) Type() VPType {
+	return VPStringSetBool[string]{}.Type()
+}
+
+func (VPReflectStringSetBool) HasValue(v *reflect.Value) bool {
+	base, ok := reflectBaseValue(v)
+	return ok && base.Len() != 0
+}
+
+func (VPReflectStringSetBool) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := make(map[string]bool, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		tmp[iter.Key().String()] = iter.Value().Bool()
+	}
+
+	return VPStringSetBool[string]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectStringSetBool) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp map[string]bool
+	err = VPStringSetBool[string]{}.ParseValue(opts, arg, noescape(&tmp), set)
+	if err != nil || !set {
+		return
+	}
+
+	typ, v := prepareRValue(value.Type(), value, set)
+	for k, on := range tmp {
+		v.SetMapIndex(
+			reflect.ValueOf(k).Convert(typ.Key()),
+			reflect.ValueOf(on).Convert(typ.Elem()),
+		)
+	}
+
+	return nil
+}
+
 // VPReflectSlice is the reflect version of VPSlice.
 //
 // It accepts arbitrary depth of pointers.
@@ -620,6 +1062,15 @@ func (vp VPReflectSlice[EP]) Type() VPType {
 	return ret | VPTypeVariantSlice
 }
 
+// ValueChoices implements [VPChoices] if vp.Elem implements it.
+func (vp VPReflectSlice[EP]) ValueChoices() []string {
+	if c, ok := any(vp.Elem).(VPChoices); ok {
+		return c.ValueChoices()
+	}
+
+	return nil
+}
+
 func (vp VPReflectSlice[EP]) HasValue(value *reflect.Value) bool {
 	v, ok := reflectBaseValue(value)
 	if !ok {
@@ -687,12 +1138,156 @@ func (vp VPReflectSlice[EP]) ParseValue(opts *ParseOptions, arg string, value *r
 	return nil
 }
 
+// VPReflectDurationCSV parses one arg of comma-separated durations (e.g.
+// "1s,2s,5s") and appends them to a slice of integers.
+//
+// Every element is decoded as VPReflectDuration does, nothing is appended
+// if any of them is invalid.
+//
+// It accepts arbitrary depth of pointers.
+type VPReflectDurationCSV struct{}
+
+func (VPReflectDurationCSV) Type() VPType { return VPTypeDuration | VPTypeVariantSlice }
+
+func (VPReflectDurationCSV) HasValue(value *reflect.Value) bool {
+	return VPReflectSlice[VPReflectDuration]{}.HasValue(value)
+}
+
+func (VPReflectDurationCSV) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	return VPReflectSlice[VPReflectDuration]{}.PrintValue(out, value)
+}
+
+func (VPReflectDurationCSV) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var (
+		elem  string
+		empty reflect.Value
+	)
+	for rest, more := arg, true; more; {
+		elem, rest, more = strings.Cut(rest, ",")
+		err = VPReflectDuration{}.ParseValue(opts, elem, noescape(&empty), false)
+		if err != nil {
+			return &ErrInvalidValue{
+				Type:  "dur",
+				Value: elem,
+			}
+		}
+	}
+
+	if !set {
+		return nil
+	}
+
+	for rest, more := arg, true; more; {
+		elem, rest, more = strings.Cut(rest, ",")
+		err = VPReflectSlice[VPReflectDuration]{}.ParseValue(opts, elem, value, true)
+		if err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// VPReflectEndpointCSV is the reflect version of VPEndpointCSV.
+//
+// It only works with slices of structs having a `Host` field of kind string
+// and a `Port` field of integer kind (e.g. []Endpoint), and accepts
+// arbitrary depth of pointers.
+type VPReflectEndpointCSV struct{}
+
+func (VPReflectEndpointCSV) Type() VPType { return VPTypeString | VPTypeVariantSlice }
+func (VPReflectEndpointCSV) HasValue(v *reflect.Value) bool {
+	base, ok := reflectBaseValue(v)
+	return ok && base.Len() != 0
+}
+
+func (VPReflectEndpointCSV) PrintValue(out io.Writer, value *reflect.Value) (int, error) {
+	v, ok := reflectBaseValue(value)
+	if !ok {
+		return 0, nil
+	}
+
+	tmp := make([]Endpoint, v.Len())
+	for i := range tmp {
+		elem := v.Index(i)
+		tmp[i].Host = elem.FieldByName("Host").String()
+		switch port := elem.FieldByName("Port"); port.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			tmp[i].Port = int(port.Int())
+		default:
+			tmp[i].Port = int(port.Uint())
+		}
+	}
+
+	return VPEndpointCSV[Endpoint]{}.PrintValue(out, noescape(&tmp))
+}
+
+func (VPReflectEndpointCSV) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
+	var tmp []Endpoint
+	err = VPEndpointCSV[Endpoint]{}.ParseValue(opts, arg, noescape(&tmp), true)
+	if err != nil || !set {
+		return
+	}
+
+	typ, v := prepareRValue(value.Type(), value, set)
+	elem := reflect.New(typ.Elem()).Elem()
+	for _, e := range tmp {
+		elem.FieldByName("Host").SetString(e.Host)
+		switch port := elem.FieldByName("Port"); port.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if port.OverflowInt(int64(e.Port)) {
+				return strconv.ErrRange
+			}
+			port.SetInt(int64(e.Port))
+		default:
+			if port.OverflowUint(uint64(e.Port)) {
+				return strconv.ErrRange
+			}
+			port.SetUint(uint64(e.Port))
+		}
+
+		v.Set(reflect.Append(v, elem))
+	}
+
+	return nil
+}
+
+// isEndpointStruct returns true if typ is a struct type with a `Host` field
+// of kind string and a `Port` field of integer kind.
+func isEndpointStruct(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	host, ok := typ.FieldByName("Host")
+	if !ok || !host.IsExported() || host.Type.Kind() != reflect.String {
+		return false
+	}
+
+	port, ok := typ.FieldByName("Port")
+	if !ok || !port.IsExported() {
+		return false
+	}
+
+	switch port.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		return false
+	}
+
+	return true
+}
+
 // VPReflectMap is the reflect version of VPMap.
 //
 // It accepts arbitrary depth of pointers.
 type VPReflectMap[K, V VP[*reflect.Value]] struct {
 	Key  K
 	Elem V
+
+	// Dup is the policy for keys already present in the map.
+	Dup MapDupPolicy
 }
 
 func (vp VPReflectMap[K, V]) Type() VPType {
@@ -720,6 +1315,7 @@ func (vp VPReflectMap[K, V]) HasValue(value *reflect.Value) bool {
 	return !v.IsZero() && v.Len() != 0
 }
 
+// PrintValue prints entries sorted by their printed keys.
 func (vp VPReflectMap[K, V]) PrintValue(out io.Writer, value *reflect.Value) (n int, err error) {
 	v, ok := reflectBaseValue(value)
 	if !ok {
@@ -727,57 +1323,36 @@ func (vp VPReflectMap[K, V]) PrintValue(out io.Writer, value *reflect.Value) (n
 	}
 
 	var (
-		x int
-
-		key, val   reflect.Value
-		iter       = v.MapRange()
-		afterFirst bool
+		sb       strings.Builder
+		key, val reflect.Value
+		iter     = v.MapRange()
+		entries  = make([]printedMapEntry, 0, v.Len())
 	)
 
-	n, err = wstr(out, "[")
-	if err != nil {
-		return
-	}
-
 	for iter.Next() {
-		if afterFirst {
-			x, err = wstr(out, ", ")
-			n += x
-			if err != nil {
-				return
-			}
-		}
-
-		key.SetIterKey(iter)
-		x, err = vp.Key.PrintValue(out, noescape(&key))
-		n += x
+		sb.Reset()
+		key = iter.Key()
+		_, err = vp.Key.PrintValue(&sb, noescape(&key))
 		if err != nil {
 			return
 		}
 
-		x, err = wstr(out, "=")
-		n += x
-		if err != nil {
-			return
-		}
-
-		val.SetIterValue(iter)
-		x, err = vp.Elem.PrintValue(out, noescape(&val))
-		n += x
+		keyLen := sb.Len()
+		sb.WriteString("=")
+		val = iter.Value()
+		_, err = vp.Elem.PrintValue(&sb, noescape(&val))
 		if err != nil {
 			return
 		}
 
-		afterFirst = true
+		entries = append(entries, printedMapEntry{text: sb.String(), keyLen: keyLen})
 	}
 
-	x, err = wstr(out, "]")
-	n += x
-	return
+	return writeMapEntries(out, entries)
 }
 
 func (vp VPReflectMap[K, V]) ParseValue(opts *ParseOptions, arg string, value *reflect.Value, set bool) (err error) {
-	strKey, strVal, ok := strings.Cut(arg, "=")
+	strKey, strVal, ok := strings.Cut(arg, opts.mapAssignSep())
 	if !ok {
 		return &ErrInvalidValue{
 			Type:  "map",
@@ -806,6 +1381,13 @@ func (vp VPReflectMap[K, V]) ParseValue(opts *ParseOptions, arg string, value *r
 		val = reflect.New(maptyp.Elem()).Elem()
 		tmp := mapval.MapIndex(key)
 		if tmp.IsValid() {
+			switch vp.Dup {
+			case MapDupFirst:
+				return vp.Elem.ParseValue(opts, strVal, noescape(&val), false)
+			case MapDupError:
+				return &ErrDuplicateMapKey{Key: strKey}
+			}
+
 			val.Set(tmp)
 		}
 	}